@@ -0,0 +1,267 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package htlc implements a hash time-locked conditional payment channel app.
+// Funds are locked under the hash of a secret preimage and are paid to the
+// receiver when the preimage is revealed or refunded to the sender after an
+// expiry time. It can be used for atomic swaps and multi-hop payments.
+//
+// Locked funds are held in a locked sub-allocation of the channel's
+// allocation. Expiries are compared with the time stored in the app data, so
+// that the validity of transitions does not depend on the verifier's clock.
+// Since the actor of an update sets this time, the App is a channel.TimedApp:
+// clients reject times that are far off their clock and adjudicators do not
+// progress to states whose time lies in their future, so that locks cannot be
+// refunded on-chain before they expired.
+package htlc // import "perun.network/go-perun/apps/htlc"
+
+import (
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+)
+
+// App is the HTLC app.
+type App struct {
+	Addr wallet.Address
+}
+
+var (
+	_ channel.TimedApp  = (*App)(nil)
+	_ channel.SettleApp = (*App)(nil)
+)
+
+// Def returns the address of this HTLC app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes HTLC Data from r.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	d := new(Data)
+	return d, d.Decode(r)
+}
+
+// ValidInit checks that the initial state has valid locks that expire after the
+// state's time and no revealed preimages.
+func (a *App) ValidInit(_ *channel.Params, s *channel.State) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	if len(d.Preimages) > 0 {
+		return errors.New("initial state must not reveal preimages")
+	}
+	for _, l := range d.Locks {
+		if l.Expiry <= d.Time {
+			return errors.Errorf("lock %x expired at %d before time %d", l.Hash, l.Expiry, d.Time)
+		}
+	}
+	return validLocks(s.ID, s.Allocation, d.Locks)
+}
+
+// ValidTransition checks that the transition from `from` to `to` only
+//   - adds locks with the actor as sender that expire after the new state's
+//     time, moving their amounts from the sender's balance to the locked funds,
+//   - removes locks by revealing their preimages, paying the receivers,
+//   - removes locks that expired at the new state's time or locks cancelled by
+//     the actor as their receiver, refunding the senders, and
+//   - transfers unlocked funds from the actor to the other participants.
+//
+// Locks that are not removed must not change and the locked funds must match
+// the pending locks. The time must not decrease.
+func (a *App) ValidTransition(_ *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromData, err := data(from)
+	if err != nil {
+		return err
+	}
+	toData, err := data(to)
+	if err != nil {
+		return err
+	}
+	if toData.Time < fromData.Time {
+		return errors.Errorf("time decreased from %d to %d", fromData.Time, toData.Time)
+	}
+	if err := validLocks(to.ID, to.Allocation, toData.Locks); err != nil {
+		return err
+	}
+
+	expected := from.Allocation.Clone().Balances
+	revealed := make(map[Hash]bool, len(toData.Preimages))
+	for _, p := range toData.Preimages {
+		revealed[HashPreimage(p)] = true
+	}
+	for _, l := range fromData.Locks {
+		if kept, ok := toData.Lock(l.Hash); ok {
+			if !kept.Equal(l) {
+				return errors.Errorf("lock %x changed", l.Hash)
+			}
+			continue
+		}
+		switch {
+		case revealed[l.Hash]:
+			expected[l.Asset][l.Receiver].Add(expected[l.Asset][l.Receiver], l.Amount)
+		case toData.Time >= l.Expiry || actor == l.Receiver:
+			expected[l.Asset][l.Sender].Add(expected[l.Asset][l.Sender], l.Amount)
+		default:
+			return errors.Errorf("lock %x removed before expiry without preimage by sender", l.Hash)
+		}
+	}
+	for _, l := range toData.Locks {
+		if _, ok := fromData.Lock(l.Hash); ok {
+			continue
+		}
+		if l.Sender != actor {
+			return errors.Errorf("lock %x added by %d instead of sender %d", l.Hash, actor, l.Sender)
+		}
+		if l.Expiry <= toData.Time {
+			return errors.Errorf("lock %x expires at %d before time %d", l.Hash, l.Expiry, toData.Time)
+		}
+		expected[l.Asset][l.Sender].Sub(expected[l.Asset][l.Sender], l.Amount)
+	}
+
+	for i, bals := range expected {
+		for j, bal := range bals {
+			if int(actor) == j && bal.Cmp(to.Balances[i][j]) == -1 {
+				return errors.Errorf("payer[%d] steals asset %d, so %d < %d", j, i, bal, to.Balances[i][j])
+			} else if int(actor) != j && bal.Cmp(to.Balances[i][j]) == 1 {
+				return errors.Errorf("payer[%d] reduces participant[%d]'s asset %d", actor, j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// StateTime returns the time of state s, see Data.Time.
+func (a *App) StateTime(s *channel.State) (time.Time, error) {
+	d, err := data(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(d.Time), 0), nil
+}
+
+// Settle returns the balances of state s with the pending locks paid out at
+// time now. A lock whose preimage is revealed in s is paid to its receiver and
+// an expired lock is refunded to its sender. Since the receiver can reveal the
+// preimage on-chain until the lock expires, an error is returned if a lock is
+// neither revealed nor expired.
+func (a *App) Settle(_ *channel.Params, s *channel.State, now time.Time) ([][]channel.Bal, error) {
+	d, err := data(s)
+	if err != nil {
+		return nil, err
+	}
+	revealed := make(map[Hash]bool, len(d.Preimages))
+	for _, p := range d.Preimages {
+		revealed[HashPreimage(p)] = true
+	}
+	bals := s.Allocation.Clone().Balances
+	for _, l := range d.Locks {
+		switch {
+		case revealed[l.Hash]:
+			bals[l.Asset][l.Receiver].Add(bals[l.Asset][l.Receiver], l.Amount)
+		case uint64(now.Unix()) >= l.Expiry:
+			bals[l.Asset][l.Sender].Add(bals[l.Asset][l.Sender], l.Amount)
+		default:
+			return nil, errors.Errorf("lock %x pending until %v", l.Hash, time.Unix(int64(l.Expiry), 0))
+		}
+	}
+	return bals, nil
+}
+
+// LockedAlloc returns the sub-allocations that hold the funds of the locks in a
+// state of the channel with ID id and numAssets assets. All locked funds are
+// held in a single sub-allocation with the channel's own ID. They are paid out
+// by adjudicators with Settle if the channel is settled with pending locks.
+// It returns nil if there are no locks.
+func LockedAlloc(id channel.ID, numAssets int, locks []Lock) []channel.SubAlloc {
+	if len(locks) == 0 {
+		return nil
+	}
+	bals := make([]channel.Bal, numAssets)
+	for i := range bals {
+		bals[i] = new(big.Int)
+	}
+	for _, l := range locks {
+		bals[l.Asset].Add(bals[l.Asset], l.Amount)
+	}
+	return []channel.SubAlloc{{ID: id, Bals: bals}}
+}
+
+// data returns the HTLC Data of state s.
+func data(s *channel.State) (*Data, error) {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		return nil, errors.Errorf("htlc app must have Data, has type %T", s.Data)
+	}
+	return d, nil
+}
+
+// validLocks checks that the locks have unique hashes and valid indices and
+// amounts, that the locked funds of allocation alloc of channel id match the
+// locks and that no balance is negative.
+func validLocks(id channel.ID, alloc channel.Allocation, locks []Lock) error {
+	if len(locks) > MaxNumLocks {
+		return errors.Errorf("too many locks: %d", len(locks))
+	}
+	hashes := make(map[Hash]bool, len(locks))
+	for _, l := range locks {
+		if hashes[l.Hash] {
+			return errors.Errorf("duplicate lock %x", l.Hash)
+		}
+		hashes[l.Hash] = true
+		if err := l.valid(alloc.Balances); err != nil {
+			return err
+		}
+	}
+
+	want := LockedAlloc(id, len(alloc.Balances), locks)
+	if len(alloc.Locked) != len(want) {
+		return errors.Errorf("%d sub-allocations for locked funds, expected %d", len(alloc.Locked), len(want))
+	}
+	for i := range want {
+		if err := alloc.Locked[i].Equal(&want[i]); err != nil {
+			return errors.WithMessage(err, "locked funds do not match locks")
+		}
+	}
+	for i, bals := range alloc.Balances {
+		for j, bal := range bals {
+			if bal.Sign() < 0 {
+				return errors.Errorf("participant %d has negative balance %v of asset %d", j, bal, i)
+			}
+		}
+	}
+	return nil
+}
+
+// valid checks that the lock has valid asset and participant indices into
+// balances and a positive amount.
+func (l Lock) valid(balances [][]channel.Bal) error {
+	if l.Asset < 0 || l.Asset >= len(balances) {
+		return errors.Errorf("lock %x: invalid asset index %d", l.Hash, l.Asset)
+	}
+	numParts := len(balances[l.Asset])
+	if int(l.Sender) >= numParts || int(l.Receiver) >= numParts || l.Sender == l.Receiver {
+		return errors.Errorf("lock %x: invalid sender %d or receiver %d", l.Hash, l.Sender, l.Receiver)
+	}
+	if l.Amount == nil || l.Amount.Sign() <= 0 {
+		return errors.Errorf("lock %x: amount must be positive", l.Hash)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	pkgtest "perun.network/go-perun/pkg/test"
+)
+
+// newState returns a two-party state of one asset at the given time with the
+// given free balances and locks.
+func newState(time uint64, bal0, bal1 int64, locks ...Lock) *channel.State {
+	s := &channel.State{
+		Allocation: channel.Allocation{
+			Balances: [][]channel.Bal{{big.NewInt(bal0), big.NewInt(bal1)}},
+		},
+		Data: &Data{Time: time, Locks: locks},
+	}
+	s.Locked = LockedAlloc(s.ID, 1, locks)
+	return s
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	app := new(App)
+	lock := Lock{Hash: HashPreimage(NewRandomPreimage(rng)), Amount: big.NewInt(10), Receiver: 1, Expiry: 100}
+
+	assert.NoError(t, app.ValidInit(nil, newState(0, 10, 10)))
+	assert.NoError(t, app.ValidInit(nil, newState(0, 10, 10, lock)))
+	assert.Error(t, app.ValidInit(nil, newState(100, 10, 10, lock)), "expired lock")
+	assert.Error(t, app.ValidInit(nil, newState(0, 10, 10, lock, lock)), "duplicate lock")
+	assert.Error(t, app.ValidInit(nil, &channel.State{Data: new(channel.MockOp)}), "wrong data")
+
+	unlocked := newState(0, 10, 10, lock)
+	unlocked.Locked = nil
+	assert.Error(t, app.ValidInit(nil, unlocked), "funds not locked")
+
+	s := newState(0, 10, 10)
+	s.Data.(*Data).Preimages = []Preimage{NewRandomPreimage(rng)}
+	assert.Error(t, app.ValidInit(nil, s), "revealed preimage")
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	app := new(App)
+	preimage := NewRandomPreimage(rng)
+	lock := Lock{Hash: HashPreimage(preimage), Amount: big.NewInt(30), Receiver: 1, Expiry: 100}
+
+	changed := lock.Clone()
+	changed.Amount.SetInt64(20)
+	unlocked := newState(50, 70, 130)
+	unlocked.Data.(*Data).Preimages = []Preimage{preimage}
+	wrongPreimage := newState(50, 70, 130)
+	wrongPreimage.Data.(*Data).Preimages = []Preimage{NewRandomPreimage(rng)}
+	notLocked := newState(50, 100, 100, lock)
+	notLocked.Locked = nil
+
+	tests := []struct {
+		desc     string
+		from, to *channel.State
+		valid    []bool // validity per actor
+	}{
+		{"add lock", newState(50, 100, 100), newState(50, 70, 100, lock), []bool{true, false}},
+		{"add lock without moving funds", newState(50, 100, 100), notLocked, []bool{false, false}},
+		{"add uncovered lock", newState(50, 20, 100), newState(50, -10, 100, lock), []bool{false, false}},
+		{"add expired lock", newState(100, 100, 100), newState(100, 70, 100, lock), []bool{false, false}},
+		{"decrease time", newState(60, 100, 100), newState(50, 100, 100), []bool{false, false}},
+		{"change lock", newState(50, 70, 100, lock), newState(50, 80, 100, changed), []bool{false, false}},
+		{"unlock", newState(50, 70, 100, lock), unlocked, []bool{true, true}},
+		{"unlock wrong preimage", newState(50, 70, 100, lock), wrongPreimage, []bool{false, false}},
+		{"remove before expiry", newState(50, 70, 100, lock), newState(50, 100, 100), []bool{false, true}},
+		{"refund", newState(50, 70, 100, lock), newState(100, 100, 100), []bool{true, true}},
+		{"pay with lock", newState(50, 70, 100, lock), newState(50, 60, 110, lock), []bool{true, false}},
+		{"pay locked funds", newState(50, 70, 100, lock), newState(50, -10, 180, lock), []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		for actor, valid := range tt.valid {
+			err := app.ValidTransition(nil, tt.from, tt.to, channel.Index(actor))
+			if valid {
+				assert.NoErrorf(t, err, "%s by %d", tt.desc, actor)
+			} else {
+				assert.Errorf(t, err, "%s by %d", tt.desc, actor)
+			}
+		}
+	}
+
+	t.Run("wrong data", func(t *testing.T) {
+		to := newState(50, 100, 100)
+		to.Data = new(channel.MockOp)
+		assert.Error(t, app.ValidTransition(nil, newState(50, 100, 100), to, 0))
+	})
+}
+
+func TestLockHelpers(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	app := new(App)
+	preimage := NewRandomPreimage(rng)
+	now := time.Now()
+	lock := Lock{
+		Hash:     HashPreimage(preimage),
+		Amount:   big.NewInt(30),
+		Receiver: 1,
+		Expiry:   uint64(now.Add(time.Hour).Unix()),
+	}
+
+	from := newState(0, 100, 100)
+	require.NoError(t, SetTime(from, now))
+	expired := lock
+	expired.Expiry = uint64(now.Unix())
+	assert.Error(t, AddLock(from.Clone(), expired), "expired lock")
+	locked := from.Clone()
+	require.NoError(t, AddLock(locked, lock))
+	assert.NoError(t, app.ValidTransition(nil, from, locked, 0))
+	assert.Zero(t, locked.Balances[0][0].Cmp(big.NewInt(70)))
+	assert.Error(t, AddLock(locked.Clone(), lock), "duplicate lock")
+
+	assert.Error(t, Refund(locked.Clone(), lock.Hash), "refund before expiry")
+	refunded := locked.Clone()
+	require.NoError(t, SetTime(refunded, now.Add(2*time.Hour)))
+	require.NoError(t, Refund(refunded, lock.Hash))
+	assert.Empty(t, refunded.Data.(*Data).Locks)
+	assert.NoError(t, app.ValidTransition(nil, locked, refunded, 0))
+	assert.Zero(t, refunded.Balances[0][0].Cmp(big.NewInt(100)))
+	cancelled := locked.Clone()
+	require.NoError(t, Cancel(cancelled, lock.Hash))
+	assert.NoError(t, app.ValidTransition(nil, locked, cancelled, 1))
+	assert.Error(t, Cancel(cancelled, lock.Hash), "missing lock")
+
+	assert.Error(t, Unlock(locked.Clone(), NewRandomPreimage(rng)), "wrong preimage")
+	unlocked := locked.Clone()
+	require.NoError(t, Unlock(unlocked, preimage))
+	assert.NoError(t, app.ValidTransition(nil, locked, unlocked, 1))
+	assert.Zero(t, unlocked.Balances[0][0].Cmp(big.NewInt(70)))
+	assert.Zero(t, unlocked.Balances[0][1].Cmp(big.NewInt(130)))
+	assert.Empty(t, unlocked.Locked)
+
+	past := locked.Clone()
+	require.NoError(t, SetTime(past, now.Add(-time.Hour)))
+	assert.Equal(t, uint64(now.Unix()), past.Data.(*Data).Time, "time decreased")
+}
+
+func TestApp_Settle(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	app := new(App)
+	preimage := NewRandomPreimage(rng)
+	revealed := Lock{Hash: HashPreimage(preimage), Amount: big.NewInt(10), Receiver: 1, Expiry: 200}
+	expired := Lock{Hash: HashPreimage(NewRandomPreimage(rng)), Amount: big.NewInt(20), Receiver: 1, Expiry: 100}
+	s := newState(50, 60, 100, revealed, expired)
+	s.Data.(*Data).Preimages = []Preimage{preimage}
+
+	_, err := app.Settle(nil, s, time.Unix(99, 0))
+	assert.Error(t, err, "settling before expiry")
+	bals, err := app.Settle(nil, s, time.Unix(100, 0))
+	require.NoError(t, err)
+	assert.Equal(t, [][]channel.Bal{{big.NewInt(80), big.NewInt(110)}}, bals)
+	assert.Zero(t, s.Balances[0][0].Cmp(big.NewInt(60)), "state modified")
+}
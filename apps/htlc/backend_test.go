@@ -0,0 +1,43 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet/test"
+)
+
+func TestBackend(t *testing.T) {
+	pkgtest.OnlyOnce(t)
+	rng := pkgtest.Prng(t)
+
+	def := test.NewRandomAddress(rng)
+	assert.Panics(t, func() { AppDef() })
+	SetAppDef(def)
+	assert.True(t, def.Equals(AppDef()))
+	assert.True(t, def.Equals(new(Randomizer).NewRandomApp(rng).Def()))
+
+	app, err := channel.AppFromDefinition(def)
+	assert.NoError(t, err)
+	assert.Equal(t, &App{def}, app, "HTLC app should be registered in channel")
+	_, err = AppFromDefinition(test.NewRandomAddress(rng))
+	assert.Error(t, err)
+}
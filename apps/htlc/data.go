@@ -0,0 +1,191 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"crypto/sha256"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// MaxNumLocks is the maximum number of pending locks and revealed preimages in
+// the app data.
+const MaxNumLocks = 1024
+
+type (
+	// Data is the app data of an HTLC channel. It holds the pending hash
+	// time-locked payments.
+	Data struct {
+		// Time is the Unix time in seconds of the update that led to this
+		// state, as set by its actor. The expiries of locks are checked
+		// against it, so that the validity of a transition does not depend on
+		// the verifier's clock. It must not decrease. Participants should check
+		// that it is close to their own clock before accepting an update.
+		Time uint64
+		// Locks are the pending locks. Their hashes are unique.
+		Locks []Lock
+		// Preimages are the preimages that were revealed in the update that led
+		// to this state, resolving the respective locks. Preimages of locks that
+		// are not resolved by the update are ignored.
+		Preimages []Preimage
+	}
+
+	// Lock is a hash time-locked payment of Amount of the asset with index Asset
+	// from Sender to Receiver. The locked amount is moved from the sender's
+	// balance to the state's locked funds, see LockedAlloc. The lock is resolved in favor of the receiver by revealing the
+	// preimage of Hash. After Expiry, it can be refunded to the sender. The
+	// receiver can cancel the lock at any time, refunding the sender.
+	Lock struct {
+		Hash     Hash
+		Asset    int
+		Amount   channel.Bal
+		Sender   channel.Index
+		Receiver channel.Index
+		Expiry   uint64 // Unix time in seconds
+	}
+
+	// Hash is the SHA-256 hash of a Preimage.
+	Hash = [sha256.Size]byte
+
+	// Preimage is the secret that unlocks a Lock.
+	Preimage = [32]byte
+)
+
+var _ channel.Data = (*Data)(nil)
+
+// HashPreimage returns the hash of preimage p.
+func HashPreimage(p Preimage) Hash {
+	return sha256.Sum256(p[:])
+}
+
+// Lock returns the pending lock with hash h, if any.
+func (d *Data) Lock(h Hash) (Lock, bool) {
+	for _, l := range d.Locks {
+		if l.Hash == h {
+			return l, true
+		}
+	}
+	return Lock{}, false
+}
+
+// Clone returns a deep copy of the data.
+func (d *Data) Clone() channel.Data {
+	clone := &Data{Time: d.Time}
+	if d.Locks != nil {
+		clone.Locks = make([]Lock, len(d.Locks))
+		for i, l := range d.Locks {
+			clone.Locks[i] = l.Clone()
+		}
+	}
+	if d.Preimages != nil {
+		clone.Preimages = append([]Preimage(nil), d.Preimages...)
+	}
+	return clone
+}
+
+// Encode encodes the data into w.
+func (d *Data) Encode(w io.Writer) error {
+	if len(d.Locks) > MaxNumLocks {
+		return errors.Errorf("too many locks: %d", len(d.Locks))
+	}
+	if err := perunio.Encode(w, d.Time, uint16(len(d.Locks))); err != nil {
+		return errors.WithMessage(err, "encoding time or number of locks")
+	}
+	for i, l := range d.Locks {
+		if err := l.Encode(w); err != nil {
+			return errors.WithMessagef(err, "encoding lock %d", i)
+		}
+	}
+	if len(d.Preimages) > MaxNumLocks {
+		return errors.Errorf("too many preimages: %d", len(d.Preimages))
+	}
+	if err := perunio.Encode(w, uint16(len(d.Preimages))); err != nil {
+		return errors.WithMessage(err, "encoding number of preimages")
+	}
+	for _, p := range d.Preimages {
+		if err := perunio.Encode(w, p); err != nil {
+			return errors.WithMessage(err, "encoding preimage")
+		}
+	}
+	return nil
+}
+
+// Decode decodes the data from r.
+func (d *Data) Decode(r io.Reader) error {
+	var n uint16
+	if err := perunio.Decode(r, &d.Time, &n); err != nil {
+		return errors.WithMessage(err, "decoding time or number of locks")
+	}
+	d.Locks = nil
+	if n > 0 {
+		d.Locks = make([]Lock, n)
+	}
+	for i := range d.Locks {
+		if err := d.Locks[i].Decode(r); err != nil {
+			return errors.WithMessagef(err, "decoding lock %d", i)
+		}
+	}
+
+	if err := perunio.Decode(r, &n); err != nil {
+		return errors.WithMessage(err, "decoding number of preimages")
+	}
+	d.Preimages = nil
+	if n > 0 {
+		d.Preimages = make([]Preimage, n)
+	}
+	for i := range d.Preimages {
+		if err := perunio.Decode(r, &d.Preimages[i]); err != nil {
+			return errors.WithMessage(err, "decoding preimage")
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the lock.
+func (l Lock) Clone() Lock {
+	if l.Amount != nil {
+		l.Amount = new(big.Int).Set(l.Amount)
+	}
+	return l
+}
+
+// Encode encodes the lock into w.
+func (l Lock) Encode(w io.Writer) error {
+	if l.Asset < 0 || l.Asset >= channel.MaxNumAssets {
+		return errors.Errorf("invalid asset index %d", l.Asset)
+	}
+	return perunio.Encode(w, l.Hash, uint16(l.Asset), l.Amount, l.Sender, l.Receiver, l.Expiry)
+}
+
+// Decode decodes the lock from r.
+func (l *Lock) Decode(r io.Reader) error {
+	var asset uint16
+	if err := perunio.Decode(r, &l.Hash, &asset, &l.Amount, &l.Sender, &l.Receiver, &l.Expiry); err != nil {
+		return err
+	}
+	l.Asset = int(asset)
+	return nil
+}
+
+// Equal returns whether the locks are equal.
+func (l Lock) Equal(m Lock) bool {
+	return l.Hash == m.Hash && l.Asset == m.Asset && l.Amount.Cmp(m.Amount) == 0 &&
+		l.Sender == m.Sender && l.Receiver == m.Receiver && l.Expiry == m.Expiry
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	iotest "perun.network/go-perun/pkg/io/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+)
+
+func TestData(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	r := new(Randomizer)
+	data := []*Data{
+		new(Data),
+		r.NewRandomData(rng).(*Data),
+		{Time: rng.Uint64(), Locks: []Lock{NewRandomLock(rng)}, Preimages: []Preimage{NewRandomPreimage(rng)}},
+	}
+	for _, d := range data {
+		iotest.GenericSerializerTest(t, d)
+
+		clone := d.Clone().(*Data)
+		assert.Equal(t, d, clone)
+		for i := range clone.Locks {
+			clone.Locks[i].Amount.SetInt64(-1)
+			assert.NotEqual(t, d.Locks[i].Amount, clone.Locks[i].Amount, "clone should be deep")
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+)
+
+// SetTime sets the time of state s to now, which is the time of the update
+// that leads to s. The time is never decreased, so that it stays valid if the
+// own clock is behind the time of the current state. The state's version is
+// not changed.
+func SetTime(s *channel.State, now time.Time) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	if t := uint64(now.Unix()); t > d.Time {
+		d.Time = t
+	}
+	return nil
+}
+
+// AddLock adds lock l to state s, moving its amount from the sender's balance
+// to the locked funds. The lock must expire after the state's time. The
+// state's version is not changed.
+func AddLock(s *channel.State, l Lock) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	if l.Expiry <= d.Time {
+		return errors.Errorf("lock %x expires at %d before time %d", l.Hash, l.Expiry, d.Time)
+	}
+	if err := l.valid(s.Balances); err != nil {
+		return err
+	}
+	locks := append(append([]Lock(nil), d.Locks...), l.Clone())
+	alloc := s.Allocation.Clone()
+	bals := alloc.Balances[l.Asset]
+	bals[l.Sender].Sub(bals[l.Sender], l.Amount)
+	alloc.Locked = LockedAlloc(s.ID, len(alloc.Balances), locks)
+	if err := validLocks(s.ID, alloc, locks); err != nil {
+		return err
+	}
+	s.Allocation = alloc
+	d.Locks, d.Preimages = locks, nil
+	return nil
+}
+
+// Unlock resolves the lock with the hash of preimage p in favor of its
+// receiver and reveals p in state s. The state's version is not changed.
+func Unlock(s *channel.State, p Preimage) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	l, ok := d.removeLock(HashPreimage(p))
+	if !ok {
+		return errors.Errorf("no lock for preimage %x", p)
+	}
+	release(s, d, l, l.Receiver)
+	d.Preimages = []Preimage{p}
+	return nil
+}
+
+// Refund removes the lock with hash h from state s, refunding its sender. The
+// lock must be expired at the state's time, see SetTime. The state's version
+// is not changed.
+func Refund(s *channel.State, h Hash) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	l, ok := d.Lock(h)
+	if !ok {
+		return errors.Errorf("no lock %x", h)
+	}
+	if d.Time < l.Expiry {
+		return errors.Errorf("lock %x expires at %v", h, time.Unix(int64(l.Expiry), 0))
+	}
+	d.removeLock(h)
+	release(s, d, l, l.Sender)
+	d.Preimages = nil
+	return nil
+}
+
+// Cancel removes the lock with hash h from state s, refunding its sender. The
+// update must be proposed by the receiver of the lock. The state's version is
+// not changed.
+func Cancel(s *channel.State, h Hash) error {
+	d, err := data(s)
+	if err != nil {
+		return err
+	}
+	l, ok := d.removeLock(h)
+	if !ok {
+		return errors.Errorf("no lock %x", h)
+	}
+	release(s, d, l, l.Sender)
+	d.Preimages = nil
+	return nil
+}
+
+// release pays the amount of the lock l, which was removed from data d, to
+// participant idx and updates the locked funds of state s.
+func release(s *channel.State, d *Data, l Lock, idx channel.Index) {
+	bals := s.Balances[l.Asset]
+	bals[idx].Add(bals[idx], l.Amount)
+	s.Locked = LockedAlloc(s.ID, len(s.Balances), d.Locks)
+}
+
+// removeLock removes the lock with hash h and returns it.
+func (d *Data) removeLock(h Hash) (Lock, bool) {
+	for i, l := range d.Locks {
+		if l.Hash == h {
+			d.Locks = append(d.Locks[:i:i], d.Locks[i+1:]...)
+			if len(d.Locks) == 0 {
+				d.Locks = nil
+			}
+			return l, true
+		}
+	}
+	return Lock{}, false
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htlc
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Contrary to the payment
+// app, the HTLC app does not set it as the global app randomizer of package
+// channel/test. Set it with test.SetAppRandomizer if needed.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp always returns an HTLC app with the same address. Currently,
+// one HTLC app address has to be set at program startup.
+func (*Randomizer) NewRandomApp(*rand.Rand) channel.App {
+	return &App{AppDef()}
+}
+
+// NewRandomData returns Data with up to three random locks between two
+// participants of the first asset and no preimages. The locked funds of a
+// state are not set accordingly, see LockedAlloc.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	d := new(Data)
+	for i := rng.Intn(4); i > 0; i-- {
+		d.Locks = append(d.Locks, NewRandomLock(rng))
+	}
+	return d
+}
+
+// NewRandomLock returns a random lock of the first asset between two
+// participants that expires within the next hour.
+func NewRandomLock(rng *rand.Rand) Lock {
+	l := Lock{
+		Amount: big.NewInt(rng.Int63n(1000) + 1),
+		Expiry: uint64(time.Now().Add(time.Duration(rng.Int63n(int64(time.Hour)))).Unix()),
+	}
+	rng.Read(l.Hash[:]) // nolint: gosec, errcheck
+	l.Sender = channel.Index(rng.Intn(2))
+	l.Receiver = l.Sender ^ 1
+	return l
+}
+
+// NewRandomPreimage returns a random preimage.
+func NewRandomPreimage(rng *rand.Rand) Preimage {
+	var p Preimage
+	rng.Read(p[:]) // nolint: gosec, errcheck
+	return p
+}
@@ -65,6 +65,12 @@ func SetAppDef(def wallet.Address) {
 	backend.SetAppDef(def)
 }
 
+// isAppDef returns whether def is the address of the payment app. It returns
+// false if the address is not set yet.
+func (b *Backend) isAppDef(def wallet.Address) bool {
+	return b.def != nil && b.def.Equals(def)
+}
+
 // AppDef gets the address of the payment app.
 func (b *Backend) AppDef() wallet.Address {
 	return b.def
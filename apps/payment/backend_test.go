@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
 	pkgtest "perun.network/go-perun/pkg/test"
 	"perun.network/go-perun/wallet/test"
 )
@@ -51,6 +52,10 @@ func TestBackend(t *testing.T) {
 	assert.NoError(err)
 	require.NotNil(app)
 	assert.Equal(&App{def}, app)
+
+	app, err = channel.AppFromDefinition(def)
+	assert.NoError(err)
+	assert.Equal(&App{def}, app, "payment app should be registered in channel")
 }
 
 func TestNoData(t *testing.T) {
@@ -21,6 +21,6 @@ import (
 
 func init() {
 	backend = new(Backend)
-	channel.SetAppBackend(backend)
+	channel.RegisterMatchingAppResolver(backend.isAppDef, backend)
 	test.SetAppRandomizer(new(Randomizer))
 }
@@ -0,0 +1,45 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"io"
+	"math/rand"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// Asset is an asset on a chain with Ed25519 keys. It is identified by a 32
+// byte ID, e.g., the hash of the asset's definition on the chain.
+type Asset struct {
+	ID [32]byte
+}
+
+// NewRandomAsset returns a new random Asset.
+func NewRandomAsset(rng *rand.Rand) *Asset {
+	var a Asset
+	rng.Read(a.ID[:])
+	return &a
+}
+
+// Encode encodes an Asset into the io.Writer `w`.
+func (a Asset) Encode(w io.Writer) error {
+	return perunio.Encode(w, a.ID)
+}
+
+// Decode decodes an Asset from the io.Reader `r`.
+func (a *Asset) Decode(r io.Reader) error {
+	return perunio.Decode(r, &a.ID)
+}
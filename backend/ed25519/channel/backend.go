@@ -0,0 +1,96 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+
+	edwallet "perun.network/go-perun/backend/ed25519/wallet"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// Backend implements the utility interface defined in the channel package. It
+// signs states with Ed25519 accounts of the Ed25519 wallet backend.
+//
+// There is no on-chain contract that has to reproduce the encodings, so the
+// channel ID is the SHA-256 hash of the canonical encoding of the parameters
+// and signatures are over the canonical encoding of the full state, including
+// the app definition.
+type Backend struct{}
+
+var _ channel.Backend = new(Backend)
+
+// CalcID calculates a channel's ID by hashing all fields of its parameters.
+func (*Backend) CalcID(p *channel.Params) channel.ID {
+	h := sha256.New()
+	if err := perunio.Encode(h,
+		p.ChallengeDuration,
+		wallet.AddressesWithLen(p.Parts),
+		p.App.Def(),
+		p.Nonce); err != nil {
+		log.Panicf("hashing params: %v", err)
+	}
+
+	var id channel.ID
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// Sign signs `state`.
+func (b *Backend) Sign(addr wallet.Account, params *channel.Params, state *channel.State) ([]byte, error) {
+	log.Tracef("Signing state %s version %d", string(state.ID[:]), state.Version)
+
+	data, err := signedData(state)
+	if err != nil {
+		return nil, err
+	}
+	return addr.SignData(data)
+}
+
+// Verify verifies the signature for `state`.
+func (b *Backend) Verify(addr wallet.Address, params *channel.Params, state *channel.State, sig []byte) (bool, error) {
+	if err := state.Valid(); err != nil {
+		return false, errors.Wrap(err, "verifying invalid state")
+	}
+	log.Tracef("Verifying state %s version %d", string(state.ID[:]), state.Version)
+
+	data, err := signedData(state)
+	if err != nil {
+		return false, err
+	}
+	return new(edwallet.Backend).VerifySignature(data, sig, addr)
+}
+
+// DecodeAsset decodes an Asset from the io.Reader `r`.
+func (*Backend) DecodeAsset(r io.Reader) (channel.Asset, error) {
+	var asset Asset
+	return &asset, asset.Decode(r)
+}
+
+// signedData returns the encoding of the state that is signed.
+func signedData(state *channel.State) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := state.Encode(&buf); err != nil {
+		return nil, errors.WithMessage(err, "encoding state")
+	}
+	return buf.Bytes(), nil
+}
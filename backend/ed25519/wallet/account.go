@@ -0,0 +1,66 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallet
+
+import (
+	"crypto/ed25519"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/pkg/sync/atomic"
+	"perun.network/go-perun/wallet"
+)
+
+// Account is an Ed25519 account.
+type Account struct {
+	privKey ed25519.PrivateKey
+
+	locked     atomic.Bool
+	references int32
+}
+
+var _ wallet.Account = (*Account)(nil)
+
+// NewRandomAccount generates a new account, reading randomness form the given
+// rng. It is not saved to any wallet.
+func NewRandomAccount(rng io.Reader) *Account {
+	_, sk, err := ed25519.GenerateKey(rng)
+	if err != nil {
+		log.Panicf("Creation of account failed with error: %v", err)
+	}
+	return NewAccount(sk)
+}
+
+// NewAccount creates an account from an Ed25519 private key. It is not saved
+// to any wallet.
+func NewAccount(sk ed25519.PrivateKey) *Account {
+	return &Account{privKey: sk}
+}
+
+// Address returns the address of this account.
+func (a *Account) Address() wallet.Address {
+	return AsAddr(a.privKey.Public().(ed25519.PublicKey))
+}
+
+// SignData is used to sign data with this account. If the account is locked,
+// returns an error instead of a signature.
+func (a *Account) SignData(data []byte) ([]byte, error) {
+	if a.locked.IsSet() {
+		return nil, errors.New("account locked")
+	}
+	return ed25519.Sign(a.privKey, data), nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// Address is an Ed25519 public key.
+type Address [ed25519.PublicKeySize]byte
+
+// compile time check that we implement the perun Address interface.
+var _ wallet.Address = (*Address)(nil)
+
+// NewRandomAddress creates a new address using the randomness provided by rng.
+func NewRandomAddress(rng io.Reader) *Address {
+	pk, _, err := ed25519.GenerateKey(rng)
+	if err != nil {
+		log.Panicf("Creation of address failed with error: %v", err)
+	}
+	return AsAddr(pk)
+}
+
+// AsAddr converts an Ed25519 public key to an Address.
+func AsAddr(pk ed25519.PublicKey) *Address {
+	var a Address
+	copy(a[:], pk)
+	return &a
+}
+
+// PublicKey returns the Ed25519 public key of the address.
+func (a *Address) PublicKey() ed25519.PublicKey {
+	return ed25519.PublicKey(a[:])
+}
+
+// Bytes returns the public key as bytes.
+func (a *Address) Bytes() []byte {
+	return a[:]
+}
+
+// String converts this address to a human-readable string.
+func (a *Address) String() string {
+	return "0x" + hex.EncodeToString(a[:4])
+}
+
+// Equals checks the equality of two addresses. The implementation must be
+// equivalent to checking `Address.Cmp(Address) == 0`.
+func (a *Address) Equals(addr wallet.Address) bool {
+	return *a == *addr.(*Address)
+}
+
+// Cmp compares the byte representation of two addresses. It returns -1 if
+// a < addr, 0 if a == addr and +1 if a > addr.
+func (a *Address) Cmp(addr wallet.Address) int {
+	return bytes.Compare(a[:], addr.(*Address)[:])
+}
+
+// Encode encodes this address into an io.Writer. Part of the
+// go-perun/pkg/io.Serializer interface.
+func (a *Address) Encode(w io.Writer) error {
+	return perunio.Encode(w, a[:])
+}
+
+// Decode decodes an address from an io.Reader. Part of the
+// go-perun/pkg/io.Serializer interface.
+func (a *Address) Decode(r io.Reader) error {
+	data := make([]byte, len(a))
+	if err := perunio.Decode(r, &data); err != nil {
+		return errors.WithMessage(err, "decoding address")
+	}
+	copy(a[:], data)
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallet
+
+import (
+	"crypto/ed25519"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// Backend implements the utility interface defined in the wallet package.
+type Backend struct{}
+
+var _ wallet.Backend = new(Backend)
+
+// DecodeAddress decodes an address from the given Reader.
+func (b *Backend) DecodeAddress(r io.Reader) (wallet.Address, error) {
+	var addr Address
+	return &addr, addr.Decode(r)
+}
+
+// DecodeSig reads a []byte with length of a signature.
+func (b *Backend) DecodeSig(r io.Reader) (wallet.Sig, error) {
+	buf := make(wallet.Sig, ed25519.SignatureSize)
+	return buf, perunio.Decode(r, &buf)
+}
+
+// VerifySignature verifies if a signature was made by this account.
+func (b *Backend) VerifySignature(msg []byte, sig wallet.Sig, a wallet.Address) (bool, error) {
+	addr, ok := a.(*Address)
+	if !ok {
+		log.Panic("Wrong address type passed to Backend.VerifySignature")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return false, errors.Errorf("expected %d bytes for a signature but got: %d", ed25519.SignatureSize, len(sig))
+	}
+	return ed25519.Verify(addr.PublicKey(), msg, sig), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallet_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	edwallet "perun.network/go-perun/backend/ed25519/wallet"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wallet/test"
+)
+
+func TestGenericTests(t *testing.T) {
+	t.Run("Generic Address Test", func(t *testing.T) {
+		t.Parallel()
+		rng := pkgtest.Prng(t, "address")
+		test.GenericAddressTest(t, newWalletSetup(rng))
+	})
+	t.Run("Generic Signature Test", func(t *testing.T) {
+		t.Parallel()
+		rng := pkgtest.Prng(t, "signature")
+		test.GenericSignatureTest(t, newWalletSetup(rng))
+		test.GenericSignatureSizeTest(t, newWalletSetup(rng))
+	})
+}
+
+func TestRandomizer(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	acc := test.NewRandomAccount(rng)
+	assert.IsType(t, new(edwallet.Account), acc)
+	assert.IsType(t, new(edwallet.Address), test.NewRandomAddress(rng))
+
+	unlocked, err := test.RandomWallet().Unlock(acc.Address())
+	require.NoError(t, err)
+	assert.Same(t, acc, unlocked)
+}
+
+func TestAddress_Cmp(t *testing.T) {
+	var a, b edwallet.Address
+	b[len(b)-1] = 1
+	assert.Equal(t, -1, a.Cmp(&b))
+	assert.Equal(t, 1, b.Cmp(&a))
+	assert.Equal(t, 0, a.Cmp(&a))
+	assert.False(t, a.Equals(&b))
+}
+
+func TestWallet_Unlock(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	acc := edwallet.NewRandomAccount(rng)
+	w := edwallet.NewRestoredWallet(acc)
+
+	_, err := acc.SignData([]byte("----"))
+	require.Error(t, err, "sign before unlock")
+	unlocked, err := w.Unlock(acc.Address())
+	require.NoError(t, err)
+	require.Same(t, acc, unlocked)
+	_, err = acc.SignData([]byte("----"))
+	require.NoError(t, err, "sign after unlock")
+
+	w.LockAll()
+	_, err = acc.SignData([]byte("----"))
+	require.Error(t, err, "sign after LockAll")
+	_, err = w.Unlock(edwallet.NewRandomAddress(rng))
+	assert.Error(t, err, "unlock unknown address")
+}
+
+func newWalletSetup(rng *rand.Rand) *test.Setup {
+	accountA := edwallet.NewRandomAccount(rng)
+	accountB := edwallet.NewRandomAccount(rng)
+	unlockedAccount := func() (wallet.Account, error) { return accountA, nil }
+
+	return &test.Setup{
+		Backend:         new(edwallet.Backend),
+		UnlockedAccount: unlockedAccount,
+		AddressBytes:    accountB.Address().Bytes(),
+		DataToSign:      []byte("pay 1 to participant 0"),
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/backend/ethereum/bindings/adjudicator"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/channel"
+)
+
+// adjudicatorABI is the parsed ABI of the Adjudicator contract. It is used to
+// decode the arguments of progress transactions.
+var adjudicatorABI = func() abi.ABI {
+	a, err := abi.JSON(strings.NewReader(adjudicator.AdjudicatorABI))
+	if err != nil {
+		panic("parsing Adjudicator ABI: " + err.Error())
+	}
+	return a
+}()
+
+// Progress progresses the registered channel state on-chain to the new state
+// of the request. If the channel was already progressed to the new state's
+// version, it is a no-op.
+func (a *Adjudicator) Progress(ctx context.Context, req channel.ProgressReq) error {
+	if found, err := a.filterProgressed(ctx, req.Params.ID(), req.NewState.Version); err != nil {
+		return errors.WithMessage(err, "filtering old Progressed events")
+	} else if found {
+		return nil
+	}
+	return errors.WithMessage(a.callProgress(ctx, req), "calling progress")
+}
+
+func (a *Adjudicator) callProgress(ctx context.Context, req channel.ProgressReq) error {
+	newState := channelStateToEthState(req.NewState)
+	actor := new(big.Int).SetUint64(uint64(req.Idx))
+	// Wrapped call to Progress, ignoring sigs of the registered state
+	progress := func(
+		opts *bind.TransactOpts,
+		params adjudicator.ChannelParams,
+		state adjudicator.ChannelState,
+		_ [][]byte,
+	) (*types.Transaction, error) {
+		return a.contract.Progress(opts, params, state, newState, actor, req.Sig)
+	}
+	return a.call(ctx, req.AdjudicatorReq, progress)
+}
+
+// filterProgressed returns whether there has been a Progressed event of the
+// channel to at least the given version in the past.
+func (a *Adjudicator) filterProgressed(ctx context.Context, id channel.ID, version uint64) (bool, error) {
+	filterOpts, err := a.NewFilterOpts(ctx)
+	if err != nil {
+		return false, err
+	}
+	iter, err := a.contract.FilterProgressed(filterOpts, [][32]byte{id})
+	if err != nil {
+		return false, errors.Wrap(err, "creating iterator")
+	}
+	// nolint:errcheck
+	defer iter.Close()
+
+	for iter.Next() {
+		if iter.Event.Version >= version {
+			return true, nil
+		}
+	}
+	return false, errors.Wrap(iter.Error(), "iterating")
+}
+
+// progressedState returns the state to which the channel with parameters
+// params was progressed by the transaction with hash txHash. It returns nil if
+// the transaction did not call progress on the adjudicator directly, e.g., if
+// progress was called by another contract.
+func progressedState(ctx context.Context, tr ethereum.TransactionReader, params *channel.Params, txHash common.Hash) (*channel.State, error) {
+	tx, _, err := tr.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching transaction")
+	}
+	data := tx.Data()
+	if len(data) < 4 {
+		return nil, nil
+	}
+	method, err := adjudicatorABI.MethodById(data[:4])
+	if err != nil || method.Name != "progress" {
+		return nil, nil // nolint:nilerr
+	}
+	args, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return nil, errors.Wrap(err, "unpacking progress arguments")
+	}
+
+	// The arguments are unpacked into anonymous structs, which are converted
+	// to the binding's type by their JSON representation.
+	enc, err := json.Marshal(args[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding state")
+	}
+	var state adjudicator.ChannelState
+	if err := json.Unmarshal(enc, &state); err != nil {
+		return nil, errors.Wrap(err, "decoding state")
+	}
+	return ethStateToChannelState(params, state)
+}
+
+// ethStateToChannelState converts a ChannelState struct of a channel with
+// parameters params to a channel.State.
+func ethStateToChannelState(params *channel.Params, s adjudicator.ChannelState) (*channel.State, error) {
+	data, err := params.App.DecodeData(bytes.NewReader(s.AppData))
+	if err != nil {
+		return nil, errors.WithMessage(err, "decoding app data")
+	}
+	assets := make([]channel.Asset, len(s.Outcome.Assets))
+	for i, a := range s.Outcome.Assets {
+		assets[i] = ethwallet.AsWalletAddr(a)
+	}
+	var locked []channel.SubAlloc
+	for _, sub := range s.Outcome.Locked {
+		locked = append(locked, channel.SubAlloc{ID: sub.ID, Bals: sub.Balances})
+	}
+	return &channel.State{
+		ID:      s.ChannelID,
+		Version: s.Version,
+		App:     params.App,
+		Allocation: channel.Allocation{
+			Assets:   assets,
+			Balances: s.Outcome.Balances,
+			Locked:   locked,
+		},
+		Data:    data,
+		IsFinal: s.IsFinal,
+	}, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+	"perun.network/go-perun/backend/ethereum/channel/test"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/channel"
+	channeltest "perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+)
+
+func TestAdjudicator_Progress(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	s := test.NewSetup(t, rng, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTxTimeout)
+	defer cancel()
+	app, err := test.DeployTrivialApp(ctx, *s.CB)
+	require.NoError(t, err)
+	params, state := channeltest.NewRandomParamsAndState(rng,
+		channeltest.WithChallengeDuration(60),
+		channeltest.WithParts(s.Parts...),
+		channeltest.WithAssets((*ethchannel.Asset)(&s.Asset)),
+		channeltest.WithAppDef(ethwallet.AsWalletAddr(app)),
+		channeltest.WithNumLocked(0),
+		channeltest.WithIsFinal(false))
+
+	ct := pkgtest.NewConcurrent(t)
+	for i, funder := range s.Funders {
+		i, funder := i, funder
+		go ct.StageN("funding", len(s.Funders), func(rt require.TestingT) {
+			req := channel.FundingReq{Params: params, State: state, Idx: channel.Index(i)}
+			require.NoError(rt, funder.Fund(ctx, req), "funding should succeed")
+		})
+	}
+	ct.Wait("funding")
+
+	sub, err := s.Adjs[1].SubscribeRegistered(ctx, params)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	req := channel.AdjudicatorReq{
+		Params: params,
+		Acc:    s.Accs[0],
+		Idx:    0,
+		Tx:     signState(t, s.Accs, params, state),
+	}
+	reg, err := s.Adjs[0].Register(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, reg, sub.Next())
+
+	// Participant 0 transfers 1 to participant 1.
+	newState := state.Clone()
+	newState.Version++
+	newState.Balances[0][0].Sub(newState.Balances[0][0], big.NewInt(1))
+	newState.Balances[0][1].Add(newState.Balances[0][1], big.NewInt(1))
+	sig, err := channel.Sign(s.Accs[0], params, newState)
+	require.NoError(t, err)
+	preq := channel.ProgressReq{AdjudicatorReq: req, NewState: newState, Sig: sig}
+
+	assert.Error(t, s.Adjs[0].Progress(ctx, preq), "progress before timeout should fail")
+	require.NoError(t, reg.Timeout.Wait(ctx))
+	require.NoError(t, s.Adjs[0].Progress(ctx, preq))
+	require.NoError(t, s.Adjs[0].Progress(ctx, preq), "progressing again should be a no-op")
+
+	ev := sub.Next()
+	require.NotNil(t, ev)
+	assert.Equal(t, newState.Version, ev.Version)
+	require.NotNil(t, ev.State, "event should carry progressed state")
+	assert.NoError(t, newState.Equal(ev.State))
+
+	req.Tx = channel.Transaction{State: newState}
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	assert.Error(t, s.Adjs[0].Withdraw(shortCtx, req), "withdrawal before timeout should fail")
+	require.NoError(t, ev.Timeout.Wait(ctx))
+	for i, adj := range s.Adjs {
+		req.Acc, req.Idx = s.Accs[i], channel.Index(i)
+		assert.NoError(t, adj.Withdraw(ctx, req), "withdrawal of progressed state should succeed")
+	}
+}
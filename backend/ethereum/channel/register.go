@@ -16,13 +16,14 @@ package channel
 
 import (
 	"context"
+	"time"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/pkg/errors"
 
 	"perun.network/go-perun/backend/ethereum/bindings/adjudicator"
 	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
 )
 
 // Register registers a state on-chain.
@@ -99,10 +100,11 @@ func (a *Adjudicator) SubscribeRegistered(ctx context.Context, params *channel.P
 	}
 
 	rsub := &RegisteredSub{
-		cr:   a.ContractInterface,
-		sub:  sub,
-		next: make(chan *channel.RegisteredEvent, 1),
-		err:  make(chan error, 1),
+		cr:     a.ContractInterface,
+		params: params,
+		sub:    sub,
+		next:   make(chan *channel.RegisteredEvent, 1),
+		err:    make(chan error, 1),
 	}
 
 	// Start event updater routine
@@ -158,13 +160,22 @@ func (a *Adjudicator) filterWatchStored(ctx context.Context, stored chan *adjudi
 	return sub, iter, nil
 }
 
+// progressedStateTimeout is the timeout for reading the progressed state of a
+// Stored event from its transaction.
+const progressedStateTimeout = 10 * time.Second
+
 // RegisteredSub implements the channel.RegisteredSubscription interface.
+// Stored events that were caused by a progression carry the progressed state,
+// which is decoded from the progressing transaction. The state is only
+// available if the transaction called progress on the adjudicator directly;
+// progressions through other contracts carry no state, like registrations.
 type RegisteredSub struct {
-	cr   ethereum.ChainReader          // chain reader to read block time
-	sub  event.Subscription            // Stored event subscription
-	next chan *channel.RegisteredEvent // Registered event sink
-	err  chan error                    // error from subscription
-	past bool                          // whether there was a past event when the subscription was created
+	cr     ContractInterface             // chain reader to read block time and transactions
+	params *channel.Params               // parameters of the subscribed channel
+	sub    event.Subscription            // Stored event subscription
+	next   chan *channel.RegisteredEvent // Registered event sink
+	err    chan error                    // error from subscription
+	past   bool                          // whether there was a past event when the subscription was created
 }
 
 func (r *RegisteredSub) hasPast() bool {
@@ -172,23 +183,26 @@ func (r *RegisteredSub) hasPast() bool {
 }
 
 func (r *RegisteredSub) updateNext(events chan *adjudicator.AdjudicatorStored) {
+	var prev *adjudicator.AdjudicatorStored
 evloop:
 	for {
 		select {
-		case next := <-events:
+		case stored := <-events:
+			next := r.storedToRegisteredEvent(stored, prev)
+			prev = stored
 			select {
 			// drain next-channel on new event
 			case current := <-r.next:
 				currentTimeout := current.Timeout.(*BlockTimeout)
 				// if newer version or same version and newer timeout, replace
-				if current.Version < next.Version ||
-					current.Version == next.Version && currentTimeout.Time < next.Timeout {
-					r.next <- r.storedToRegisteredEvent(next)
+				if current.Version < stored.Version ||
+					current.Version == stored.Version && currentTimeout.Time < stored.Timeout {
+					r.next <- next
 				} else { // otherwise, reuse old
 					r.next <- current
 				}
 			default: // next-channel is empty
-				r.next <- r.storedToRegisteredEvent(next)
+				r.next <- next
 			}
 		case err := <-r.sub.Err():
 			r.err <- err
@@ -225,13 +239,27 @@ func (r *RegisteredSub) Err() error {
 	return <-r.err
 }
 
-func (r *RegisteredSub) storedToRegisteredEvent(event *adjudicator.AdjudicatorStored) *channel.RegisteredEvent {
-	if event == nil {
-		return nil
-	}
-	return &channel.RegisteredEvent{
+// storedToRegisteredEvent converts the Stored event to a RegisteredEvent. The
+// progressed state is only read for events that can be progressions, i.e., the
+// first event of the subscription and events that increment the version of the
+// previous event prev by one. If it cannot be read, the event carries no state.
+func (r *RegisteredSub) storedToRegisteredEvent(event, prev *adjudicator.AdjudicatorStored) *channel.RegisteredEvent {
+	ev := &channel.RegisteredEvent{
 		ID:      event.ChannelID,
 		Version: event.Version,
 		Timeout: NewBlockTimeout(r.cr, event.Timeout),
 	}
+	if event.Version == 0 || prev != nil && event.Version != prev.Version+1 {
+		return ev
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), progressedStateTimeout)
+	defer cancel()
+	state, err := progressedState(ctx, r.cr, r.params, event.Raw.TxHash)
+	if err != nil {
+		log.WithField("channel", r.params.ID()).Warnf("Reading progressed state: %v", err)
+		return ev
+	}
+	ev.State = state
+	return ev
 }
@@ -0,0 +1,49 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+)
+
+// trivialAppCode is the init code of a contract that returns true on every
+// call. As the app of a channel, it accepts all transitions.
+const trivialAppCode = "600a600c600039600a6000f3" + // copy runtime code to memory and return it
+	"600160005260206000f3" // runtime code: return uint256(1)
+
+const deployGasLimit = 100000
+
+// DeployTrivialApp deploys a contract that can be used as the app of channels
+// whose transitions should all be valid on-chain, e.g., to test progression.
+func DeployTrivialApp(ctx context.Context, backend ethchannel.ContractBackend) (common.Address, error) {
+	auth, err := backend.NewTransactor(ctx, big.NewInt(0), deployGasLimit)
+	if err != nil {
+		return common.Address{}, errors.WithMessage(err, "creating transactor")
+	}
+	addr, tx, _, err := bind.DeployContract(auth, abi.ABI{}, common.FromHex(trivialAppCode), backend)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "creating transaction")
+	}
+	_, err = bind.WaitDeployed(ctx, backend, tx)
+	return addr, errors.Wrap(err, "deploying trivial app")
+}
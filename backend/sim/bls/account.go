@@ -0,0 +1,55 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"io"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// Account is a BLS account, holding a secret key.
+type Account struct {
+	sk   *big.Int
+	addr *Address
+}
+
+var _ wallet.Account = (*Account)(nil)
+
+// NewRandomAccount generates a new account, reading randomness from the given
+// rng.
+func NewRandomAccount(rng io.Reader) *Account {
+	sk, pk, err := bn256.RandomG2(rng)
+	if err != nil {
+		log.Panicf("Creation of account failed with error: %v", err)
+	}
+	addr := new(Address)
+	copy(addr[:], pk.Marshal())
+	return &Account{sk: sk, addr: addr}
+}
+
+// Address returns the address of this account.
+func (a *Account) Address() wallet.Address {
+	return a.addr
+}
+
+// SignData signs data with this account.
+func (a *Account) SignData(data []byte) ([]byte, error) {
+	return sign(a.sk, data), nil
+}
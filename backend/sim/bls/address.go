@@ -0,0 +1,88 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/pkg/errors"
+
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// Address is a BLS public key, the marshaled point on G2.
+type Address [AddrLen]byte
+
+// compile time check that we implement the perun Address interface.
+var _ wallet.Address = (*Address)(nil)
+
+// NewRandomAddress creates a new address using the randomness provided by rng.
+func NewRandomAddress(rng io.Reader) *Address {
+	return NewRandomAccount(rng).addr
+}
+
+// Bytes returns the marshaled public key.
+func (a *Address) Bytes() []byte {
+	return a[:]
+}
+
+// String converts this address to a human-readable string.
+func (a *Address) String() string {
+	return "0x" + hex.EncodeToString(a[:4])
+}
+
+// Equals checks the equality of two addresses.
+func (a *Address) Equals(addr wallet.Address) bool {
+	return a.Cmp(addr) == 0
+}
+
+// Cmp compares the byte representation of two addresses.
+func (a *Address) Cmp(addr wallet.Address) int {
+	return bytes.Compare(a[:], addr.(*Address)[:])
+}
+
+// Encode encodes this address into an io.Writer. Part of the
+// go-perun/pkg/io.Serializer interface.
+func (a *Address) Encode(w io.Writer) error {
+	return perunio.Encode(w, a[:])
+}
+
+// Decode decodes an address from an io.Reader. Part of the
+// go-perun/pkg/io.Serializer interface. The public key is only validated on
+// signature verification.
+func (a *Address) Decode(r io.Reader) error {
+	data := make([]byte, AddrLen)
+	if err := perunio.Decode(r, &data); err != nil {
+		return errors.WithMessage(err, "decoding address")
+	}
+	copy(a[:], data)
+	return nil
+}
+
+// publicKey returns the public key on G2. The point at infinity is rejected.
+func (a *Address) publicKey() (*bn256.G2, error) {
+	if isZero(a[:]) {
+		return nil, errors.New("public key is the point at infinity")
+	}
+	pk := new(bn256.G2)
+	if _, err := pk.Unmarshal(a[:]); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling public key")
+	}
+	return pk, nil
+}
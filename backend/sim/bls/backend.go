@@ -0,0 +1,122 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"io"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// Backend implements the utility interface defined in the wallet package.
+type Backend struct{}
+
+var _ wallet.Backend = new(Backend)
+
+// DecodeAddress decodes an address from the given Reader.
+func (b *Backend) DecodeAddress(r io.Reader) (wallet.Address, error) {
+	var addr Address
+	return &addr, addr.Decode(r)
+}
+
+// DecodeSig reads a []byte with length of a signature.
+func (b *Backend) DecodeSig(r io.Reader) (wallet.Sig, error) {
+	buf := make(wallet.Sig, SigLen)
+	return buf, perunio.Decode(r, &buf)
+}
+
+// VerifySignature verifies if a signature was made by this account.
+func (b *Backend) VerifySignature(msg []byte, sig wallet.Sig, a wallet.Address) (bool, error) {
+	addr, ok := a.(*Address)
+	if !ok {
+		log.Panic("Wrong address type passed to Backend.VerifySignature")
+	}
+	pk, err := addr.publicKey()
+	if err != nil {
+		return false, err
+	}
+	s, err := unmarshalSig(sig)
+	if err != nil {
+		return false, errors.WithMessage(err, "could not deserialize signature")
+	}
+	return verify(msg, s, pk), nil
+}
+
+// Aggregate aggregates the signatures of the addresses, in the same order, on
+// the same message into a single signature. It can be verified with
+// VerifyAggregate.
+func Aggregate(sigs []wallet.Sig, addrs []wallet.Address) (wallet.Sig, error) {
+	if len(sigs) != len(addrs) {
+		return nil, errors.Errorf("%d signatures for %d addresses", len(sigs), len(addrs))
+	} else if len(sigs) == 0 {
+		return nil, errors.New("no signatures to aggregate")
+	}
+
+	agg := new(bn256.G1)
+	for i, coeff := range coefficients(pubKeyBytes(addrs)) {
+		s, err := unmarshalSig(sigs[i])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "signature %d", i)
+		}
+		s.ScalarMult(s, coeff)
+		if i == 0 {
+			agg.Set(s)
+		} else {
+			agg.Add(agg, s)
+		}
+	}
+	return agg.Marshal(), nil
+}
+
+// VerifyAggregate verifies that the aggregated signature is an aggregate of
+// valid signatures of all addresses on msg. It performs a single pairing
+// check, independent of the number of addresses.
+func VerifyAggregate(msg []byte, sig wallet.Sig, addrs []wallet.Address) (bool, error) {
+	if len(addrs) == 0 {
+		return false, errors.New("no addresses")
+	}
+	s, err := unmarshalSig(sig)
+	if err != nil {
+		return false, errors.WithMessage(err, "could not deserialize signature")
+	}
+
+	aggPk := new(bn256.G2)
+	for i, coeff := range coefficients(pubKeyBytes(addrs)) {
+		pk, err := addrs[i].(*Address).publicKey()
+		if err != nil {
+			return false, errors.WithMessagef(err, "address %d", i)
+		}
+		pk.ScalarMult(pk, coeff)
+		if i == 0 {
+			aggPk.Set(pk)
+		} else {
+			aggPk.Add(aggPk, pk)
+		}
+	}
+	return verify(msg, s, aggPk), nil
+}
+
+func pubKeyBytes(addrs []wallet.Address) [][]byte {
+	pks := make([][]byte, len(addrs))
+	for i, a := range addrs {
+		pks[i] = a.(*Address).Bytes()
+	}
+	return pks
+}
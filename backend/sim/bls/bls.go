@@ -0,0 +1,145 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SigLen is the length of a marshaled signature, a point on G1.
+	SigLen = 64
+	// AddrLen is the length of a marshaled address, a public key on G2.
+	AddrLen = 128
+
+	// hashDomain separates the hash-to-curve from other uses of the hash.
+	hashDomain = "perun-sim-bls-sig"
+	// coeffDomain separates the aggregation coefficients.
+	coeffDomain = "perun-sim-bls-agg"
+)
+
+var (
+	// g2 is the generator of G2.
+	g2 = new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	// sqrtExp is (P+1)/4, the exponent for square roots modulo P, which is
+	// 3 mod 4.
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(bn256.P, big.NewInt(1)), 2)
+	curveB  = big.NewInt(3)
+)
+
+// hashToG1 maps msg to a point on G1 by try-and-increment: the hash of msg and
+// a counter is used as x-coordinate until x³+3 is a square. G1 has cofactor 1,
+// so every curve point is in G1.
+func hashToG1(msg []byte) *bn256.G1 {
+	var (
+		ctr [4]byte
+		buf [2 * 32]byte
+		x   = new(big.Int)
+		y   = new(big.Int)
+		y2  = new(big.Int)
+	)
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(ctr[:], i)
+		h := sha256.New()
+		h.Write([]byte(hashDomain)) // nolint:errcheck
+		h.Write(ctr[:])             // nolint:errcheck
+		h.Write(msg)                // nolint:errcheck
+		x.SetBytes(h.Sum(nil)).Mod(x, bn256.P)
+
+		y2.Exp(x, big.NewInt(3), bn256.P).Add(y2, curveB).Mod(y2, bn256.P)
+		y.Exp(y2, sqrtExp, bn256.P)
+		if new(big.Int).Exp(y, big.NewInt(2), bn256.P).Cmp(y2) != 0 {
+			continue
+		}
+
+		putBig(buf[:32], x)
+		putBig(buf[32:], y)
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(buf[:]); err == nil {
+			return p
+		}
+	}
+}
+
+// sign creates the signature of sk on msg.
+func sign(sk *big.Int, msg []byte) []byte {
+	return new(bn256.G1).ScalarMult(hashToG1(msg), sk).Marshal()
+}
+
+// verify checks the signature on msg against the public key.
+func verify(msg []byte, sig *bn256.G1, pk *bn256.G2) bool {
+	h := new(bn256.G1).Neg(hashToG1(msg))
+	return bn256.PairingCheck([]*bn256.G1{sig, h}, []*bn256.G2{g2, pk})
+}
+
+// unmarshalSig decodes a signature. The point at infinity is rejected.
+func unmarshalSig(sig []byte) (*bn256.G1, error) {
+	if len(sig) != SigLen {
+		return nil, errors.Errorf("expected %d bytes for a signature but got %d", SigLen, len(sig))
+	}
+	if isZero(sig) {
+		return nil, errors.New("signature is the point at infinity")
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(sig); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling signature")
+	}
+	return p, nil
+}
+
+// coefficients returns the aggregation coefficients of the public keys. The
+// coefficient of a key is derived from the key and all keys, so a rogue key
+// cannot cancel out the other keys.
+func coefficients(pks [][]byte) []*big.Int {
+	all := sha256.New()
+	all.Write([]byte(coeffDomain)) // nolint:errcheck
+	for _, pk := range pks {
+		all.Write(pk) // nolint:errcheck
+	}
+	digest := all.Sum(nil)
+
+	coeffs := make([]*big.Int, len(pks))
+	for i, pk := range pks {
+		h := sha256.New()
+		h.Write(digest) // nolint:errcheck
+		h.Write(pk)     // nolint:errcheck
+		// 128 bit coefficients suffice for the security of the aggregation.
+		coeffs[i] = new(big.Int).SetBytes(h.Sum(nil)[:16])
+	}
+	return coeffs
+}
+
+// putBig writes x left-padded into buf.
+func putBig(buf []byte, x *big.Int) {
+	b := x.Bytes()
+	for i := range buf[:len(buf)-len(b)] {
+		buf[i] = 0
+	}
+	copy(buf[len(buf)-len(b):], b)
+}
+
+func isZero(b []byte) bool {
+	for _, x := range b {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
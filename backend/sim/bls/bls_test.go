@@ -0,0 +1,97 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/backend/sim/bls"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wallet/test"
+)
+
+func TestGenericTests(t *testing.T) {
+	t.Run("Generic Address Test", func(t *testing.T) {
+		rng := pkgtest.Prng(t, "address")
+		test.GenericAddressTest(t, newWalletSetup(rng))
+	})
+	t.Run("Generic Signature Test", func(t *testing.T) {
+		rng := pkgtest.Prng(t, "signature")
+		test.GenericSignatureTest(t, newWalletSetup(rng))
+		test.GenericSignatureSizeTest(t, newWalletSetup(rng))
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	msg := []byte("state")
+	const n = 4
+	sigs, addrs := make([]wallet.Sig, n), make([]wallet.Address, n)
+	for i := range sigs {
+		acc := bls.NewRandomAccount(rng)
+		addrs[i] = acc.Address()
+		var err error
+		sigs[i], err = acc.SignData(msg)
+		require.NoError(t, err)
+	}
+
+	agg, err := bls.Aggregate(sigs, addrs)
+	require.NoError(t, err)
+	assert.Len(t, agg, bls.SigLen)
+	ok, err := bls.VerifyAggregate(msg, agg, addrs)
+	require.NoError(t, err)
+	assert.True(t, ok, "aggregated signature should be valid")
+
+	ok, err = bls.VerifyAggregate([]byte("other state"), agg, addrs)
+	require.NoError(t, err)
+	assert.False(t, ok, "aggregated signature on other message should be invalid")
+
+	ok, err = bls.VerifyAggregate(msg, agg, addrs[1:])
+	require.NoError(t, err)
+	assert.False(t, ok, "aggregated signature should be invalid for fewer addresses")
+
+	swapped := []wallet.Address{addrs[1], addrs[0], addrs[2], addrs[3]}
+	ok, err = bls.VerifyAggregate(msg, agg, swapped)
+	require.NoError(t, err)
+	assert.False(t, ok, "aggregated signature should be bound to the address order")
+
+	// An aggregate that misses a signature is invalid.
+	partial, err := bls.Aggregate(sigs[:n-1], addrs[:n-1])
+	require.NoError(t, err)
+	ok, err = bls.VerifyAggregate(msg, partial, addrs)
+	require.NoError(t, err)
+	assert.False(t, ok, "partial aggregated signature should be invalid")
+
+	_, err = bls.Aggregate(sigs, addrs[1:])
+	assert.Error(t, err, "number of signatures and addresses should match")
+	_, err = bls.VerifyAggregate(msg, make(wallet.Sig, bls.SigLen), addrs)
+	assert.Error(t, err, "point at infinity should be rejected")
+}
+
+func newWalletSetup(rng *rand.Rand) *test.Setup {
+	accountA := bls.NewRandomAccount(rng)
+	accountB := bls.NewRandomAccount(rng)
+	return &test.Setup{
+		Backend:         new(bls.Backend),
+		UnlockedAccount: func() (wallet.Account, error) { return accountA, nil },
+		AddressBytes:    accountB.Address().Bytes(),
+		DataToSign:      []byte("pay 1 to participant 0"),
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bls contains a simulated wallet backend with BLS signatures on the
+// BN256 curve. Signatures of several accounts on the same message can be
+// aggregated into a single signature, which is verified with one pairing
+// check. Aggregation uses key-dependent coefficients, which makes it secure
+// against rogue-key attacks.
+//
+// The backend is not registered globally. It can be injected with
+// channel.Backends, together with the BLS channel backend of the simulated
+// channel backend.
+package bls // import "perun.network/go-perun/backend/sim/bls"
@@ -70,18 +70,11 @@ func (*backend) CalcID(p *channel.Params) channel.ID {
 func (b *backend) Sign(addr wallet.Account, params *channel.Params, state *channel.State) ([]byte, error) {
 	log.Tracef("Signing state %s version %d", string(state.ID[:]), state.Version)
 
-	buff := new(bytes.Buffer)
-	w := bufio.NewWriter(buff)
-
-	if err := b.encodeState(*state, w); err != nil {
-		return nil, errors.WithMessage(err, "pack state")
+	data, err := b.signedData(state)
+	if err != nil {
+		return nil, err
 	}
-
-	if err := w.Flush(); err != nil {
-		log.Panic("bufio flush")
-	}
-
-	return addr.SignData(buff.Bytes())
+	return addr.SignData(data)
 }
 
 // Verify verifies the signature for `state`.
@@ -91,18 +84,26 @@ func (b *backend) Verify(addr wallet.Address, params *channel.Params, state *cha
 	}
 	log.Tracef("Verifying state %s version %d", string(state.ID[:]), state.Version)
 
+	data, err := b.signedData(state)
+	if err != nil {
+		return false, err
+	}
+	return wallet.VerifySignature(data, sig, addr)
+}
+
+// signedData returns the encoding of the state that is signed.
+func (b *backend) signedData(state *channel.State) ([]byte, error) {
 	buff := new(bytes.Buffer)
 	w := bufio.NewWriter(buff)
 
 	if err := b.encodeState(*state, w); err != nil {
-		return false, errors.WithMessage(err, "pack state")
+		return nil, errors.WithMessage(err, "pack state")
 	}
 
 	if err := w.Flush(); err != nil {
 		log.Panic("bufio flush")
 	}
-
-	return wallet.VerifySignature(buff.Bytes(), sig, addr)
+	return buff.Bytes(), nil
 }
 
 // encodeState packs all fields of a State into a []byte.
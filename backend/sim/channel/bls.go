@@ -0,0 +1,67 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/backend/sim/bls"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+)
+
+// BLSBackend is a channel backend for participants with BLS accounts of
+// package bls. It calculates channel IDs, encodes states and decodes assets
+// like the default simulated backend. The signatures of all participants on a
+// state can be aggregated and verified with a single check.
+//
+// It is not registered globally and should be used with channel.Backends,
+// together with a bls.Backend as wallet backend.
+type BLSBackend struct {
+	backend
+	wallet bls.Backend
+}
+
+var _ channel.AggregatingBackend = (*BLSBackend)(nil)
+
+// Verify verifies the BLS signature for `state`.
+func (b *BLSBackend) Verify(addr wallet.Address, params *channel.Params, state *channel.State, sig []byte) (bool, error) {
+	if err := state.Valid(); err != nil {
+		return false, errors.Wrap(err, "verifying invalid state")
+	}
+	data, err := b.signedData(state)
+	if err != nil {
+		return false, err
+	}
+	return b.wallet.VerifySignature(data, sig, addr)
+}
+
+// AggregateSigs aggregates the signatures of all participants on a state.
+func (b *BLSBackend) AggregateSigs(params *channel.Params, sigs []wallet.Sig) (wallet.Sig, error) {
+	return bls.Aggregate(sigs, params.Parts)
+}
+
+// VerifyAggregate verifies the aggregated signature of all participants on
+// the state with a single pairing check.
+func (b *BLSBackend) VerifyAggregate(params *channel.Params, state *channel.State, sig wallet.Sig) (bool, error) {
+	if err := state.Valid(); err != nil {
+		return false, errors.Wrap(err, "verifying invalid state")
+	}
+	data, err := b.signedData(state)
+	if err != nil {
+		return false, err
+	}
+	return bls.VerifyAggregate(data, sig, params.Parts)
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/backend/sim/bls"
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+)
+
+// blsBackends are the backends for BLS channels. App definitions are BLS
+// addresses that resolve to mock apps.
+var blsBackends = channel.Backends{
+	Channel: new(BLSBackend),
+	Wallet:  new(bls.Backend),
+	App:     new(channel.MockAppBackend),
+}
+
+func TestBLSBackend(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	t.Run("Generic", func(t *testing.T) {
+		params, state := newRandomBLSParamsAndState(rng, nil)
+		params2, state2 := newRandomBLSParamsAndState(rng, nil, chtest.WithIsFinal(!state.IsFinal))
+		chtest.GenericBackendTest(t, &chtest.Setup{
+			Params:        params,
+			Params2:       params2,
+			State:         state,
+			State2:        state2,
+			Account:       bls.NewRandomAccount(rng),
+			RandomAddress: func() wallet.Address { return bls.NewRandomAddress(rng) },
+		})
+	})
+
+	t.Run("Aggregate", func(t *testing.T) {
+		accs := make([]wallet.Account, 5)
+		for i := range accs {
+			accs[i] = bls.NewRandomAccount(rng)
+		}
+		params, state := newRandomBLSParamsAndState(rng, accs)
+		tx := channel.Transaction{State: state, Sigs: make([]wallet.Sig, len(accs))}
+		require.Error(t, blsBackends.AggregateTx(params, &tx), "missing signatures")
+		for i, acc := range accs {
+			var err error
+			tx.Sigs[i], err = blsBackends.Sign(acc, params, state)
+			require.NoError(t, err)
+		}
+		require.NoError(t, blsBackends.AggregateTx(params, &tx))
+
+		// Only the aggregated signature is needed.
+		aggTx := channel.Transaction{State: state, AggSig: tx.AggSig}
+		assert.NoError(t, blsBackends.VerifyTx(params, aggTx))
+		var buf bytes.Buffer
+		require.NoError(t, aggTx.Encode(&buf))
+		decTx, err := blsBackends.DecodeTransaction(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, aggTx.AggSig, decTx.AggSig)
+		assert.NoError(t, blsBackends.VerifyTx(params, decTx))
+
+		aggTx.State = state.Clone()
+		aggTx.Version++
+		assert.Error(t, blsBackends.VerifyTx(params, aggTx), "aggregate on other state")
+		aggTx.State, aggTx.AggSig = state, tx.Sigs[0]
+		assert.Error(t, blsBackends.VerifyTx(params, aggTx), "single signature is no aggregate")
+	})
+}
+
+// newRandomBLSParamsAndState creates random params with the BLS backends and
+// a random state of them. If accs is nil, the participants are random.
+func newRandomBLSParamsAndState(rng *rand.Rand, accs []wallet.Account, opts ...chtest.RandomOpt) (*channel.Params, *channel.State) {
+	if accs == nil {
+		accs = make([]wallet.Account, 2+rng.Intn(3))
+		for i := range accs {
+			accs[i] = bls.NewRandomAccount(rng)
+		}
+	}
+	parts := make([]wallet.Address, len(accs))
+	for i, acc := range accs {
+		parts[i] = acc.Address()
+	}
+
+	tmpl := chtest.NewRandomParams(rng, chtest.WithNumParts(len(parts)))
+	params, err := blsBackends.NewParams(tmpl.ChallengeDuration, parts, bls.NewRandomAddress(rng), tmpl.Nonce)
+	if err != nil {
+		panic(err)
+	}
+	opts = append(opts, chtest.WithParams(params), chtest.WithNumLocked(int(rng.Int31n(4)+1)))
+	return params, chtest.NewRandomState(rng, opts...)
+}
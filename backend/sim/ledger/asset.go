@@ -0,0 +1,51 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"io"
+
+	"perun.network/go-perun/channel/multi"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// Asset is a simulated asset that is held on a simulated Ledger. It is
+// identified by its ID and the ID of the ledger.
+type Asset struct {
+	ID     int64
+	Ledger multi.LedgerID
+}
+
+var _ multi.Asset = new(Asset)
+
+// Encode encodes the Asset into the io.Writer `w`.
+func (a Asset) Encode(w io.Writer) error {
+	return perunio.Encode(w, string(a.Ledger), a.ID)
+}
+
+// Decode decodes an Asset from the io.Reader `r`.
+func (a *Asset) Decode(r io.Reader) error {
+	var ledger string
+	if err := perunio.Decode(r, &ledger, &a.ID); err != nil {
+		return err
+	}
+	a.Ledger = multi.LedgerID(ledger)
+	return nil
+}
+
+// LedgerID returns the ID of the ledger on which the asset is held.
+func (a Asset) LedgerID() multi.LedgerID {
+	return a.Ledger
+}
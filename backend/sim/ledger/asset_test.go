@@ -0,0 +1,40 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	simledger "perun.network/go-perun/backend/sim/ledger"
+	"perun.network/go-perun/channel"
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestAsset(t *testing.T) {
+	asset := simledger.NewLedger("chain").NewAsset(42)
+	assert.Equal(t, &simledger.Asset{ID: 42, Ledger: "chain"}, asset)
+	iotest.GenericSerializerTest(t, asset)
+
+	var buf bytes.Buffer
+	require.NoError(t, asset.Encode(&buf))
+	decoded, err := channel.Backends{Channel: simledger.Backend{}}.DecodeAsset(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, asset, decoded)
+}
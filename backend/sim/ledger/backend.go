@@ -0,0 +1,53 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"io"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+)
+
+// Backend is a channel backend that decodes ledger Assets. It calculates
+// channel IDs, signs and verifies states with the global channel backend,
+// which must be the simulated backend.
+//
+// It is not registered globally and should be used with channel.Backends by
+// all clients and decoders that handle states with ledger Assets.
+type Backend struct{}
+
+var _ channel.Backend = Backend{}
+
+// CalcID calculates the channel ID with the global channel backend.
+func (Backend) CalcID(p *channel.Params) channel.ID {
+	return channel.CalcID(p)
+}
+
+// Sign signs the state with the global channel backend.
+func (Backend) Sign(acc wallet.Account, p *channel.Params, s *channel.State) (wallet.Sig, error) {
+	return channel.Sign(acc, p, s)
+}
+
+// Verify verifies the signature with the global channel backend.
+func (Backend) Verify(addr wallet.Address, p *channel.Params, s *channel.State, sig wallet.Sig) (bool, error) {
+	return channel.Verify(addr, p, s, sig)
+}
+
+// DecodeAsset decodes a ledger Asset from the io.Reader `r`.
+func (Backend) DecodeAsset(r io.Reader) (channel.Asset, error) {
+	var a Asset
+	return &a, a.Decode(r)
+}
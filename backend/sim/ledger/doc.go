@@ -0,0 +1,23 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ledger contains a simulated ledger that funds and adjudicates
+// channels, and the assets that are held on it. Multiple ledgers can be
+// combined with package channel/multi to simulate channels with assets on
+// different ledgers.
+//
+// The assets of a ledger are encoded together with the ledger's ID, unlike
+// the assets of the simulated channel backend. States with ledger assets must
+// therefore be decoded with the ledger Backend.
+package ledger // import "perun.network/go-perun/backend/sim/ledger"
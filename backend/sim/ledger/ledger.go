@@ -0,0 +1,484 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/multi"
+	"perun.network/go-perun/wallet"
+)
+
+type (
+	// Ledger is a simulated ledger that holds the deposits of channels. It
+	// implements channel.Funder and channel.Adjudicator for the Assets that
+	// are held on it. Multiple Ledgers can be combined with a multi.Funder and
+	// multi.Adjudicator to simulate channels with assets on different ledgers.
+	//
+	// Registered non-final states can be withdrawn after the challenge
+	// duration, which is interpreted in seconds. After the challenge duration
+	// of a registration, the state can be progressed by valid app transitions
+	// until it is withdrawn. Each progression starts a new challenge duration.
+	// The time of progressed states of a channel.TimedApp must not lie after
+	// the ledger time, which is the local clock. The balances of withdrawn
+	// states of a channel.SettleApp are settled by the app at the ledger time.
+	Ledger struct {
+		id multi.LedgerID
+
+		mu       sync.Mutex
+		changed  chan struct{} // closed and replaced on every deposit
+		deposits map[channel.ID]map[int64][]channel.Bal
+		regs     map[channel.ID]*registration
+		paidOut  map[channel.ID]map[channel.Index]bool
+		balances map[wallet.AddrKey]map[int64]*big.Int
+		subs     map[channel.ID]map[*ledgerSub]struct{}
+	}
+
+	// registration is a state registered on the Ledger.
+	registration struct {
+		tx         channel.Transaction
+		deadline   time.Time
+		event      *channel.RegisteredEvent
+		progressed bool
+	}
+
+	// ledgerSub is a subscription to the RegisteredEvents of a channel on a
+	// Ledger.
+	ledgerSub struct {
+		ctx    context.Context
+		ledger *Ledger
+		id     channel.ID
+		next   chan *channel.RegisteredEvent
+		done   chan struct{}
+		once   sync.Once
+		err    error
+	}
+)
+
+var (
+	_ channel.Funder      = (*Ledger)(nil)
+	_ channel.Adjudicator = (*Ledger)(nil)
+)
+
+// NewLedger creates a new, empty simulated ledger with the given ID.
+func NewLedger(id multi.LedgerID) *Ledger {
+	return &Ledger{
+		id:       id,
+		changed:  make(chan struct{}),
+		deposits: make(map[channel.ID]map[int64][]channel.Bal),
+		regs:     make(map[channel.ID]*registration),
+		paidOut:  make(map[channel.ID]map[channel.Index]bool),
+		balances: make(map[wallet.AddrKey]map[int64]*big.Int),
+		subs:     make(map[channel.ID]map[*ledgerSub]struct{}),
+	}
+}
+
+// ID returns the ID of the ledger.
+func (l *Ledger) ID() multi.LedgerID {
+	return l.id
+}
+
+// NewAsset creates a new Asset with the given ID that is held on the ledger.
+func (l *Ledger) NewAsset(id int64) *Asset {
+	return &Asset{ID: id, Ledger: l.id}
+}
+
+// Balance returns the funds of asset that were withdrawn to addr from
+// channels on the ledger.
+func (l *Ledger) Balance(addr wallet.Address, asset *Asset) channel.Bal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bal, ok := l.balances[wallet.Key(addr)][asset.ID]; ok {
+		return new(big.Int).Set(bal)
+	}
+	return big.NewInt(0)
+}
+
+// Fund deposits the own balances of all assets of the funding request and
+// waits until all participants have deposited theirs. All assets must be held
+// on the ledger. If the context is done before, a FundingTimeoutError is
+// returned.
+func (l *Ledger) Fund(ctx context.Context, req channel.FundingReq) error {
+	assets, err := l.ownAssets(req.State.Assets, false)
+	if err != nil {
+		return err
+	}
+	id := req.Params.ID()
+	numParts := len(req.Params.Parts)
+
+	l.mu.Lock()
+	if l.deposits[id] == nil {
+		l.deposits[id] = make(map[int64][]channel.Bal)
+	}
+	for i, a := range assets {
+		deps := l.deposits[id][a.ID]
+		if deps == nil {
+			deps = make([]channel.Bal, numParts)
+			l.deposits[id][a.ID] = deps
+		}
+		deps[req.Idx] = new(big.Int).Set(req.State.Balances[i][req.Idx])
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+	l.mu.Unlock()
+
+	for {
+		l.mu.Lock()
+		missing := l.missingDeposits(id, assets, numParts)
+		changed := l.changed
+		l.mu.Unlock()
+		if len(missing) == 0 {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return channel.NewFundingTimeoutError(missing)
+		}
+	}
+}
+
+// Register registers the state of the request after verifying all signatures.
+// If the same or a newer state is already registered, the event of the
+// registered state is returned.
+func (l *Ledger) Register(ctx context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	if err := verifyTx(req.Params, req.Tx); err != nil {
+		return nil, err
+	}
+	id := req.Params.ID()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if reg, ok := l.regs[id]; ok && reg.tx.Version >= req.Tx.Version {
+		return reg.event, nil
+	} else if ok && reg.progressed {
+		return nil, errors.New("cannot register state of progressed channel")
+	}
+
+	reg := &registration{tx: req.Tx.Clone()}
+	var timeout channel.Timeout = new(channel.ElapsedTimeout)
+	if !req.Tx.IsFinal {
+		reg.deadline = time.Now().Add(time.Duration(req.Params.ChallengeDuration) * time.Second)
+		timeout = &channel.TimeTimeout{Time: reg.deadline}
+	}
+	reg.event = &channel.RegisteredEvent{ID: id, Version: req.Tx.Version, Timeout: timeout}
+	l.regs[id] = reg
+
+	for sub := range l.subs[id] {
+		sub.push(reg.event)
+	}
+	return reg.event, nil
+}
+
+// Progress progresses the registered state of the channel to the new state of
+// the request after verifying the actor's signature and that the new state is
+// a valid transition of the channel's StateApp. The registered state must have
+// been registered or progressed before and must not be withdrawn yet. A
+// registered state can be progressed after its challenge duration, a
+// progressed state during its challenge duration. Progressing to the same
+// state again is a no-op.
+func (l *Ledger) Progress(ctx context.Context, req channel.ProgressReq) error {
+	if err := validProgression(req, time.Now()); err != nil {
+		return err
+	}
+	id := req.Params.ID()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	reg, ok := l.regs[id]
+	switch {
+	case !ok:
+		return errors.New("channel not registered")
+	case reg.progressed && reg.tx.Version == req.NewState.Version && reg.tx.State.Equal(req.NewState) == nil:
+		return nil
+	case reg.tx.Version != req.Tx.Version || reg.tx.State.Equal(req.Tx.State) != nil:
+		return errors.New("state to progress from is not registered")
+	case len(l.paidOut[id]) != 0:
+		return errors.New("channel already withdrawn")
+	case !reg.progressed && time.Now().Before(reg.deadline):
+		return errors.Errorf("challenge duration of registered state not passed until %v", reg.deadline)
+	case reg.progressed && !time.Now().Before(reg.deadline):
+		return errors.Errorf("challenge duration of progressed state passed at %v", reg.deadline)
+	}
+
+	reg = &registration{
+		tx:         channel.Transaction{State: req.NewState.Clone(), Sigs: make([]wallet.Sig, len(req.Params.Parts))},
+		deadline:   time.Now().Add(time.Duration(req.Params.ChallengeDuration) * time.Second),
+		progressed: true,
+	}
+	reg.tx.Sigs[req.Idx] = req.Sig
+	reg.event = &channel.RegisteredEvent{
+		ID:      id,
+		Version: req.NewState.Version,
+		Timeout: &channel.TimeTimeout{Time: reg.deadline},
+		State:   req.NewState.Clone(),
+	}
+	l.regs[id] = reg
+
+	for sub := range l.subs[id] {
+		sub.push(reg.event)
+	}
+	return nil
+}
+
+// Withdraw pays out the balances of the withdrawing participant of all assets
+// that are held on the ledger. A final state is withdrawn directly. Otherwise,
+// the registered state is withdrawn once its challenge duration has passed.
+// The locked funds of a channel.SettleApp's state are paid out as settled by
+// the app. Subsequent withdrawals of the same participant are no-ops.
+func (l *Ledger) Withdraw(ctx context.Context, req channel.AdjudicatorReq) error {
+	id, numParts := req.Params.ID(), len(req.Params.Parts)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.paidOut[id][req.Idx] {
+		return nil
+	}
+
+	state := req.Tx.State
+	if reg, ok := l.regs[id]; ok && (!req.Tx.IsFinal || reg.tx.Version >= req.Tx.Version) {
+		if time.Now().Before(reg.deadline) {
+			return errors.Errorf("challenge duration of registered state not passed until %v", reg.deadline)
+		}
+		state = reg.tx.State
+	} else if !req.Tx.IsFinal {
+		return errors.New("cannot withdraw unregistered non-final state")
+	} else if err := verifyTx(req.Params, req.Tx); err != nil {
+		return err
+	}
+
+	assets, err := l.ownAssets(state.Assets, true)
+	if err != nil {
+		return err
+	}
+	if missing := l.missingDeposits(id, assets, numParts); len(missing) != 0 {
+		return errors.Errorf("channel not funded: %v", channel.NewFundingTimeoutError(missing))
+	}
+	balances := state.Balances
+	if app, ok := req.Params.App.(channel.SettleApp); ok {
+		if balances, err = app.Settle(req.Params, state, time.Now()); err != nil {
+			return errors.WithMessage(err, "settling locked funds")
+		}
+	}
+
+	key := wallet.Key(req.Params.Parts[req.Idx])
+	if l.balances[key] == nil {
+		l.balances[key] = make(map[int64]*big.Int)
+	}
+	for i, a := range state.Assets {
+		if a, ok := a.(*Asset); ok && a.Ledger == l.id {
+			bal := l.balances[key][a.ID]
+			if bal == nil {
+				bal = new(big.Int)
+				l.balances[key][a.ID] = bal
+			}
+			bal.Add(bal, balances[i][req.Idx])
+		}
+	}
+	if l.paidOut[id] == nil {
+		l.paidOut[id] = make(map[channel.Index]bool)
+	}
+	l.paidOut[id][req.Idx] = true
+	return nil
+}
+
+// SubscribeRegistered returns a subscription to the RegisteredEvents of the
+// channel. If a state is already registered, its event is returned first.
+func (l *Ledger) SubscribeRegistered(ctx context.Context, params *channel.Params) (channel.RegisteredSubscription, error) {
+	id := params.ID()
+	sub := &ledgerSub{
+		ctx:    ctx,
+		ledger: l,
+		id:     id,
+		next:   make(chan *channel.RegisteredEvent, 1),
+		done:   make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.subs[id] == nil {
+		l.subs[id] = make(map[*ledgerSub]struct{})
+	}
+	l.subs[id][sub] = struct{}{}
+	if reg, ok := l.regs[id]; ok {
+		sub.push(reg.event)
+	}
+	return sub, nil
+}
+
+// ownAssets returns the assets as ledger Assets. If skipForeign is false, it
+// returns an error if any asset is not held on the ledger. Otherwise, such
+// assets are skipped.
+func (l *Ledger) ownAssets(assets []channel.Asset, skipForeign bool) ([]*Asset, error) {
+	own := make([]*Asset, 0, len(assets))
+	for i, a := range assets {
+		sa, ok := a.(*Asset)
+		if !ok || sa.Ledger != l.id {
+			if skipForeign {
+				continue
+			}
+			return nil, errors.Errorf("asset %d is not held on ledger %q", i, l.id)
+		}
+		own = append(own, sa)
+	}
+	return own, nil
+}
+
+// missingDeposits returns the participants out of numParts that did not
+// deposit the assets of channel id yet, per asset. The asset indices refer to the passed assets.
+//
+// The caller is expected to have locked the ledger mutex.
+func (l *Ledger) missingDeposits(id channel.ID, assets []*Asset, numParts int) []*channel.AssetFundingError {
+	var missing []*channel.AssetFundingError
+	for i, a := range assets {
+		var peers []channel.Index
+		deps := l.deposits[id][a.ID]
+		for idx := 0; idx < numParts; idx++ {
+			if idx >= len(deps) || deps[idx] == nil {
+				peers = append(peers, channel.Index(idx))
+			}
+		}
+		if len(peers) > 0 {
+			missing = append(missing, &channel.AssetFundingError{Asset: i, TimedOutPeers: peers})
+		}
+	}
+	return missing
+}
+
+// verifyTx verifies that the transaction is signed by all participants.
+func verifyTx(params *channel.Params, tx channel.Transaction) error {
+	if len(tx.Sigs) != len(params.Parts) {
+		return errors.Errorf("expected %d signatures, got %d", len(params.Parts), len(tx.Sigs))
+	}
+	for i, part := range params.Parts {
+		if tx.Sigs[i] == nil {
+			return errors.Errorf("missing signature of participant %d", i)
+		}
+		ok, err := params.Backends().Verify(part, params, tx.State, tx.Sigs[i])
+		if err != nil {
+			return errors.WithMessagef(err, "verifying signature of participant %d", i)
+		}
+		if !ok {
+			return errors.Errorf("invalid signature of participant %d", i)
+		}
+	}
+	return nil
+}
+
+// validProgression verifies that the new state of the request is a valid
+// transition of the channel's StateApp by the actor, who signed it. The time
+// of the new state of a TimedApp must not be after the ledger time now.
+func validProgression(req channel.ProgressReq, now time.Time) error {
+	from, to := req.Tx.State, req.NewState
+	switch {
+	case int(req.Idx) >= len(req.Params.Parts):
+		return errors.New("actor index out of range")
+	case to.ID != req.Params.ID():
+		return errors.New("new state's ID does not match")
+	case from.IsFinal:
+		return errors.New("cannot progress final state")
+	case to.Version != from.Version+1:
+		return errors.New("version must increase by one")
+	}
+	if err := to.Allocation.Valid(); err != nil {
+		return errors.WithMessage(err, "invalid allocation")
+	}
+	fromSum, toSum := from.Sum(), to.Sum()
+	if len(fromSum) != len(toSum) {
+		return errors.New("number of assets changed")
+	}
+	for i := range fromSum {
+		if fromSum[i].Cmp(toSum[i]) != 0 {
+			return errors.Errorf("sum of asset %d not preserved", i)
+		}
+	}
+
+	app, ok := req.Params.App.(channel.StateApp)
+	if !ok {
+		return errors.New("channel app is not a StateApp")
+	}
+	if err := app.ValidTransition(req.Params, from, to, req.Idx); err != nil {
+		return errors.WithMessage(err, "invalid app transition")
+	}
+	if app, ok := app.(channel.TimedApp); ok {
+		t, err := app.StateTime(to)
+		if err != nil {
+			return errors.WithMessage(err, "reading state time")
+		} else if t.After(now) {
+			return errors.Errorf("state time %v is after ledger time %v", t, now)
+		}
+	}
+
+	ok, err := req.Params.Backends().Verify(req.Params.Parts[req.Idx], req.Params, to, req.Sig)
+	if err != nil {
+		return errors.WithMessage(err, "verifying actor's signature")
+	} else if !ok {
+		return errors.New("invalid signature of actor")
+	}
+	return nil
+}
+
+// push replaces a pending event with ev.
+//
+// The caller is expected to have locked the ledger mutex.
+func (s *ledgerSub) push(ev *channel.RegisteredEvent) {
+	select {
+	case <-s.next:
+	default:
+	}
+	s.next <- ev
+}
+
+// Next returns the newest registered event. It blocks until there is one,
+// the subscription is closed or its context is done.
+func (s *ledgerSub) Next() *channel.RegisteredEvent {
+	select {
+	case ev := <-s.next:
+		return ev
+	case <-s.done:
+		return nil
+	case <-s.ctx.Done():
+		s.ledger.mu.Lock()
+		s.err = s.ctx.Err()
+		s.ledger.mu.Unlock()
+		return nil
+	}
+}
+
+// Err returns the error of the subscription, which is the context's error if
+// it is done.
+func (s *ledgerSub) Err() error {
+	s.ledger.mu.Lock()
+	defer s.ledger.mu.Unlock()
+	return s.err
+}
+
+// Close closes the subscription.
+func (s *ledgerSub) Close() error {
+	s.once.Do(func() {
+		s.ledger.mu.Lock()
+		defer s.ledger.mu.Unlock()
+		delete(s.ledger.subs[s.id], s)
+		close(s.done)
+	})
+	return nil
+}
@@ -30,9 +30,13 @@ type (
 	// A channel state needs to be registered before the concluded state can be
 	// withdrawn after a possible timeout.
 	//
+	// A registered state of a channel with an app can be progressed on-chain by
+	// a valid app transition, so that the channel can be advanced even if a peer
+	// refuses to sign the new state.
+	//
 	// Furthermore, it has a method for subscribing to RegisteredEvents. Those
-	// events might be triggered by a Register call on the adjudicator from any
-	// channel participant.
+	// events might be triggered by a Register or Progress call on the
+	// adjudicator from any channel participant.
 	Adjudicator interface {
 		// Register should register the given channel state on-chain. It must be
 		// taken into account that a peer might already have registered the same or
@@ -47,6 +51,14 @@ type (
 		// account that a peer might already have concluded the same channel.
 		Withdraw(context.Context, AdjudicatorReq) error
 
+		// Progress should progress the registered channel state req.Tx.State to
+		// the new state req.NewState on-chain. The new state must be a valid
+		// transition of the channel's app by participant req.Idx, whose
+		// signature on it is req.Sig. The signatures of req.Tx are not needed.
+		// Progression is only possible after the timeout of the registration
+		// has elapsed. Each progression starts a new timeout.
+		Progress(context.Context, ProgressReq) error
+
 		// SubscribeRegistered returns a RegisteredEvent subscription. The
 		// subscription should be a subscription of the newest past as well as
 		// future events. The subscription should only be valid within the given
@@ -55,6 +67,18 @@ type (
 		SubscribeRegistered(context.Context, *Params) (RegisteredSubscription, error)
 	}
 
+	// An AssetSubscriber is an Adjudicator that subscribes to the
+	// RegisteredEvents of a channel depending on the channel's assets, which
+	// are not known from its parameters, e.g., only on the ledgers on which the
+	// assets are held. Use SubscribeRegistered to subscribe on any Adjudicator.
+	AssetSubscriber interface {
+		Adjudicator
+
+		// SubscribeRegisteredAssets is like SubscribeRegistered for a channel
+		// with the given assets.
+		SubscribeRegisteredAssets(context.Context, *Params, []Asset) (RegisteredSubscription, error)
+	}
+
 	// An AdjudicatorReq collects all necessary information to make calls to the
 	// adjudicator.
 	AdjudicatorReq struct {
@@ -64,12 +88,23 @@ type (
 		Idx    Index
 	}
 
+	// A ProgressReq collects all necessary information to progress a
+	// registered channel state on the adjudicator. The transaction of the
+	// embedded AdjudicatorReq is the registered state and Idx the actor of the
+	// app transition.
+	ProgressReq struct {
+		AdjudicatorReq
+		NewState *State     // State to progress to
+		Sig      wallet.Sig // Actor's signature on NewState
+	}
+
 	// RegisteredEvent is the abstract event that signals a successful state
-	// registration on the blockchain.
+	// registration or progression on the blockchain.
 	RegisteredEvent struct {
 		ID      ID      // Channel ID
 		Version uint64  // Registered version.
 		Timeout Timeout // Timeout when the event can be concluded or progressed
+		State   *State  // Progressed state, nil if the state was registered or the backend could not read it
 	}
 
 	// A Timeout is an abstract timeout of a channel dispute. A timeout can be
@@ -86,7 +121,8 @@ type (
 
 	// A RegisteredSubscription is a subscription to RegisteredEvents for a
 	// specific channel. The subscription should also return the newest past
-	// RegisteredEvent, if there is any.
+	// RegisteredEvent, if there is any. Progressions of the registered state
+	// are delivered as RegisteredEvents that carry the progressed State.
 	//
 	// The usage of the subscription should be similar to that of an iterator.
 	// Next calls should block until a new event is generated (or the first past
@@ -123,7 +159,7 @@ func (t *ElapsedTimeout) String() string { return "<Always elapsed timeout>" }
 type TimeTimeout struct{ time.Time }
 
 // IsElapsed returns whether the current time is after the fixed timeout.
-func (t *TimeTimeout) IsElapsed(context.Context) bool { return t.After(time.Now()) }
+func (t *TimeTimeout) IsElapsed(context.Context) bool { return !time.Now().Before(t.Time) }
 
 // Wait waits until the timeout has elapsed or the context is cancelled.
 func (t *TimeTimeout) Wait(ctx context.Context) error {
@@ -139,3 +175,13 @@ func (t *TimeTimeout) Wait(ctx context.Context) error {
 func (t *TimeTimeout) String() string {
 	return fmt.Sprintf("<Timeout: %v>", t.Time)
 }
+
+// SubscribeRegistered subscribes to the RegisteredEvents of the channel with
+// the given parameters and assets on the adjudicator. The assets are passed to
+// an AssetSubscriber.
+func SubscribeRegistered(ctx context.Context, adj Adjudicator, params *Params, assets []Asset) (RegisteredSubscription, error) {
+	if as, ok := adj.(AssetSubscriber); ok {
+		return as.SubscribeRegisteredAssets(ctx, params, assets)
+	}
+	return adj.SubscribeRegistered(ctx, params)
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"perun.network/go-perun/channel"
+)
+
+func TestTimeTimeout_IsElapsed(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, (&channel.TimeTimeout{Time: time.Now().Add(-time.Second)}).IsElapsed(ctx))
+	assert.False(t, (&channel.TimeTimeout{Time: time.Now().Add(time.Hour)}).IsElapsed(ctx))
+}
@@ -163,8 +163,13 @@ func (a Allocation) Encode(w io.Writer) error {
 	return nil
 }
 
-// Decode decodes an allocation from an io.Reader.
+// Decode decodes an allocation from an io.Reader. The assets are decoded with
+// the global backend.
 func (a *Allocation) Decode(r io.Reader) error {
+	return a.decode(r, Backends{})
+}
+
+func (a *Allocation) decode(r io.Reader, b Backends) error {
 	// decode dimensions
 	var numAssets, numParts, numLocked Index
 	if err := perunio.Decode(r, &numAssets, &numParts, &numLocked); err != nil {
@@ -176,7 +181,7 @@ func (a *Allocation) Decode(r io.Reader) error {
 	// decode assets
 	a.Assets = make([]Asset, numAssets)
 	for i := range a.Assets {
-		asset, err := DecodeAsset(r)
+		asset, err := b.DecodeAsset(r)
 		if err != nil {
 			return errors.WithMessagef(err, "decoding asset %d", i)
 		}
@@ -16,6 +16,7 @@ package channel
 
 import (
 	"io"
+	"time"
 
 	perunio "perun.network/go-perun/pkg/io"
 	"perun.network/go-perun/wallet"
@@ -57,6 +58,31 @@ type (
 		ValidInit(*Params, *State) error
 	}
 
+	// A TimedApp is a StateApp whose states carry a time, which is set by the
+	// actor of an update and against which ValidTransition checks
+	// time-dependent rules, e.g., expiries. Since the actor chooses the time,
+	// adjudicators must not progress channels to states whose time lies after
+	// their own time, and clients reject updates whose time is far off their
+	// clock.
+	TimedApp interface {
+		StateApp
+
+		// StateTime returns the time of state s.
+		StateTime(s *State) (time.Time, error)
+	}
+
+	// A SettleApp is an App that pays out the locked funds of its states when
+	// they are withdrawn on-chain. Adjudicators pay out the balances returned
+	// by Settle instead of the state's balances.
+	SettleApp interface {
+		App
+
+		// Settle returns the balances of state s after paying out its locked
+		// funds at time now. It returns an error if the locked funds cannot be
+		// paid out yet.
+		Settle(params *Params, s *State, now time.Time) ([][]Bal, error)
+	}
+
 	// An ActionApp is advanced by first collecting actions from the participants
 	// and then applying those actions to the state. In a sense it is a more
 	// fine-grained version of a StateApp and allows for more optimized
@@ -123,27 +149,51 @@ func IsActionApp(app App) bool {
 	return ok
 }
 
-// appBackend stores the AppBackend globally for the channel package.
-var appBackend AppBackend = &MockAppBackend{}
+// appRegistry is the global app registry of the channel package. Its default
+// resolver is the MockAppBackend until it is changed with SetAppBackend.
+var appRegistry = &AppRegistry{fallback: &MockAppBackend{}}
 
-// isAppBackendSet whether the appBackend was already set with `SetAppBackend`.
+// isAppBackendSet whether the default app backend was already set with
+// `SetAppBackend`.
 var isAppBackendSet bool
 
-// SetAppBackend sets the channel package's app backend. This is more specific
-// than the blockchain backend, so it has to be set separately.
-// The app backend is set to the MockAppBackend by default. Because the MockApp is in
-// package channel, we cannot set it through the usual init.go idiom.
-// The app backend can be changed once by another app (by a SetAppBackend call
-// of the app package's init() function).
+// SetAppBackend sets the default resolver of the global app registry. It is
+// used for all app definitions that have no app registered with RegisterApp,
+// RegisterAppResolver or RegisterMatchingAppResolver.
+// The default is the MockAppBackend. Because the MockApp is in package
+// channel, we cannot set it through the usual init.go idiom.
+// The default app backend can be changed once. App packages that should be
+// usable side by side with other apps should use the Register functions
+// instead.
 func SetAppBackend(b AppBackend) {
 	if isAppBackendSet {
 		panic("app backend already set")
 	}
 	isAppBackendSet = true
-	appBackend = b
+	appRegistry.SetDefault(b)
+}
+
+// RegisterApp registers app under its definition in the global app registry.
+// It panics if something is already registered for the definition.
+func RegisterApp(app App) {
+	appRegistry.RegisterApp(app)
+}
+
+// RegisterAppResolver registers resolver for the app definition def in the
+// global app registry. It panics if something is already registered for the
+// definition.
+func RegisterAppResolver(def wallet.Address, resolver AppBackend) {
+	appRegistry.RegisterAppResolver(def, resolver)
+}
+
+// RegisterMatchingAppResolver registers resolver for all app definitions
+// matching match in the global app registry.
+func RegisterMatchingAppResolver(match AppDefPredicate, resolver AppBackend) {
+	appRegistry.RegisterMatchingAppResolver(match, resolver)
 }
 
-// AppFromDefinition is a global wrapper call to the app backend function.
+// AppFromDefinition resolves an app from its definition using the global app
+// registry.
 func AppFromDefinition(def wallet.Address) (App, error) {
-	return appBackend.AppFromDefinition(def)
+	return appRegistry.AppFromDefinition(def)
 }
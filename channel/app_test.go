@@ -25,18 +25,18 @@ import (
 func TestAppBackendSet(t *testing.T) {
 	test.OnlyOnce(t)
 
-	assert.NotNil(t, appBackend, "appBackend should be default initialized")
+	assert.NotNil(t, appRegistry.fallback, "default app backend should be initialized")
 	assert.False(t, isAppBackendSet, "isAppBackendSet should be defaulted to false")
 
-	old := appBackend
-	assert.NotPanics(t, func() { SetAppBackend(&MockAppBackend{}) }, "first SetAppBackend() should work")
+	old := appRegistry.fallback
+	assert.NotPanics(t, func() { SetAppBackend(&AppRegistry{fallback: &MockAppBackend{}}) }, "first SetAppBackend() should work")
 	assert.True(t, isAppBackendSet, "isAppBackendSet should be true")
-	assert.NotNil(t, appBackend, "appBackend should not be nil")
-	assert.False(t, old == appBackend, "appBackend should have changed")
+	assert.NotNil(t, appRegistry.fallback, "default app backend should not be nil")
+	assert.False(t, old == appRegistry.fallback, "default app backend should have changed")
 
-	old = appBackend
+	old = appRegistry.fallback
 	assert.Panics(t, func() { SetAppBackend(&MockAppBackend{}) }, "second SetAppBackend() should panic")
 	assert.True(t, isAppBackendSet, "isAppBackendSet should be true")
-	assert.NotNil(t, appBackend, "appBackend should not be nil")
-	assert.True(t, old == appBackend, "appBackend should not have changed")
+	assert.NotNil(t, appRegistry.fallback, "default app backend should not be nil")
+	assert.True(t, old == appRegistry.fallback, "default app backend should not have changed")
 }
@@ -0,0 +1,139 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/wallet"
+)
+
+type (
+	// AppRegistry is an AppBackend that resolves apps from multiple registered
+	// apps and resolvers. It allows to use several apps side by side.
+	//
+	// An app definition is resolved in the following order:
+	//  1. an App or resolver that was registered for exactly this definition,
+	//  2. the first registered resolver whose predicate matches the definition,
+	//  3. the default resolver, if set.
+	//
+	// An AppRegistry is safe for concurrent use. The zero value is an empty
+	// registry without a default resolver.
+	AppRegistry struct {
+		mu       sync.RWMutex
+		exact    map[wallet.AddrKey]AppBackend
+		matching []matchingResolver
+		fallback AppBackend
+	}
+
+	// AppResolverFunc is a function that resolves an app from its definition.
+	// It implements AppBackend.
+	AppResolverFunc func(def wallet.Address) (App, error)
+
+	// AppDefPredicate decides whether a resolver is responsible for an app
+	// definition.
+	AppDefPredicate func(def wallet.Address) bool
+
+	matchingResolver struct {
+		match    AppDefPredicate
+		resolver AppBackend
+	}
+
+	// staticApp resolves to a fixed app instance.
+	staticApp struct{ app App }
+)
+
+// AppFromDefinition calls f(def).
+func (f AppResolverFunc) AppFromDefinition(def wallet.Address) (App, error) {
+	return f(def)
+}
+
+func (s staticApp) AppFromDefinition(wallet.Address) (App, error) {
+	return s.app, nil
+}
+
+// NewAppRegistry creates a new, empty AppRegistry.
+func NewAppRegistry() *AppRegistry {
+	return new(AppRegistry)
+}
+
+// RegisterApp registers app under its definition app.Def(). It panics if
+// something is already registered for the definition.
+func (r *AppRegistry) RegisterApp(app App) {
+	r.RegisterAppResolver(app.Def(), staticApp{app})
+}
+
+// RegisterAppResolver registers resolver for the app definition def. It panics
+// if something is already registered for the definition.
+func (r *AppRegistry) RegisterAppResolver(def wallet.Address, resolver AppBackend) {
+	key := wallet.Key(def)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.exact[key]; ok {
+		panic("app already registered for definition " + def.String())
+	}
+	if r.exact == nil {
+		r.exact = make(map[wallet.AddrKey]AppBackend)
+	}
+	r.exact[key] = resolver
+}
+
+// RegisterMatchingAppResolver registers resolver for all app definitions for
+// which match returns true. Matching resolvers are consulted in the order in
+// which they were registered, after the exactly registered apps.
+func (r *AppRegistry) RegisterMatchingAppResolver(match AppDefPredicate, resolver AppBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.matching = append(r.matching, matchingResolver{match: match, resolver: resolver})
+}
+
+// SetDefault sets the default resolver that is used for all app definitions
+// for which no other app or resolver is registered. A nil resolver removes the
+// default resolver.
+func (r *AppRegistry) SetDefault(resolver AppBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = resolver
+}
+
+// AppFromDefinition resolves the app with definition def. It returns an error
+// if no app or resolver is registered for def and no default resolver is set.
+func (r *AppRegistry) AppFromDefinition(def wallet.Address) (App, error) {
+	if def == nil {
+		return nil, errors.New("app definition must not be nil")
+	}
+	resolver := r.resolver(def)
+	if resolver == nil {
+		return nil, errors.Errorf("no app registered for definition %v", def)
+	}
+	return resolver.AppFromDefinition(def)
+}
+
+// resolver returns the resolver responsible for def or nil if there is none.
+func (r *AppRegistry) resolver(def wallet.Address) AppBackend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if resolver, ok := r.exact[wallet.Key(def)]; ok {
+		return resolver
+	}
+	for _, m := range r.matching {
+		if m.match(def) {
+			return m.resolver
+		}
+	}
+	return r.fallback
+}
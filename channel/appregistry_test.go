@@ -0,0 +1,71 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestAppRegistry(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	r := channel.NewAppRegistry()
+	defA, defB, defC := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+
+	_, err := r.AppFromDefinition(defA)
+	assert.Error(t, err, "empty registry should not resolve apps")
+	_, err = r.AppFromDefinition(nil)
+	assert.Error(t, err, "nil definition should not be resolved")
+
+	appA := channel.NewMockApp(defA)
+	r.RegisterApp(appA)
+	assert.Panics(t, func() { r.RegisterApp(channel.NewMockApp(defA)) }, "double registration should panic")
+
+	var resolvedB int
+	r.RegisterAppResolver(defB, channel.AppResolverFunc(func(def wallet.Address) (channel.App, error) {
+		resolvedB++
+		return channel.NewMockApp(def), nil
+	}))
+	errNotC := errors.New("not C")
+	r.RegisterMatchingAppResolver(func(def wallet.Address) bool { return !def.Equals(defC) },
+		channel.AppResolverFunc(func(wallet.Address) (channel.App, error) { return nil, errNotC }))
+
+	app, err := r.AppFromDefinition(defA)
+	require.NoError(t, err)
+	assert.Same(t, appA, app, "registered app should be returned")
+
+	app, err = r.AppFromDefinition(defB)
+	require.NoError(t, err)
+	assert.True(t, app.Def().Equals(defB))
+	assert.Equal(t, 1, resolvedB, "registered resolver should be called")
+
+	_, err = r.AppFromDefinition(wallettest.NewRandomAddress(rng))
+	assert.Same(t, errNotC, err, "matching resolver should be called")
+	_, err = r.AppFromDefinition(defC)
+	assert.Error(t, err, "definition should not be resolved without default")
+
+	r.SetDefault(&channel.MockAppBackend{})
+	app, err = r.AppFromDefinition(defC)
+	require.NoError(t, err)
+	assert.True(t, app.Def().Equals(defC), "default resolver should be called")
+}
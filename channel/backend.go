@@ -50,6 +50,22 @@ type Backend interface {
 	DecodeAsset(io.Reader) (Asset, error)
 }
 
+// An AggregatingBackend is a Backend whose signatures of all participants on
+// a state can be aggregated into a single signature. An aggregated signature
+// is verified with a single check, independent of the number of participants.
+type AggregatingBackend interface {
+	Backend
+
+	// AggregateSigs aggregates the signatures of all participants, in the
+	// order of params.Parts, on the same state into a single signature.
+	AggregateSigs(params *Params, sigs []wallet.Sig) (wallet.Sig, error)
+
+	// VerifyAggregate verifies that the aggregated signature is an aggregate
+	// of valid signatures of all participants on the state. It returns an
+	// error iff the signature or state are malformed.
+	VerifyAggregate(params *Params, state *State, sig wallet.Sig) (bool, error)
+}
+
 // SetBackend sets the global channel backend. Must not be called directly but
 // through importing the needed backend.
 func SetBackend(b Backend) {
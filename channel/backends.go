@@ -0,0 +1,239 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// Backends bundles the channel backend, wallet backend and app backend that
+// are used for creating, de-/encoding, signing and verifying channel data. It
+// allows to use different backends side by side in the same program, e.g., one
+// per client.
+//
+// A nil field falls back to the respective global backend, as set by
+// SetBackend, wallet.SetBackend and SetAppBackend. Hence, the zero value uses
+// the global backends.
+type Backends struct {
+	Channel Backend
+	Wallet  wallet.Backend
+	App     AppBackend
+}
+
+func (b Backends) channel() Backend {
+	if b.Channel != nil {
+		return b.Channel
+	}
+	return backend
+}
+
+// CalcID calculates the channel ID of the given parameters.
+func (b Backends) CalcID(p *Params) ID {
+	return b.channel().CalcID(p)
+}
+
+// Sign creates a signature from the account a on state s.
+func (b Backends) Sign(a wallet.Account, p *Params, s *State) (wallet.Sig, error) {
+	return b.channel().Sign(a, p, s)
+}
+
+// Verify verifies that a signature was a valid signature from addr on a state.
+func (b Backends) Verify(addr wallet.Address, params *Params, state *State, sig wallet.Sig) (bool, error) {
+	return b.channel().Verify(addr, params, state, sig)
+}
+
+// AggregateTx sets the aggregated signature of the transaction from its
+// signatures, which must be complete. The channel backend must be an
+// AggregatingBackend.
+func (b Backends) AggregateTx(params *Params, tx *Transaction) error {
+	ab, ok := b.channel().(AggregatingBackend)
+	if !ok {
+		return errors.New("channel backend does not aggregate signatures")
+	}
+	for i, sig := range tx.Sigs {
+		if sig == nil {
+			return errors.Errorf("missing signature of participant %d", i)
+		}
+	}
+	aggSig, err := ab.AggregateSigs(params, tx.Sigs)
+	if err != nil {
+		return errors.WithMessage(err, "aggregating signatures")
+	}
+	tx.AggSig = aggSig
+	return nil
+}
+
+// VerifyTx verifies that the transaction's state is signed by all
+// participants. If the transaction carries an aggregated signature and the
+// channel backend is an AggregatingBackend, it is verified with a single
+// check. Otherwise, the signatures of all participants are verified
+// separately.
+func (b Backends) VerifyTx(params *Params, tx Transaction) error {
+	if ab, ok := b.channel().(AggregatingBackend); ok && tx.AggSig != nil {
+		if ok, err := ab.VerifyAggregate(params, tx.State, tx.AggSig); err != nil {
+			return errors.WithMessage(err, "verifying aggregated signature")
+		} else if !ok {
+			return errors.New("invalid aggregated signature")
+		}
+		return nil
+	}
+
+	if len(tx.Sigs) != len(params.Parts) {
+		return errors.Errorf("%d signatures for %d participants", len(tx.Sigs), len(params.Parts))
+	}
+	for i, sig := range tx.Sigs {
+		if sig == nil {
+			return errors.Errorf("missing signature of participant %d", i)
+		}
+		if ok, err := b.Verify(params.Parts[i], params, tx.State, sig); err != nil {
+			return errors.WithMessagef(err, "verifying signature of participant %d", i)
+		} else if !ok {
+			return errors.Errorf("invalid signature of participant %d", i)
+		}
+	}
+	return nil
+}
+
+// DecodeAsset decodes an Asset from an io.Reader.
+func (b Backends) DecodeAsset(r io.Reader) (Asset, error) {
+	return b.channel().DecodeAsset(r)
+}
+
+// DecodeAddress decodes a wallet address from an io.Reader.
+func (b Backends) DecodeAddress(r io.Reader) (wallet.Address, error) {
+	if b.Wallet != nil {
+		return b.Wallet.DecodeAddress(r)
+	}
+	return wallet.DecodeAddress(r)
+}
+
+// DecodeSig decodes a signature from an io.Reader.
+func (b Backends) DecodeSig(r io.Reader) (wallet.Sig, error) {
+	if b.Wallet != nil {
+		return b.Wallet.DecodeSig(r)
+	}
+	return wallet.DecodeSig(r)
+}
+
+// AppFromDefinition resolves an app from its definition address.
+func (b Backends) AppFromDefinition(def wallet.Address) (App, error) {
+	if b.App != nil {
+		return b.App.AppFromDefinition(def)
+	}
+	return AppFromDefinition(def)
+}
+
+// NewParams creates Params from the given data using the backends, like the
+// package-level NewParams. The returned Params use the backends for signing
+// and verifying.
+func (b Backends) NewParams(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) (*Params, error) {
+	if err := b.ValidateParameters(challengeDuration, len(parts), appDef, nonce); err != nil {
+		return nil, errors.WithMessage(err, "invalid parameter for NewParams")
+	}
+	return b.NewParamsUnsafe(challengeDuration, parts, appDef, nonce), nil
+}
+
+// ValidateParameters checks that the arguments form valid Params, like the
+// package-level ValidateParameters, resolving the app with the backends.
+func (b Backends) ValidateParameters(challengeDuration uint64, numParts int, appDef wallet.Address, nonce *big.Int) error {
+	if challengeDuration == 0 {
+		return errors.New("challengeDuration must be != 0")
+	}
+	if nonce == nil {
+		return errors.New("nonce must not be nil")
+	}
+	if numParts < 2 {
+		return errors.New("need at least two participants")
+	}
+	if numParts > MaxNumParts {
+		return errors.Errorf("too many participants, got: %d max: %d", numParts, MaxNumParts)
+	}
+	app, err := b.AppFromDefinition(appDef)
+	if err != nil {
+		return errors.WithMessage(err, "app from definition")
+	}
+	if !IsStateApp(app) && !IsActionApp(app) {
+		return errors.New("app must be either an Action- or StateApp")
+	}
+	return nil
+}
+
+// NewParamsUnsafe creates Params from the given data using the backends and
+// does NOT perform sanity checks, like the package-level NewParamsUnsafe.
+func (b Backends) NewParamsUnsafe(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) *Params {
+	app, err := b.AppFromDefinition(appDef)
+	if err != nil {
+		log.Panic("AppFromDefinition on validated parameters returned error")
+	}
+	p := &Params{
+		ChallengeDuration: challengeDuration,
+		Parts:             parts,
+		App:               app,
+		Nonce:             nonce,
+		backends:          b,
+	}
+	// probably an expensive hash operation, do it only once during creation.
+	p.id = b.CalcID(p)
+	return p
+}
+
+// DecodeParams decodes Params from an io.Reader using the backends. The
+// returned Params use the backends for signing and verifying.
+func (b Backends) DecodeParams(r io.Reader) (*Params, error) {
+	p := new(Params)
+	return p, p.decode(r, b)
+}
+
+// BackendsOf returns b if it is a Backends and the global backends otherwise.
+// Decoders of wire messages use it to decode with the backends that are passed
+// to them, see wire.RegisterBackendDecoder.
+func BackendsOf(b interface{}) Backends {
+	if b, ok := b.(Backends); ok {
+		return b
+	}
+	return Backends{}
+}
+
+// DecodeAllocation decodes an Allocation from an io.Reader using the
+// backends.
+func (b Backends) DecodeAllocation(r io.Reader) (*Allocation, error) {
+	a := new(Allocation)
+	return a, a.decode(r, b)
+}
+
+// DecodeState decodes a State from an io.Reader using the backends.
+func (b Backends) DecodeState(r io.Reader) (*State, error) {
+	s := new(State)
+	return s, s.decode(r, b)
+}
+
+// DecodeTransaction decodes a Transaction from an io.Reader using the
+// backends.
+func (b Backends) DecodeTransaction(r io.Reader) (Transaction, error) {
+	var t Transaction
+	return t, t.decode(r, b)
+}
+
+// DecodeTranscript decodes a Transcript from an io.Reader using the backends.
+func (b Backends) DecodeTranscript(r io.Reader) (*Transcript, error) {
+	t := new(Transcript)
+	return t, t.decode(r, b)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// countingBackend is a channel and wallet backend that counts the calls and
+// forwards them to the global backends.
+type countingBackend struct {
+	calcID, sign, verify, decodeAsset, decodeAddress, decodeSig int
+}
+
+func (b *countingBackend) CalcID(p *channel.Params) channel.ID {
+	b.calcID++
+	return channel.CalcID(p)
+}
+
+func (b *countingBackend) Sign(a wallet.Account, p *channel.Params, s *channel.State) (wallet.Sig, error) {
+	b.sign++
+	return channel.Sign(a, p, s)
+}
+
+func (b *countingBackend) Verify(addr wallet.Address, p *channel.Params, s *channel.State, sig wallet.Sig) (bool, error) {
+	b.verify++
+	return channel.Verify(addr, p, s, sig)
+}
+
+func (b *countingBackend) DecodeAsset(r io.Reader) (channel.Asset, error) {
+	b.decodeAsset++
+	return channel.DecodeAsset(r)
+}
+
+func (b *countingBackend) DecodeAddress(r io.Reader) (wallet.Address, error) {
+	b.decodeAddress++
+	return wallet.DecodeAddress(r)
+}
+
+func (b *countingBackend) DecodeSig(r io.Reader) (wallet.Sig, error) {
+	b.decodeSig++
+	return wallet.DecodeSig(r)
+}
+
+func (b *countingBackend) VerifySignature(msg []byte, sig wallet.Sig, a wallet.Address) (bool, error) {
+	return wallet.VerifySignature(msg, sig, a)
+}
+
+func TestBackends(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	accs, parts := wallettest.NewRandomAccounts(rng, 2)
+	cb := new(countingBackend)
+	b := channel.Backends{Channel: cb, Wallet: cb}
+
+	tmpl := test.NewRandomParams(rng, test.WithParts(parts...))
+	params, err := b.NewParams(tmpl.ChallengeDuration, parts, tmpl.App.Def(), tmpl.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cb.calcID)
+	assert.Equal(t, tmpl.ID(), params.ID())
+	assert.Equal(t, b, params.Backends())
+	assert.Equal(t, b, params.Clone().Backends())
+	assert.Equal(t, channel.Backends{}, tmpl.Backends(), "global backends by default")
+
+	t.Run("decoding", func(t *testing.T) {
+		*cb = countingBackend{}
+		var buf bytes.Buffer
+		require.NoError(t, params.Encode(&buf))
+		decParams, err := b.DecodeParams(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, params, decParams)
+		assert.Equal(t, len(parts)+1, cb.decodeAddress)
+
+		state := test.NewRandomState(rng, test.WithParams(params), test.WithNumAssets(2))
+		sig, err := b.Sign(accs[0], params, state)
+		require.NoError(t, err)
+		tx := channel.Transaction{State: state, Sigs: []wallet.Sig{sig, nil}}
+		buf.Reset()
+		require.NoError(t, tx.Encode(&buf))
+		decTx, err := b.DecodeTransaction(&buf)
+		require.NoError(t, err)
+		assert.NoError(t, decTx.State.Equal(state))
+		assert.Equal(t, tx.Sigs, decTx.Sigs)
+		assert.Equal(t, 2, cb.decodeAsset)
+		assert.Equal(t, 1, cb.decodeSig)
+	})
+
+	t.Run("signing", func(t *testing.T) {
+		*cb = countingBackend{}
+		m, err := channel.NewStateMachine(accs[0], *params)
+		require.NoError(t, err)
+		initBals := test.NewRandomAllocation(rng, test.WithNumParts(2))
+		require.NoError(t, m.Init(*initBals, channel.NewMockOp(channel.OpValid)))
+		_, err = m.Sig()
+		require.NoError(t, err)
+		assert.Equal(t, 1, cb.sign)
+
+		sig, err := channel.Sign(accs[1], params, m.StagingState())
+		require.NoError(t, err)
+		require.NoError(t, m.AddSig(1, sig))
+		assert.Equal(t, 1, cb.verify)
+	})
+}
@@ -65,6 +65,8 @@ const (
 	Registered
 	Withdrawing
 	Withdrawn
+	Progressing
+	Progressed
 )
 
 func (p Phase) String() string {
@@ -79,6 +81,8 @@ func (p Phase) String() string {
 		"Registered",
 		"Withdrawing",
 		"Withdrawn",
+		"Progressing",
+		"Progressed",
 	}[p]
 }
 
@@ -103,7 +107,7 @@ var signingPhases = []Phase{InitSigning, Signing}
 // It only contains implementations for the phase transitions common to
 // both, ActionMachine and StateMachine, that is, AddSig, EnableInit, SetFunded,
 // EnableUpdate, EnableFinal and the external phase changes
-// Set(Funded|Register(ing|ed)|Withdraw(ing|n)).
+// Set(Funded|Register(ing|ed)|Progressed|Withdraw(ing|n)).
 // The other transitions are specific to the type of machine and are implemented
 // individually.
 type machine struct {
@@ -114,6 +118,10 @@ type machine struct {
 	stagingTX Transaction
 	currentTX Transaction
 	prevTXs   []Transaction
+	// phase transitions since creation or restoration
+	phases []PhaseTransition
+	// observers are notified about changes, see AddObserver
+	observers []MachineObserver
 
 	// currently registered event, if any
 	registered *RegisteredEvent
@@ -182,7 +190,13 @@ func (m *machine) Phase() Phase {
 // setPhase is internally used to set the phase.
 func (m *machine) setPhase(p Phase) {
 	m.Log().Tracef("phase transition: %v", PhaseTransition{m.phase, p})
+	if p == m.phase {
+		return
+	}
+	t := PhaseTransition{m.phase, p}
+	m.phases = append(m.phases, t)
 	m.phase = p
+	m.notifyPhaseChange(t)
 }
 
 // inPhase returns whether phase is in phases.
@@ -205,11 +219,12 @@ func (m *machine) Sig() (sig wallet.Sig, err error) {
 	}
 
 	if m.stagingTX.Sigs[m.idx] == nil {
-		sig, err = Sign(m.acc, &m.params, m.stagingTX.State)
+		sig, err = m.params.backends.Sign(m.acc, &m.params, m.stagingTX.State)
 		if err != nil {
 			return
 		}
 		m.stagingTX.Sigs[m.idx] = sig
+		m.notifySigAdded(m.idx)
 	} else {
 		sig = m.stagingTX.Sigs[m.idx]
 	}
@@ -267,13 +282,14 @@ func (m *machine) AddSig(idx Index, sig wallet.Sig) error {
 		return errors.Errorf("signature for idx %d already present (ID: %x)", idx, m.params.id)
 	}
 
-	if ok, err := Verify(m.params.Parts[idx], &m.params, m.stagingTX.State, sig); err != nil {
+	if ok, err := m.params.backends.Verify(m.params.Parts[idx], &m.params, m.stagingTX.State, sig); err != nil {
 		return err
 	} else if !ok {
 		return errors.Errorf("invalid signature for idx %d (ID: %x)", idx, m.params.id)
 	}
 
 	m.stagingTX.Sigs[idx] = sig
+	m.notifySigAdded(idx)
 	return nil
 }
 
@@ -337,11 +353,13 @@ func (m *machine) enableStaged(expected PhaseTransition) error {
 		}
 	}
 
+	oldVersion := m.currentVersion()
 	m.prevTXs = append(m.prevTXs, m.currentTX) // push current to previous
 	m.currentTX = m.stagingTX                  // promote staging to current
 	m.stagingTX = Transaction{}                // clear staging
 
 	m.setPhase(expected.To)
+	m.notifyStateEnabled(expected, oldVersion)
 	return nil
 }
 
@@ -386,12 +404,51 @@ func (m *machine) Registered() *RegisteredEvent {
 	return m.registered
 }
 
+// SetProgressed moves the machine into the Progressed phase. The passed event
+// must carry the state to which the registered state was progressed on-chain.
+// If it is newer than the current state, it becomes the current state. Since
+// a progressed state is only signed by the actor of the app transition, the
+// current transaction then misses the other signatures. If the progressed
+// state is the state that is being progressed by the own participant, its own
+// signature is kept.
+// This phase can only be reached from the Registered, Progressing and
+// Progressed phases.
+func (m *machine) SetProgressed(e *RegisteredEvent) error {
+	if !inPhase(m.phase, []Phase{Registered, Progressing, Progressed}) {
+		return m.phaseErrorf(PhaseTransition{m.phase, Progressed}, "can only progress after registering")
+	}
+	if e.State == nil {
+		return errors.New("event does not carry a progressed state")
+	}
+
+	enabled, oldVersion, from := e.Version > m.currentTX.Version, m.currentVersion(), m.phase
+	if enabled {
+		tx := Transaction{State: e.State.Clone(), Sigs: make([]wallet.Sig, m.N())}
+		if m.phase == Progressing && m.stagingTX.Version == e.Version {
+			tx.Sigs[m.idx] = m.stagingTX.Sigs[m.idx]
+		}
+		m.prevTXs = append(m.prevTXs, m.currentTX)
+		m.currentTX = tx
+		m.stagingTX = Transaction{}
+	}
+	if m.registered == nil || e.Version > m.registered.Version {
+		m.registered = e
+	}
+	m.setPhase(Progressed)
+	if enabled {
+		m.notifyStateEnabled(PhaseTransition{from, Progressed}, oldVersion)
+	}
+	return nil
+}
+
 // SetWithdrawing sets the state machine to the Withdrawing phase. The current
 // state was registered on-chain and funds withdrawal is in progress.
-// This phase can only be reached from the Registered or Withdrawing phase.
+// This phase can only be reached from the Registered, Progressing, Progressed
+// or Withdrawing phase, or from the Final phase since a final state can be
+// withdrawn without registering it first.
 func (m *machine) SetWithdrawing() error {
-	if !inPhase(m.phase, []Phase{Registered, Withdrawing}) {
-		return m.phaseErrorf(m.selfTransition(), "can only withdraw after registering")
+	if !inPhase(m.phase, []Phase{Final, Registered, Progressing, Progressed, Withdrawing}) {
+		return m.phaseErrorf(m.selfTransition(), "can only withdraw after registering or from final state")
 	}
 	m.setPhase(Withdrawing)
 	return nil
@@ -414,23 +471,30 @@ func (m *machine) simplePhaseTransition(from, to Phase) error {
 }
 
 var validPhaseTransitions = map[PhaseTransition]struct{}{
-	{InitActing, InitSigning}: {},
-	{InitSigning, Funding}:    {},
-	{Funding, Acting}:         {},
-	{Acting, Signing}:         {},
-	{Signing, Acting}:         {},
-	{Signing, Final}:          {},
-	{Funding, Registering}:    {},
-	{Acting, Registering}:     {},
-	{Signing, Registering}:    {},
-	{Final, Registering}:      {},
-	{Funding, Registered}:     {},
-	{Acting, Registered}:      {},
-	{Signing, Registered}:     {},
-	{Final, Registered}:       {},
-	{Registering, Registered}: {},
-	{Registered, Withdrawing}: {},
-	{Withdrawing, Withdrawn}:  {},
+	{InitActing, InitSigning}:  {},
+	{InitSigning, Funding}:     {},
+	{Funding, Acting}:          {},
+	{Acting, Signing}:          {},
+	{Signing, Acting}:          {},
+	{Signing, Final}:           {},
+	{Funding, Registering}:     {},
+	{Acting, Registering}:      {},
+	{Signing, Registering}:     {},
+	{Final, Registering}:       {},
+	{Funding, Registered}:      {},
+	{Acting, Registered}:       {},
+	{Signing, Registered}:      {},
+	{Final, Registered}:        {},
+	{Registering, Registered}:  {},
+	{Final, Withdrawing}:       {},
+	{Registered, Withdrawing}:  {},
+	{Registered, Progressing}:  {},
+	{Registered, Progressed}:   {},
+	{Progressing, Progressed}:  {},
+	{Progressed, Progressing}:  {},
+	{Progressing, Withdrawing}: {},
+	{Progressed, Withdrawing}:  {},
+	{Withdrawing, Withdrawn}:   {},
 }
 
 func (m *machine) expect(tr PhaseTransition) error {
@@ -452,28 +516,38 @@ func (m *machine) expect(tr PhaseTransition) error {
 // A StateMachine will additionally check the validity of the app-specific
 // transition whereas an ActionMachine checks each Action as being valid.
 func (m *machine) validTransition(to *State) error {
-	if to.ID != m.params.id {
+	return validFrameworkTransition(&m.params, m.currentTX.State, to, true)
+}
+
+// validFrameworkTransition checks the app-independent rules of the transition
+// from state from to state to of the channel with the passed params, see
+// machine.validTransition. If consecutive is false, the version only needs to
+// increase instead of increasing by 1.
+func validFrameworkTransition(params *Params, from, to *State, consecutive bool) error {
+	if to.ID != params.id {
 		return errors.New("new state's ID doesn't match")
 	}
-	if !m.params.App.Def().Equals(to.App.Def()) {
+	if !params.App.Def().Equals(to.App.Def()) {
 		return errors.New("new state's App dosen't match")
 	}
 
-	newError := func(s string) error { return NewStateTransitionError(m.params.id, s) }
+	newError := func(s string) error { return NewStateTransitionError(params.id, s) }
 
-	if m.currentTX.IsFinal {
+	if from.IsFinal {
 		return newError("cannot advance final state")
 	}
 
-	if m.currentTX.Version+1 != to.Version {
+	if consecutive && from.Version+1 != to.Version {
 		return newError("version must increase by one")
+	} else if to.Version <= from.Version {
+		return newError("version must increase")
 	}
 
 	if err := to.Allocation.Valid(); err != nil {
 		return newError(fmt.Sprintf("invalid allocation: %v", err))
 	}
 
-	if eq, err := equalSum(m.currentTX.Allocation, to.Allocation); err != nil {
+	if eq, err := equalSum(from.Allocation, to.Allocation); err != nil {
 		return err
 	} else if !eq {
 		return newError("allocations must be preserved")
@@ -509,6 +583,7 @@ func (m *machine) Clone() *machine {
 		stagingTX: m.stagingTX.Clone(),
 		currentTX: m.currentTX.Clone(),
 		prevTXs:   prevTXs,
+		phases:    append([]PhaseTransition(nil), m.phases...),
 		Embedding: m.Embedding,
 	}
 }
@@ -0,0 +1,213 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"perun.network/go-perun/channel"
+)
+
+// Adjudicator is a channel.Adjudicator that registers, progresses and
+// withdraws channels with assets on multiple ledgers. Calls are routed to the
+// adjudicators of all ledgers on which the channel's assets are held. All
+// assets of a channel must implement Asset.
+//
+// Unlike the Funder, the Adjudicator passes the unfiltered request, including
+// the assets of other ledgers, to each ledger adjudicator. The signatures of
+// the transaction are over the full state, so a state with filtered assets
+// could not be verified on-chain. Ledger adjudicators must therefore accept
+// states with foreign assets and only pay out the assets held on their own
+// ledger, like the simulated Ledger does.
+type Adjudicator struct {
+	mu   sync.RWMutex
+	adjs map[LedgerID]channel.Adjudicator
+}
+
+var _ channel.AssetSubscriber = (*Adjudicator)(nil)
+
+// NewAdjudicator creates a new multi-ledger adjudicator without any ledger
+// adjudicators.
+func NewAdjudicator() *Adjudicator {
+	return &Adjudicator{adjs: make(map[LedgerID]channel.Adjudicator)}
+}
+
+// RegisterAdjudicator registers the adjudicator for the ledger with the given
+// ID. A previously registered adjudicator for the ledger is replaced.
+func (a *Adjudicator) RegisterAdjudicator(l LedgerID, la channel.Adjudicator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.adjs[l] = la
+}
+
+// Register registers the full channel state on all ledgers of the channel's
+// assets, concurrently. The timeout of the returned event elapses when the
+// timeouts on all ledgers have elapsed.
+func (a *Adjudicator) Register(ctx context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	adjs, err := a.ledgerAdjudicators(req.Tx.State.Assets)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		eg     errgroup.Group
+		events = make([]*channel.RegisteredEvent, len(adjs))
+	)
+	for i, la := range adjs {
+		i, la := i, la
+		eg.Go(func() (err error) {
+			events[i], err = la.Register(ctx, req)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	timeout := make(timeout, len(events))
+	for i, ev := range events {
+		if ev.Version != events[0].Version {
+			return nil, errors.Errorf("ledgers registered different versions %d and %d",
+				events[0].Version, ev.Version)
+		}
+		timeout[i] = ev.Timeout
+	}
+	return &channel.RegisteredEvent{
+		ID:      events[0].ID,
+		Version: events[0].Version,
+		Timeout: timeout,
+	}, nil
+}
+
+// Withdraw withdraws the channel on all ledgers of the channel's assets,
+// concurrently. Each ledger adjudicator only pays out its own assets.
+func (a *Adjudicator) Withdraw(ctx context.Context, req channel.AdjudicatorReq) error {
+	adjs, err := a.ledgerAdjudicators(req.Tx.State.Assets)
+	if err != nil {
+		return err
+	}
+
+	var eg errgroup.Group
+	for _, la := range adjs {
+		la := la
+		eg.Go(func() error { return la.Withdraw(ctx, req) })
+	}
+	return eg.Wait()
+}
+
+// Progress progresses the registered channel state on all ledgers of the
+// channel's assets, concurrently.
+func (a *Adjudicator) Progress(ctx context.Context, req channel.ProgressReq) error {
+	adjs, err := a.ledgerAdjudicators(req.Tx.State.Assets)
+	if err != nil {
+		return err
+	}
+
+	var eg errgroup.Group
+	for _, la := range adjs {
+		la := la
+		eg.Go(func() error { return la.Progress(ctx, req) })
+	}
+	return eg.Wait()
+}
+
+// SubscribeRegistered returns an error because the ledgers of the channel
+// are not known from its parameters. Use SubscribeRegisteredAssets or
+// channel.SubscribeRegistered instead.
+func (a *Adjudicator) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
+	return nil, errors.New("multi-ledger subscription needs the channel's assets")
+}
+
+// SubscribeRegisteredAssets subscribes to RegisteredEvents on the ledgers of
+// the channel's assets. The returned subscription merges the events of all
+// ledgers by version: the first event of a newer version is returned and the
+// timeouts of the events of the same version on the other ledgers are added to
+// its timeout. Events of older versions are dropped. The subscription ends as
+// soon as any of the ledger subscriptions ends.
+func (a *Adjudicator) SubscribeRegisteredAssets(ctx context.Context, params *channel.Params, assets []channel.Asset) (channel.RegisteredSubscription, error) {
+	adjs, err := a.ledgerAdjudicators(assets)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]channel.RegisteredSubscription, 0, len(adjs))
+	for _, la := range adjs {
+		sub, err := la.SubscribeRegistered(ctx, params)
+		if err != nil {
+			for _, s := range subs {
+				s.Close() // nolint: errcheck
+			}
+			return nil, errors.WithMessage(err, "subscribing on ledger")
+		}
+		subs = append(subs, sub)
+	}
+	return newSubscription(subs), nil
+}
+
+// ledgerAdjudicators returns the adjudicators of the ledgers on which the
+// assets are held. It returns an error if there is no adjudicator registered
+// for any of the ledgers.
+func (a *Adjudicator) ledgerAdjudicators(assets []channel.Asset) ([]channel.Adjudicator, error) {
+	ledgers, err := assetsByLedger(assets)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	adjs := make([]channel.Adjudicator, 0, len(ledgers))
+	for l := range ledgers {
+		la, ok := a.adjs[l]
+		if !ok {
+			return nil, errors.Errorf("no adjudicator registered for ledger %q", l)
+		}
+		adjs = append(adjs, la)
+	}
+	return adjs, nil
+}
+
+// timeout is a channel.Timeout that elapses when all of its timeouts have
+// elapsed.
+type timeout []channel.Timeout
+
+// IsElapsed returns whether all timeouts have elapsed.
+func (t timeout) IsElapsed(ctx context.Context) bool {
+	for _, to := range t {
+		if !to.IsElapsed(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// Wait waits for all timeouts to elapse.
+func (t timeout) Wait(ctx context.Context) error {
+	for _, to := range t {
+		if err := to.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the timeouts of all ledgers.
+func (t timeout) String() string {
+	return fmt.Sprintf("<Multi-ledger timeout: %v>", []channel.Timeout(t))
+}
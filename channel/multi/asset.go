@@ -0,0 +1,71 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+)
+
+type (
+	// LedgerID identifies a ledger, e.g., a blockchain, on which assets are
+	// held.
+	LedgerID string
+
+	// Asset is a channel.Asset that is held on a specific ledger.
+	Asset interface {
+		channel.Asset
+		// LedgerID returns the identifier of the ledger on which the asset is
+		// held.
+		LedgerID() LedgerID
+	}
+)
+
+// assetsByLedger groups the indices of the assets by their ledgers. It returns
+// an error if an asset is not a multi-ledger Asset.
+func assetsByLedger(assets []channel.Asset) (map[LedgerID][]int, error) {
+	ledgers := make(map[LedgerID][]int)
+	for i, a := range assets {
+		ma, ok := a.(Asset)
+		if !ok {
+			return nil, errors.Errorf("asset %d is not a multi-ledger asset: %T", i, a)
+		}
+		ledgers[ma.LedgerID()] = append(ledgers[ma.LedgerID()], i)
+	}
+	return ledgers, nil
+}
+
+// filterAllocation returns a copy of alloc that only contains the assets at the
+// given indices.
+func filterAllocation(alloc channel.Allocation, indices []int) channel.Allocation {
+	filtered := channel.Allocation{
+		Assets:   make([]channel.Asset, len(indices)),
+		Balances: make([][]channel.Bal, len(indices)),
+		Locked:   make([]channel.SubAlloc, len(alloc.Locked)),
+	}
+	for j, i := range indices {
+		filtered.Assets[j] = alloc.Assets[i]
+		filtered.Balances[j] = alloc.Balances[i]
+	}
+	for k, sub := range alloc.Locked {
+		bals := make([]channel.Bal, len(indices))
+		for j, i := range indices {
+			bals[j] = sub.Bals[i]
+		}
+		filtered.Locked[k] = channel.SubAlloc{ID: sub.ID, Bals: bals}
+	}
+	return filtered.Clone()
+}
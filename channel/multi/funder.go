@@ -0,0 +1,112 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"perun.network/go-perun/channel"
+)
+
+// Funder is a channel.Funder that funds channels with assets on multiple
+// ledgers. For each ledger, it calls the registered ledger funder with a
+// funding request that only contains the assets held on that ledger. All
+// assets of a funded channel must implement Asset.
+type Funder struct {
+	mu      sync.RWMutex
+	funders map[LedgerID]channel.Funder
+}
+
+var _ channel.Funder = (*Funder)(nil)
+
+// NewFunder creates a new multi-ledger funder without any ledger funders.
+func NewFunder() *Funder {
+	return &Funder{funders: make(map[LedgerID]channel.Funder)}
+}
+
+// RegisterFunder registers the funder for the ledger with the given ID. A
+// previously registered funder for the ledger is replaced.
+func (f *Funder) RegisterFunder(l LedgerID, lf channel.Funder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.funders[l] = lf
+}
+
+// Fund funds the channel on all ledgers on which its assets are held,
+// concurrently. The assets of the returned FundingTimeoutError, if any,
+// refer to the indices of the assets in the full channel allocation.
+func (f *Funder) Fund(ctx context.Context, req channel.FundingReq) error {
+	ledgers, err := assetsByLedger(req.State.Assets)
+	if err != nil {
+		return err
+	}
+	funders, err := f.ledgerFunders(ledgers)
+	if err != nil {
+		return err
+	}
+
+	var (
+		eg          errgroup.Group
+		mu          sync.Mutex
+		timeoutErrs []*channel.AssetFundingError
+	)
+	for l, indices := range ledgers {
+		lf, indices := funders[l], indices
+		lreq := req
+		lreq.State = req.State.Clone()
+		lreq.State.Allocation = filterAllocation(req.State.Allocation, indices)
+
+		eg.Go(func() error {
+			err := lf.Fund(ctx, lreq)
+			if !channel.IsFundingTimeoutError(err) {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// Map the asset indices back to the full allocation.
+			for _, aerr := range errors.Cause(err).(*channel.FundingTimeoutError).Errors {
+				timeoutErrs = append(timeoutErrs, &channel.AssetFundingError{
+					Asset:         indices[aerr.Asset],
+					TimedOutPeers: aerr.TimedOutPeers,
+				})
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return channel.NewFundingTimeoutError(timeoutErrs)
+}
+
+// ledgerFunders returns the funders for the given ledgers. It returns an error
+// if there is no funder registered for any of the ledgers.
+func (f *Funder) ledgerFunders(ledgers map[LedgerID][]int) (map[LedgerID]channel.Funder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	funders := make(map[LedgerID]channel.Funder, len(ledgers))
+	for l := range ledgers {
+		lf, ok := f.funders[l]
+		if !ok {
+			return nil, errors.Errorf("no funder registered for ledger %q", l)
+		}
+		funders[l] = lf
+	}
+	return funders, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	simledger "perun.network/go-perun/backend/sim/ledger"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/multi"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+const timeout = 200 * time.Millisecond
+
+func TestFunder(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	la, lb := simledger.NewLedger("A"), simledger.NewLedger("B")
+	f := multi.NewFunder()
+	f.RegisterFunder(la.ID(), la)
+	f.RegisterFunder(lb.ID(), lb)
+	assets := []channel.Asset{la.NewAsset(1), lb.NewAsset(2), la.NewAsset(3)}
+
+	t.Run("unknown ledger", func(t *testing.T) {
+		params, state := test.NewRandomParamsAndState(rng, test.WithNumParts(2),
+			test.WithAssets(la.NewAsset(1), simledger.NewLedger("C").NewAsset(1)))
+		err := f.Fund(context.Background(), channel.FundingReq{Params: params, State: state})
+		assert.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		params, state := test.NewRandomParamsAndState(rng, test.WithNumParts(2), test.WithAssets(assets...))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err := f.Fund(ctx, channel.FundingReq{Params: params, State: state, Idx: 0})
+		require.True(t, channel.IsFundingTimeoutError(err))
+		ferr := errors.Cause(err).(*channel.FundingTimeoutError)
+		var fundingAssets []int
+		for _, aerr := range ferr.Errors {
+			fundingAssets = append(fundingAssets, aerr.Asset)
+			assert.Equal(t, []channel.Index{1}, aerr.TimedOutPeers)
+		}
+		assert.ElementsMatch(t, []int{0, 1, 2}, fundingAssets,
+			"asset indices should refer to the full allocation")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		params, state := test.NewRandomParamsAndState(rng, test.WithNumParts(2), test.WithAssets(assets...))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		errs := make(chan error, 2)
+		for i := range params.Parts {
+			req := channel.FundingReq{Params: params, State: state, Idx: channel.Index(i)}
+			go func() { errs <- f.Fund(ctx, req) }()
+		}
+		assert.NoError(t, <-errs)
+		assert.NoError(t, <-errs)
+	})
+}
+
+func TestAdjudicator(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	la, lb := simledger.NewLedger("A"), simledger.NewLedger("B")
+	f, adj := multi.NewFunder(), multi.NewAdjudicator()
+	for _, l := range []*simledger.Ledger{la, lb} {
+		f.RegisterFunder(l.ID(), l)
+		adj.RegisterAdjudicator(l.ID(), l)
+	}
+	adj.RegisterAdjudicator("C", unusedLedger{})
+
+	accs := []wallet.Account{wallettest.NewRandomAccount(rng), wallettest.NewRandomAccount(rng)}
+	assets := []*simledger.Asset{la.NewAsset(1), lb.NewAsset(2)}
+	params, state := test.NewRandomParamsAndState(rng,
+		test.WithParts(accs[0].Address(), accs[1].Address()),
+		test.WithAssets(assets[0], assets[1]),
+		test.WithBalances([]channel.Bal{big.NewInt(1), big.NewInt(2)}, []channel.Bal{big.NewInt(3), big.NewInt(4)}),
+		test.WithNumLocked(0), test.WithIsFinal(false), test.WithChallengeDuration(60))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for i := range accs {
+		req := channel.FundingReq{Params: params, State: state, Idx: channel.Index(i)}
+		go func() { assert.NoError(t, f.Fund(ctx, req)) }()
+	}
+
+	_, err := adj.SubscribeRegistered(ctx, params)
+	assert.Error(t, err, "subscription without assets should fail")
+	sub, err := channel.SubscribeRegistered(ctx, adj, params, state.Assets)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	tx := signedTx(t, accs, params, state)
+	reg, err := adj.Register(ctx, channel.AdjudicatorReq{Params: params, Tx: tx})
+	require.NoError(t, err)
+	assert.Equal(t, state.Version, reg.Version)
+	assert.False(t, reg.Timeout.IsElapsed(ctx), "timeout should wait for challenge duration")
+	ev := sub.Next()
+	require.NotNil(t, ev)
+	assert.Equal(t, state.Version, ev.Version)
+	assert.False(t, ev.Timeout.IsElapsed(ctx), "timeout should wait for challenge duration")
+	next := make(chan *channel.RegisteredEvent, 1)
+	go func() { next <- sub.Next() }()
+	select {
+	case ev := <-next:
+		t.Errorf("events of the ledgers should be merged, got second event %v", ev)
+	case <-time.After(timeout / 4):
+	}
+	assert.Error(t, adj.Withdraw(ctx, channel.AdjudicatorReq{Params: params, Tx: tx}),
+		"withdrawal before timeout should fail")
+
+	final := state.Clone()
+	final.Version++
+	final.IsFinal = true
+	tx = signedTx(t, accs, params, final)
+	for i, acc := range accs {
+		req := channel.AdjudicatorReq{Params: params, Acc: acc, Tx: tx, Idx: channel.Index(i)}
+		require.NoError(t, adj.Withdraw(ctx, req))
+	}
+	for a, asset := range assets {
+		l := []*simledger.Ledger{la, lb}[a]
+		for i, acc := range accs {
+			assert.Zero(t, final.Balances[a][i].Cmp(l.Balance(acc.Address(), asset)))
+		}
+	}
+
+	require.NoError(t, sub.Close())
+	assert.Nil(t, <-next, "closed subscription should return nil")
+}
+
+// unusedLedger is the adjudicator of a ledger that holds none of the channel's
+// assets. Subscriptions on it fail.
+type unusedLedger struct{ channel.Adjudicator }
+
+func (unusedLedger) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
+	return nil, errors.New("subscribed on unused ledger")
+}
+
+func signedTx(t *testing.T, accs []wallet.Account, params *channel.Params, state *channel.State) channel.Transaction {
+	tx := channel.Transaction{State: state, Sigs: make([]wallet.Sig, len(accs))}
+	for i, acc := range accs {
+		sig, err := channel.Sign(acc, params, state)
+		require.NoError(t, err)
+		tx.Sigs[i] = sig
+	}
+	return tx
+}
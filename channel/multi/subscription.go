@@ -0,0 +1,184 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"context"
+	"sync"
+
+	"perun.network/go-perun/channel"
+)
+
+// subscription merges the RegisteredEvents of multiple subscriptions by
+// version.
+type subscription struct {
+	subs []channel.RegisteredSubscription
+	next chan *channel.RegisteredEvent // holds the newest unread event
+	errs chan error
+	done chan struct{}
+
+	mu      sync.Mutex
+	last    *channel.RegisteredEvent // newest merged event
+	timeout *mergedTimeout           // timeout of last
+	err     error
+	closed  bool
+}
+
+var _ channel.RegisteredSubscription = (*subscription)(nil)
+
+func newSubscription(subs []channel.RegisteredSubscription) *subscription {
+	s := &subscription{
+		subs: subs,
+		next: make(chan *channel.RegisteredEvent, 1),
+		errs: make(chan error, len(subs)),
+		done: make(chan struct{}),
+	}
+	for _, sub := range subs {
+		go s.forward(sub)
+	}
+	return s
+}
+
+// forward merges the events of sub until it ends or the subscription is
+// closed.
+func (s *subscription) forward(sub channel.RegisteredSubscription) {
+	for {
+		ev := sub.Next()
+		if ev == nil {
+			s.errs <- sub.Err()
+			return
+		}
+		s.merge(ev)
+	}
+}
+
+// merge merges the event of a ledger subscription. An event of a newer version
+// replaces an unread event. The timeout of an event of the same version is
+// added to the timeout of the merged event. Older events are dropped.
+func (s *subscription) merge(ev *channel.RegisteredEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.last != nil && ev.Version < s.last.Version:
+		return
+	case s.last != nil && ev.Version == s.last.Version:
+		s.timeout.add(ev.Timeout)
+		return
+	}
+
+	s.timeout = &mergedTimeout{timeouts: timeout{ev.Timeout}}
+	s.last = &channel.RegisteredEvent{
+		ID:      ev.ID,
+		Version: ev.Version,
+		Timeout: s.timeout,
+		State:   ev.State,
+	}
+	select {
+	case <-s.next:
+	default:
+	}
+	s.next <- s.last
+}
+
+// Next returns the newest merged event. If any of the ledger subscriptions
+// ends, the subscription is closed and nil is returned.
+func (s *subscription) Next() *channel.RegisteredEvent {
+	select {
+	case ev := <-s.next:
+		return ev
+	case err := <-s.errs:
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.mu.Unlock()
+		s.Close() // nolint: errcheck, gosec
+		return nil
+	case <-s.done:
+		return nil
+	}
+}
+
+// Err returns the error of the first ledger subscription that ended.
+func (s *subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes all ledger subscriptions.
+func (s *subscription) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	var err error
+	for _, sub := range s.subs {
+		if cerr := sub.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// mergedTimeout is the timeout of a merged event. It elapses when the timeouts
+// of all ledger events of the same version, which were merged so far, have
+// elapsed.
+type mergedTimeout struct {
+	mu       sync.Mutex
+	timeouts timeout
+}
+
+func (t *mergedTimeout) add(to channel.Timeout) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timeouts = append(t.timeouts, to)
+}
+
+func (t *mergedTimeout) get() timeout {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append(timeout(nil), t.timeouts...)
+}
+
+// IsElapsed returns whether all merged timeouts have elapsed.
+func (t *mergedTimeout) IsElapsed(ctx context.Context) bool {
+	return t.get().IsElapsed(ctx)
+}
+
+// Wait waits for all merged timeouts to elapse, including the timeouts that
+// are merged while waiting.
+func (t *mergedTimeout) Wait(ctx context.Context) error {
+	for waited := 0; ; {
+		timeouts := t.get()
+		if waited == len(timeouts) {
+			return nil
+		}
+		if err := timeouts[waited:].Wait(ctx); err != nil {
+			return err
+		}
+		waited = len(timeouts)
+	}
+}
+
+// String returns the merged timeouts.
+func (t *mergedTimeout) String() string {
+	return t.get().String()
+}
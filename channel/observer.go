@@ -0,0 +1,129 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+type (
+	// A MachineObserver is notified about changes of a StateMachine or
+	// ActionMachine. Its methods are called synchronously after the respective
+	// change, so they should return quickly. They must not call back into the
+	// machine, which is still locked by its user at that time.
+	MachineObserver interface {
+		// OnPhaseChange is called after every phase transition.
+		OnPhaseChange(PhaseChangeEvent)
+		// OnSigAdded is called after a signature was added to the staging
+		// transaction.
+		OnSigAdded(SigAddedEvent)
+		// OnStateEnabled is called after a new current state was enabled.
+		OnStateEnabled(StateEnabledEvent)
+	}
+
+	// MachineObserverFuncs is a MachineObserver that calls the respective
+	// functions. Nil functions are skipped.
+	MachineObserverFuncs struct {
+		PhaseChange  func(PhaseChangeEvent)
+		SigAdded     func(SigAddedEvent)
+		StateEnabled func(StateEnabledEvent)
+	}
+
+	// PhaseChangeEvent is emitted after a machine changed its phase.
+	PhaseChangeEvent struct {
+		ID         ID              // ID is the channel ID.
+		Transition PhaseTransition // Transition holds the old and new phase.
+		Version    uint64          // Version of the current state, 0 if there is none.
+	}
+
+	// SigAddedEvent is emitted after a signature was added to the staging
+	// transaction of a machine. This includes the machine's own signature.
+	SigAddedEvent struct {
+		ID      ID     // ID is the channel ID.
+		Phase   Phase  // Phase is the signing phase of the machine.
+		Version uint64 // Version of the staging state.
+		Idx     Index  // Idx is the index of the signer.
+	}
+
+	// StateEnabledEvent is emitted after a machine enabled a new current state,
+	// either by promoting a fully signed staging state or by adopting a state
+	// that was progressed on-chain.
+	StateEnabledEvent struct {
+		ID         ID              // ID is the channel ID.
+		Transition PhaseTransition // Transition holds the old and new phase.
+		OldVersion uint64          // OldVersion is 0 if there was no current state.
+		NewVersion uint64          // NewVersion is the version of the new current state.
+	}
+)
+
+var _ MachineObserver = MachineObserverFuncs{}
+
+// OnPhaseChange calls PhaseChange, if set.
+func (f MachineObserverFuncs) OnPhaseChange(e PhaseChangeEvent) {
+	if f.PhaseChange != nil {
+		f.PhaseChange(e)
+	}
+}
+
+// OnSigAdded calls SigAdded, if set.
+func (f MachineObserverFuncs) OnSigAdded(e SigAddedEvent) {
+	if f.SigAdded != nil {
+		f.SigAdded(e)
+	}
+}
+
+// OnStateEnabled calls StateEnabled, if set.
+func (f MachineObserverFuncs) OnStateEnabled(e StateEnabledEvent) {
+	if f.StateEnabled != nil {
+		f.StateEnabled(e)
+	}
+}
+
+// AddObserver adds an observer that is notified about all future changes of
+// the machine. Observers are not copied to clones of the machine.
+func (m *machine) AddObserver(o MachineObserver) {
+	m.observers = append(m.observers, o)
+}
+
+// currentVersion returns the version of the current state or 0 if there is
+// none.
+func (m *machine) currentVersion() uint64 {
+	if m.currentTX.State == nil {
+		return 0
+	}
+	return m.currentTX.Version
+}
+
+func (m *machine) notifyPhaseChange(t PhaseTransition) {
+	e := PhaseChangeEvent{ID: m.params.id, Transition: t, Version: m.currentVersion()}
+	for _, o := range m.observers {
+		o.OnPhaseChange(e)
+	}
+}
+
+func (m *machine) notifySigAdded(idx Index) {
+	e := SigAddedEvent{ID: m.params.id, Phase: m.phase, Version: m.stagingTX.Version, Idx: idx}
+	for _, o := range m.observers {
+		o.OnSigAdded(e)
+	}
+}
+
+func (m *machine) notifyStateEnabled(t PhaseTransition, oldVersion uint64) {
+	e := StateEnabledEvent{
+		ID:         m.params.id,
+		Transition: t,
+		OldVersion: oldVersion,
+		NewVersion: m.currentTX.Version,
+	}
+	for _, o := range m.observers {
+		o.OnStateEnabled(e)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestMachineObserver(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	accs, parts := wallettest.NewRandomAccounts(rng, 2)
+	params := test.NewRandomParams(rng, test.WithParts(parts...))
+	m, err := channel.NewStateMachine(accs[0], *params)
+	require.NoError(t, err)
+
+	var (
+		phases  []channel.PhaseChangeEvent
+		sigs    []channel.SigAddedEvent
+		enabled []channel.StateEnabledEvent
+	)
+	m.AddObserver(channel.MachineObserverFuncs{
+		PhaseChange: func(e channel.PhaseChangeEvent) { phases = append(phases, e) },
+		SigAdded:    func(e channel.SigAddedEvent) { sigs = append(sigs, e) },
+	})
+	m.AddObserver(channel.MachineObserverFuncs{
+		StateEnabled: func(e channel.StateEnabledEvent) { enabled = append(enabled, e) },
+	})
+
+	initBals := test.NewRandomAllocation(rng, test.WithNumParts(2))
+	require.NoError(t, m.Init(*initBals, channel.NewMockOp(channel.OpValid)))
+	_, err = m.Sig()
+	require.NoError(t, err)
+	_, err = m.Sig() // own signature is only added once
+	require.NoError(t, err)
+	sig, err := channel.Sign(accs[1], params, m.StagingState())
+	require.NoError(t, err)
+	require.NoError(t, m.AddSig(1, sig))
+	require.NoError(t, m.EnableInit())
+	require.NoError(t, m.SetFunded())
+
+	id := params.ID()
+	assert.Equal(t, []channel.PhaseChangeEvent{
+		{ID: id, Transition: channel.PhaseTransition{From: channel.InitActing, To: channel.InitSigning}},
+		{ID: id, Transition: channel.PhaseTransition{From: channel.InitSigning, To: channel.Funding}},
+		{ID: id, Transition: channel.PhaseTransition{From: channel.Funding, To: channel.Acting}},
+	}, phases)
+	assert.Equal(t, []channel.SigAddedEvent{
+		{ID: id, Phase: channel.InitSigning, Idx: 0},
+		{ID: id, Phase: channel.InitSigning, Idx: 1},
+	}, sigs)
+	assert.Equal(t, []channel.StateEnabledEvent{
+		{ID: id, Transition: channel.PhaseTransition{From: channel.InitSigning, To: channel.Funding}},
+	}, enabled)
+
+	// Update to version 1.
+	phases, sigs, enabled = nil, nil, nil
+	s := m.State().Clone()
+	s.Version++
+	require.NoError(t, m.Update(s, 0))
+	_, err = m.Sig()
+	require.NoError(t, err)
+	sig, err = channel.Sign(accs[1], params, s)
+	require.NoError(t, err)
+	require.NoError(t, m.AddSig(1, sig))
+	require.NoError(t, m.EnableUpdate())
+
+	require.Len(t, phases, 2)
+	assert.Equal(t, channel.PhaseTransition{From: channel.Signing, To: channel.Acting}, phases[1].Transition)
+	assert.Equal(t, uint64(1), phases[1].Version)
+	require.Len(t, sigs, 2)
+	assert.Equal(t, uint64(1), sigs[1].Version)
+	assert.Equal(t, []channel.StateEnabledEvent{{
+		ID:         id,
+		Transition: channel.PhaseTransition{From: channel.Signing, To: channel.Acting},
+		OldVersion: 0,
+		NewVersion: 1,
+	}}, enabled)
+}
@@ -52,6 +52,8 @@ type Params struct {
 	App App `cloneable:"shallow"`
 	// Nonce is a randomness to make the channel id unique
 	Nonce *big.Int
+	// backends are the backends used for the channel, see Backends.
+	backends Backends
 }
 
 // ID returns the channelID of this channel.
@@ -61,12 +63,9 @@ func (p *Params) ID() ID {
 
 // NewParams creates Params from the given data and performs sanity checks. The
 // channel id is also calculated here and persisted because it probably is an
-// expensive hash operation.
+// expensive hash operation. The global backends are used.
 func NewParams(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) (*Params, error) {
-	if err := ValidateParameters(challengeDuration, len(parts), appDef, nonce); err != nil {
-		return nil, errors.WithMessage(err, "invalid parameter for NewParams")
-	}
-	return NewParamsUnsafe(challengeDuration, parts, appDef, nonce), nil
+	return Backends{}.NewParams(challengeDuration, parts, appDef, nonce)
 }
 
 // ValidateParameters checks that the arguments form valid Params:
@@ -75,45 +74,20 @@ func NewParams(challengeDuration uint64, parts []wallet.Address, appDef wallet.A
 // * at least two and at most MaxNumParts parts
 // * appDef belongs to either a StateApp or ActionApp.
 func ValidateParameters(challengeDuration uint64, numParts int, appDef wallet.Address, nonce *big.Int) error {
-	if challengeDuration == 0 {
-		return errors.New("challengeDuration must be != 0")
-	}
-	if nonce == nil {
-		return errors.New("nonce must not be nil")
-	}
-	if numParts < 2 {
-		return errors.New("need at least two participants")
-	}
-	if numParts > MaxNumParts {
-		return errors.Errorf("too many participants, got: %d max: %d", numParts, MaxNumParts)
-	}
-	app, err := AppFromDefinition(appDef)
-	if err != nil {
-		return errors.WithMessage(err, "app from definition")
-	}
-	if !IsStateApp(app) && !IsActionApp(app) {
-		return errors.New("app must be either an Action- or StateApp")
-	}
-	return nil
+	return Backends{}.ValidateParameters(challengeDuration, numParts, appDef, nonce)
 }
 
 // NewParamsUnsafe creates Params from the given data and does NOT perform sanity checks.
 // The channel id is also calculated here and persisted because it probably is an
-// expensive hash operation.
+// expensive hash operation. The global backends are used.
 func NewParamsUnsafe(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) *Params {
-	app, err := AppFromDefinition(appDef)
-	if err != nil {
-		log.Panic("AppFromDefinition on validated parameters returned error")
-	}
-	p := &Params{
-		ChallengeDuration: challengeDuration,
-		Parts:             parts,
-		App:               app,
-		Nonce:             nonce,
-	}
-	// probably an expensive hash operation, do it only once during creation.
-	p.id = CalcID(p)
-	return p
+	return Backends{}.NewParamsUnsafe(challengeDuration, parts, appDef, nonce)
+}
+
+// Backends returns the backends that these Params were created or decoded
+// with. They are used for signing and verifying states of the channel.
+func (p *Params) Backends() Backends {
+	return p.backends
 }
 
 // Clone returns a deep copy of Params.
@@ -125,7 +99,7 @@ func (p *Params) Clone() *Params {
 			log.WithError(err).Panic("Could not encode part")
 		}
 
-		addr, err := wallet.DecodeAddress(&buff)
+		addr, err := p.backends.DecodeAddress(&buff)
 		if err != nil {
 			log.WithError(err).Panic("Could not clone params' addresses")
 		}
@@ -137,7 +111,9 @@ func (p *Params) Clone() *Params {
 		ChallengeDuration: p.ChallengeDuration,
 		Parts:             clonedParts,
 		App:               p.App,
-		Nonce:             new(big.Int).Set(p.Nonce)}
+		Nonce:             new(big.Int).Set(p.Nonce),
+		backends:          p.backends,
+	}
 }
 
 // Encode uses the pkg/io module to serialize a params instance.
@@ -150,19 +126,33 @@ func (p *Params) Encode(w stdio.Writer) error {
 		p.Nonce)
 }
 
-// Decode uses the pkg/io module to deserialize a params instance.
+// Decode uses the pkg/io module to deserialize a params instance. The global
+// backends are used, see Backends.DecodeParams.
 func (p *Params) Decode(r stdio.Reader) error {
-	var appDef wallet.Address
-	err := io.Decode(r,
-		&p.id,
-		&p.ChallengeDuration,
-		(*wallet.AddressesWithLen)(&p.Parts),
-		wallet.AddressDec{Addr: &appDef},
-		&p.Nonce)
-	if err != nil {
+	return p.decode(r, Backends{})
+}
+
+func (p *Params) decode(r stdio.Reader, b Backends) error {
+	var numParts uint16
+	if err := io.Decode(r, &p.id, &p.ChallengeDuration, &numParts); err != nil {
 		return errors.WithMessage(err, "decode fields")
 	}
+	p.Parts = make([]wallet.Address, numParts)
+	for i := range p.Parts {
+		var err error
+		if p.Parts[i], err = b.DecodeAddress(r); err != nil {
+			return errors.WithMessagef(err, "decode part %d", i)
+		}
+	}
+	appDef, err := b.DecodeAddress(r)
+	if err != nil {
+		return errors.WithMessage(err, "decode app definition")
+	}
+	if err := io.Decode(r, &p.Nonce); err != nil {
+		return errors.WithMessage(err, "decode nonce")
+	}
 
-	p.App, err = AppFromDefinition(appDef)
+	p.backends = b
+	p.App, err = b.AppFromDefinition(appDef)
 	return errors.WithMessage(err, "resolve app")
 }
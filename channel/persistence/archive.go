@@ -0,0 +1,245 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+// ArchiveVersion is the version of the archive format written by
+// ExportArchive.
+const ArchiveVersion uint8 = 1
+
+// archiveMagic starts every archive.
+var archiveMagic = [8]byte{'p', 'e', 'r', 'u', 'n', 'a', 'r', 'c'}
+
+// Archive record markers.
+const (
+	archiveEnd     uint8 = 0
+	archiveChannel uint8 = 1
+)
+
+// ExportArchive writes all channels that r restores into an archive. An
+// archive consists of
+//  - the magic bytes "perunarc" and the ArchiveVersion,
+//  - a channel record per channel, marked with a 1 byte, containing the own
+//    index, the parameters, the staging and current transaction, the phase
+//    and the peers of the channel,
+//  - a 0 byte, the number of channels as uint32 and the SHA-256 checksum of
+//    all previous bytes.
+// If r is a Snapshotter, the channels are exported from a snapshot, so that
+// the archive is consistent across channels while they are still used.
+// Otherwise, or if r does not support snapshots of its data source, the
+// channels are read one by one, so each channel is exported as the Restorer
+// restores it, but the archive is not a snapshot across channels. It returns
+// the number of exported channels.
+func ExportArchive(ctx context.Context, w io.Writer, r Restorer) (int, error) {
+	if s, ok := r.(Snapshotter); ok {
+		snap, err := s.Snapshot()
+		if err == nil {
+			defer snap.Close() // nolint: errcheck
+			r = snap
+		} else if !errors.Is(err, ErrSnapshotUnsupported) {
+			return 0, errors.WithMessage(err, "taking snapshot")
+		}
+	}
+
+	peers, err := channelPeers(ctx, r)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]channel.ID, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+
+	hw := &hashWriter{w: w, h: sha256.New()}
+	if err := perunio.Encode(hw, archiveMagic[:], ArchiveVersion); err != nil {
+		return 0, errors.WithMessage(err, "encoding header")
+	}
+	for i, id := range ids {
+		ch, err := r.RestoreChannel(ctx, id)
+		if err != nil {
+			return i, errors.WithMessagef(err, "restoring channel %x", id)
+		}
+		if err := perunio.Encode(hw, archiveChannel, ch.IdxV, ch.ParamsV, ch.StagingTXV,
+			ch.CurrentTXV, ch.PhaseV, wire.AddressesWithLen(peers[id])); err != nil {
+			return i, errors.WithMessagef(err, "encoding channel %x", id)
+		}
+	}
+	if err := perunio.Encode(hw, archiveEnd, uint32(len(ids))); err != nil {
+		return len(ids), errors.WithMessage(err, "encoding trailer")
+	}
+	_, err = w.Write(hw.h.Sum(nil))
+	return len(ids), errors.Wrap(err, "writing checksum")
+}
+
+// channelPeers collects the peers of all channels of r.
+func channelPeers(ctx context.Context, r Restorer) (map[channel.ID][]wire.Address, error) {
+	ps, err := r.ActivePeers(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "restoring active peers")
+	}
+
+	peers := make(map[channel.ID][]wire.Address)
+	for _, p := range ps {
+		it, err := r.RestorePeer(p)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "restoring channels of peer %v", p)
+		}
+		for it.Next(ctx) {
+			id := it.Channel().ID()
+			peers[id] = append(peers[id], p)
+		}
+		if err := it.Close(); err != nil {
+			return nil, errors.WithMessagef(err, "restoring channels of peer %v", p)
+		}
+	}
+	return peers, nil
+}
+
+// ImportArchive reads an archive that was written by ExportArchive and
+// persists all its channels with p. The channels are only persisted after the
+// whole archive was read and its checksum was verified. p should not contain
+// any of the archived channels. The backends b are used for decoding. It
+// returns the number of imported channels.
+func ImportArchive(ctx context.Context, r io.Reader, p Persister, b channel.Backends) (int, error) {
+	chans, peers, err := readArchive(r, b)
+	if err != nil {
+		return 0, err
+	}
+	for i, ch := range chans {
+		if err := p.ChannelCreated(ctx, ch, peers[i]); err != nil {
+			return i, errors.WithMessagef(err, "persisting channel %x", ch.ID())
+		}
+	}
+	return len(chans), nil
+}
+
+func readArchive(r io.Reader, b channel.Backends) ([]*Channel, [][]wire.Address, error) {
+	hr := &hashReader{r: r, h: sha256.New()}
+	var (
+		magic   = make([]byte, len(archiveMagic))
+		version uint8
+	)
+	if err := perunio.Decode(hr, &magic, &version); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding header")
+	}
+	if !bytes.Equal(magic, archiveMagic[:]) {
+		return nil, nil, errors.New("not an archive")
+	}
+	if version != ArchiveVersion {
+		return nil, nil, errors.Errorf("unsupported archive version %d", version)
+	}
+
+	var (
+		chans []*Channel
+		peers [][]wire.Address
+	)
+	for {
+		var marker uint8
+		if err := perunio.Decode(hr, &marker); err != nil {
+			return nil, nil, errors.WithMessage(err, "decoding record marker")
+		}
+		if marker == archiveEnd {
+			break
+		} else if marker != archiveChannel {
+			return nil, nil, errors.Errorf("unknown record marker %d", marker)
+		}
+
+		ch, ps, err := decodeArchivedChannel(hr, b)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "decoding channel %d", len(chans))
+		}
+		chans = append(chans, ch)
+		peers = append(peers, ps)
+	}
+
+	var num uint32
+	if err := perunio.Decode(hr, &num); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding trailer")
+	}
+	if int(num) != len(chans) {
+		return nil, nil, errors.Errorf("archive has %d channels, trailer says %d", len(chans), num)
+	}
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return nil, nil, errors.Wrap(err, "reading checksum")
+	}
+	if !bytes.Equal(sum, hr.h.Sum(nil)) {
+		return nil, nil, errors.New("checksum mismatch")
+	}
+	return chans, peers, nil
+}
+
+func decodeArchivedChannel(r io.Reader, b channel.Backends) (ch *Channel, peers wallet.AddressesWithLen, err error) {
+	ch = new(Channel)
+	if err = perunio.Decode(r, &ch.IdxV); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding index")
+	}
+	if ch.ParamsV, err = b.DecodeParams(r); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding params")
+	}
+	if ch.StagingTXV, err = b.DecodeTransaction(r); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding staging transaction")
+	}
+	if ch.CurrentTXV, err = b.DecodeTransaction(r); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding current transaction")
+	}
+	if err = perunio.Decode(r, &ch.PhaseV, &peers); err != nil {
+		return nil, nil, errors.WithMessage(err, "decoding phase and peers")
+	}
+	if int(ch.IdxV) >= len(ch.ParamsV.Parts) {
+		return nil, nil, errors.Errorf("index %d out of range", ch.IdxV)
+	}
+	return ch, peers, nil
+}
+
+// hashWriter writes to w and hashes everything written.
+type hashWriter struct {
+	w io.Writer
+	h hash.Hash
+}
+
+func (w *hashWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.h.Write(p[:n]) // nolint: errcheck
+	return n, err
+}
+
+// hashReader reads from r and hashes everything read.
+type hashReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n]) // nolint: errcheck
+	return n, err
+}
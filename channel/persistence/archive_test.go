@@ -0,0 +1,92 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	pr := test.NewPersistRestorer(t)
+	c := test.NewClient(ctx, t, rng, pr)
+
+	peers := wtest.NewRandomAddresses(rng, 2)
+	var chans []*test.Channel
+	for i := 0; i < 4; i++ {
+		ch := c.NewChannel(t, peers[i%2])
+		ch.Init(t, rng)
+		ch.SignAll(t)
+		ch.EnableInit(t)
+		ch.SetFunded(t)
+		chans = append(chans, ch)
+	}
+	// Leave a staged update in the last channel.
+	state := chans[3].State().Clone()
+	state.Version++
+	require.NoError(t, chans[3].Update(t, state, chans[3].Idx()))
+
+	var archive bytes.Buffer
+	n, err := persistence.ExportArchive(ctx, &archive, pr)
+	require.NoError(t, err)
+	require.Equal(t, len(chans), n)
+
+	t.Run("import", func(t *testing.T) {
+		imported := test.NewPersistRestorer(t)
+		n, err := persistence.ImportArchive(ctx, bytes.NewReader(archive.Bytes()), imported, channel.Backends{})
+		require.NoError(t, err)
+		require.Equal(t, len(chans), n)
+
+		for _, ch := range chans {
+			restored, err := imported.RestoreChannel(ctx, ch.ID())
+			require.NoError(t, err)
+			ch.RequireEqual(t, restored)
+		}
+		activePeers, err := imported.ActivePeers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, activePeers, len(peers)+1) // + local client
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		data := archive.Bytes()
+		for _, i := range []int{0, 8, len(data) / 2, len(data) - 1} {
+			tampered := append([]byte(nil), data...)
+			tampered[i] ^= 1
+			imported := test.NewPersistRestorer(t)
+			_, err := persistence.ImportArchive(ctx, bytes.NewReader(tampered), imported, channel.Backends{})
+			assert.Error(t, err, "byte %d tampered", i)
+			activePeers, _ := imported.ActivePeers(ctx)
+			assert.Empty(t, activePeers, "nothing imported")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := archive.Bytes()
+		_, err := persistence.ImportArchive(ctx, bytes.NewReader(data[:len(data)-1]), test.NewPersistRestorer(t), channel.Backends{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,378 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wire"
+)
+
+type (
+	// Durability is the durability level of a persistence operation.
+	Durability uint8
+
+	// Op is the kind of a persistence operation, named after the Persister
+	// method.
+	Op uint8
+
+	// DurabilityPolicy maps the operations to their durability levels.
+	// Operations that are missing are Durable.
+	DurabilityPolicy map[Op]Durability
+
+	// A Syncer flushes written data to stable storage. If the Persister that
+	// is wrapped by an AsyncPersister is a Syncer, Sync is called once for each
+	// group of operations before durable operations return.
+	Syncer interface {
+		Sync() error
+	}
+
+	// AsyncPersister is a write-behind Persister. It queues the operations and
+	// writes them to the wrapped Persister in a background goroutine. Lazy
+	// operations return immediately, durable operations only after they and
+	// all operations queued before them were written.
+	//
+	// Operations are written in the order in which they were queued. The
+	// background goroutine takes all queued operations as a group, so durable
+	// operations of concurrent channels share the write and sync of their group.
+	// Queued lazy PhaseChanged operations to off-chain phases are dropped when
+	// a later operation of the same channel persists the phase anyway. Changes
+	// to on-chain phases are always written, since Persisters may record when
+	// they happened.
+	//
+	// If the context of a durable operation is done before the operation is
+	// written, the call returns an error, but the operation stays queued and
+	// is still written.
+	//
+	// If writing an operation fails, all later operations fail with the same
+	// error, which is also returned by all further calls.
+	AsyncPersister struct {
+		p      Persister
+		policy DurabilityPolicy
+
+		mu     sync.Mutex
+		queue  []*asyncOp
+		closed bool
+		err    error // first write error
+
+		wake    chan struct{}
+		stopped chan struct{}
+	}
+
+	asyncOp struct {
+		op    Op
+		id    channel.ID
+		src   *Channel                 // cloned source, nil for ChannelRemoved and flushes.
+		reg   *channel.RegisteredEvent // registration of a RegistrationSource.
+		peers []wire.Address
+		idx   channel.Index
+		done  chan error // nil for lazy operations.
+	}
+
+	durabilityKey struct{}
+)
+
+const (
+	// Durable operations return after they were written.
+	Durable Durability = iota
+	// Lazy operations return after they were queued. They are lost if the
+	// process crashes before they are written.
+	Lazy
+)
+
+// The operations of a Persister.
+const (
+	OpChannelCreated Op = iota
+	OpChannelRemoved
+	OpStaged
+	OpSigAdded
+	OpEnabled
+	OpPhaseChanged
+	opFlush
+)
+
+var _ Persister = (*AsyncPersister)(nil)
+
+// DefaultDurabilityPolicy returns the policy in which phase changes and
+// channel removals are lazy. All other operations are durable, so that
+// signatures and enabled states are persisted before they are sent.
+func DefaultDurabilityPolicy() DurabilityPolicy {
+	return DurabilityPolicy{
+		OpChannelRemoved: Lazy,
+		OpPhaseChanged:   Lazy,
+	}
+}
+
+// WithDurability returns a context that overrides the durability level of the
+// AsyncPersister operations that are called with it.
+func WithDurability(ctx context.Context, d Durability) context.Context {
+	return context.WithValue(ctx, durabilityKey{}, d)
+}
+
+// NewAsyncPersister wraps p in an AsyncPersister with the given durability
+// policy and starts its background goroutine. A nil policy makes all
+// operations durable.
+func NewAsyncPersister(p Persister, policy DurabilityPolicy) *AsyncPersister {
+	a := &AsyncPersister{
+		p:       p,
+		policy:  policy,
+		wake:    make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// ChannelCreated queues the creation of a channel.
+func (a *AsyncPersister) ChannelCreated(ctx context.Context, source channel.Source, peers []wire.Address) error {
+	return a.enqueue(ctx, &asyncOp{
+		op:    OpChannelCreated,
+		src:   CloneSource(source),
+		peers: append([]wire.Address(nil), peers...),
+	})
+}
+
+// ChannelRemoved queues the removal of a channel.
+func (a *AsyncPersister) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	return a.enqueue(ctx, &asyncOp{op: OpChannelRemoved, id: id})
+}
+
+// Staged queues the persistence of a staged state.
+func (a *AsyncPersister) Staged(ctx context.Context, source channel.Source) error {
+	return a.enqueue(ctx, &asyncOp{op: OpStaged, src: CloneSource(source)})
+}
+
+// SigAdded queues the persistence of a signature.
+func (a *AsyncPersister) SigAdded(ctx context.Context, source channel.Source, idx channel.Index) error {
+	return a.enqueue(ctx, &asyncOp{op: OpSigAdded, src: CloneSource(source), idx: idx})
+}
+
+// Enabled queues the persistence of an enabled state.
+func (a *AsyncPersister) Enabled(ctx context.Context, source channel.Source) error {
+	return a.enqueue(ctx, &asyncOp{op: OpEnabled, src: CloneSource(source)})
+}
+
+// PhaseChanged queues the persistence of a phase change.
+func (a *AsyncPersister) PhaseChanged(ctx context.Context, source channel.Source) error {
+	op := &asyncOp{op: OpPhaseChanged, src: CloneSource(source)}
+	if rs, ok := source.(RegistrationSource); ok {
+		op.reg = rs.Registered()
+	}
+	return a.enqueue(ctx, op)
+}
+
+// Flush waits until all queued operations are written.
+func (a *AsyncPersister) Flush(ctx context.Context) error {
+	return a.enqueue(WithDurability(ctx, Durable), &asyncOp{op: opFlush})
+}
+
+// Close writes all queued operations, stops the background goroutine and
+// closes the wrapped Persister. It returns the first write error, if any.
+func (a *AsyncPersister) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return errors.New("persister already closed")
+	}
+	a.closed = true
+	a.mu.Unlock()
+	a.signal()
+	<-a.stopped
+
+	err := a.p.Close()
+	if a.err != nil {
+		return a.err
+	}
+	return errors.WithMessage(err, "closing persister")
+}
+
+// durability returns the durability level of an operation.
+func (a *AsyncPersister) durability(ctx context.Context, op Op) Durability {
+	if d, ok := ctx.Value(durabilityKey{}).(Durability); ok {
+		return d
+	}
+	if d, ok := a.policy[op]; ok {
+		return d
+	}
+	return Durable
+}
+
+// enqueue queues an operation and, if it is durable, waits until it is
+// written or the context is done. The operation is written in either case.
+func (a *AsyncPersister) enqueue(ctx context.Context, op *asyncOp) error {
+	if op.src != nil {
+		op.id = op.src.ID()
+	}
+	if a.durability(ctx, op.op) == Durable {
+		op.done = make(chan error, 1)
+	}
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return errors.New("persister closed")
+	}
+	if a.err != nil {
+		err := a.err
+		a.mu.Unlock()
+		return err
+	}
+	a.coalesce(op)
+	a.queue = append(a.queue, op)
+	a.mu.Unlock()
+	a.signal()
+
+	if op.done == nil {
+		return nil
+	}
+	select {
+	case err := <-op.done:
+		return err
+	case <-ctx.Done():
+		return errors.WithMessage(ctx.Err(), "waiting for write")
+	}
+}
+
+// coalesce drops the queued lazy phase changes of op's channel to off-chain
+// phases if op persists the phase, too. a.mu must be held.
+func (a *AsyncPersister) coalesce(op *asyncOp) {
+	switch op.op {
+	case OpStaged, OpEnabled, OpPhaseChanged:
+	default:
+		return
+	}
+
+	queue := a.queue[:0]
+	for _, q := range a.queue {
+		if q.op == OpPhaseChanged && q.done == nil && q.id == op.id && q.src.Phase() <= channel.Final {
+			continue
+		}
+		queue = append(queue, q)
+	}
+	a.queue = queue
+}
+
+// signal wakes up the background goroutine.
+func (a *AsyncPersister) signal() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run is the background goroutine. It writes groups of queued operations
+// until the persister is closed and the queue is empty.
+func (a *AsyncPersister) run() {
+	defer close(a.stopped)
+	for {
+		a.mu.Lock()
+		group, closed := a.queue, a.closed
+		a.queue = nil
+		a.mu.Unlock()
+
+		if len(group) == 0 {
+			if closed {
+				return
+			}
+			<-a.wake
+			continue
+		}
+		a.commit(group)
+	}
+}
+
+// commit writes a group of operations, syncs the wrapped Persister and then
+// notifies the waiting durable operations.
+func (a *AsyncPersister) commit(group []*asyncOp) {
+	a.mu.Lock()
+	err := a.err
+	a.mu.Unlock()
+
+	for _, op := range group {
+		if err != nil {
+			break
+		}
+		err = errors.WithMessagef(a.write(op), "writing %v of channel %x", op.op, op.id)
+	}
+	if s, ok := a.p.(Syncer); ok && err == nil {
+		err = errors.WithMessage(s.Sync(), "syncing")
+	}
+
+	if err != nil {
+		a.mu.Lock()
+		if a.err == nil {
+			a.err = err
+		}
+		err = a.err
+		a.mu.Unlock()
+	}
+	for _, op := range group {
+		if op.done != nil {
+			op.done <- err
+		}
+	}
+}
+
+// write writes a single operation to the wrapped Persister.
+func (a *AsyncPersister) write(op *asyncOp) error {
+	ctx := context.Background()
+	switch op.op {
+	case OpChannelCreated:
+		return a.p.ChannelCreated(ctx, op.src, op.peers)
+	case OpChannelRemoved:
+		return a.p.ChannelRemoved(ctx, op.id)
+	case OpStaged:
+		return a.p.Staged(ctx, op.src)
+	case OpSigAdded:
+		return a.p.SigAdded(ctx, op.src, op.idx)
+	case OpEnabled:
+		return a.p.Enabled(ctx, op.src)
+	case OpPhaseChanged:
+		if op.reg != nil {
+			return a.p.PhaseChanged(ctx, registeredChannel{op.src, op.reg})
+		}
+		return a.p.PhaseChanged(ctx, op.src)
+	case opFlush:
+		return nil
+	}
+	panic(fmt.Sprintf("unknown operation %d", op.op))
+}
+
+// String returns the name of the Persister method of the operation.
+func (o Op) String() string {
+	return [...]string{
+		"ChannelCreated",
+		"ChannelRemoved",
+		"Staged",
+		"SigAdded",
+		"Enabled",
+		"PhaseChanged",
+		"flush",
+	}[o]
+}
+
+// registeredChannel is a cloned RegistrationSource.
+type registeredChannel struct {
+	*Channel
+	reg *channel.RegisteredEvent
+}
+
+// Registered returns the registration of the cloned source.
+func (c registeredChannel) Registered() *channel.RegisteredEvent { return c.reg }
@@ -0,0 +1,283 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence_test
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/test"
+	ctest "perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wire"
+)
+
+func TestAsyncPersister_Generic(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	pr := test.NewPersistRestorer(t)
+	// All operations are durable, so that the restorer sees them.
+	async := persistence.NewAsyncPersister(pr, nil)
+	test.GenericPersistRestorerTest(
+		context.Background(),
+		t,
+		rng,
+		struct {
+			*persistence.AsyncPersister
+			persistence.Restorer
+		}{async, pr},
+		4,
+		16)
+	require.NoError(t, async.Close())
+}
+
+func TestAsyncPersister_Durability(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	rec := newRecorder()
+	async := persistence.NewAsyncPersister(rec, persistence.DefaultDurabilityPolicy())
+	ch := newRandomSource(rng)
+
+	// Block the background goroutine in a lazy write.
+	rec.block()
+	require.NoError(t, async.PhaseChanged(ctx, ch))
+	<-rec.blocked
+
+	// Lazy phase changes are queued and coalesced.
+	for _, phase := range []channel.Phase{channel.Funding, channel.Acting} {
+		ch.PhaseV = phase
+		require.NoError(t, async.PhaseChanged(ctx, ch))
+	}
+	ch.PhaseV = channel.Signing
+	require.NoError(t, async.PhaseChanged(ctx, ch))
+	done := make(chan error)
+	go func() { done <- async.SigAdded(ctx, ch, 0) }()
+	select {
+	case <-done:
+		t.Fatal("durable operation returned before it was written")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	rec.unblock()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"PhaseChanged", "PhaseChanged", "SigAdded"}, rec.ops())
+	assert.Equal(t, channel.Signing, rec.lastPhase())
+
+	// The durability can be overridden by the context.
+	rec.block()
+	require.NoError(t, async.Enabled(persistence.WithDurability(ctx, persistence.Lazy), ch))
+	<-rec.blocked
+
+	// Close flushes lazy operations.
+	ch.PhaseV = channel.Withdrawing
+	require.NoError(t, async.PhaseChanged(ctx, ch))
+	require.NoError(t, async.ChannelRemoved(ctx, ch.ID()))
+	rec.unblock()
+	require.NoError(t, async.Close())
+	assert.Equal(t, []string{"PhaseChanged", "PhaseChanged", "SigAdded", "Enabled", "PhaseChanged", "ChannelRemoved", "Close"}, rec.ops())
+	assert.Error(t, async.PhaseChanged(ctx, ch))
+}
+
+func TestAsyncPersister_Coalesce(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	rec := newRecorder()
+	async := persistence.NewAsyncPersister(rec, persistence.DefaultDurabilityPolicy())
+	ch := newRandomSource(rng)
+
+	rec.block()
+	require.NoError(t, async.ChannelRemoved(ctx, ch.ID()))
+	<-rec.blocked
+
+	// Changes to on-chain phases are not dropped.
+	for _, phase := range []channel.Phase{channel.Registering, channel.Registered, channel.Withdrawing} {
+		ch.PhaseV = phase
+		require.NoError(t, async.PhaseChanged(ctx, ch))
+	}
+	rec.unblock()
+	require.NoError(t, async.Close())
+	assert.Equal(t, []string{"ChannelRemoved", "PhaseChanged", "PhaseChanged", "PhaseChanged", "Close"}, rec.ops())
+}
+
+func TestAsyncPersister_Registration(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	rec := newRecorder()
+	async := persistence.NewAsyncPersister(rec, persistence.DefaultDurabilityPolicy())
+	ch := newRandomSource(rng)
+	ch.PhaseV = channel.Registered
+	reg := &channel.RegisteredEvent{ID: ch.ID(), Version: 1, Timeout: new(channel.ElapsedTimeout)}
+
+	// The registration of a RegistrationSource is passed on.
+	require.NoError(t, async.PhaseChanged(persistence.WithDurability(ctx, persistence.Lazy), registeredSource{ch, reg}))
+	require.NoError(t, async.Close())
+	assert.Same(t, reg, rec.reg)
+}
+
+func TestAsyncPersister_Sync(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	rec := &syncRecorder{newRecorder()}
+	async := persistence.NewAsyncPersister(rec, nil)
+	ch := newRandomSource(rng)
+
+	rec.block()
+	require.NoError(t, async.Enabled(persistence.WithDurability(ctx, persistence.Lazy), ch))
+	<-rec.blocked
+
+	// Durable operations whose context is done are still written.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, async.SigAdded(cancelled, ch, 0))
+	assert.Error(t, async.SigAdded(cancelled, ch, 1))
+	assert.Error(t, async.Flush(cancelled))
+	rec.unblock()
+	require.NoError(t, async.Close())
+	// One sync per group of operations.
+	assert.Equal(t, []string{"Enabled", "Sync", "SigAdded", "SigAdded", "Sync", "Close"}, rec.ops())
+}
+
+func TestAsyncPersister_Error(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	rec := newRecorder()
+	rec.err = errors.New("write error")
+	async := persistence.NewAsyncPersister(rec, persistence.DefaultDurabilityPolicy())
+	ch := newRandomSource(rng)
+
+	// The error of a lazy operation is returned by later operations.
+	require.NoError(t, async.ChannelRemoved(ctx, ch.ID()))
+	assert.Error(t, async.Flush(ctx))
+	assert.Error(t, async.Enabled(ctx, ch))
+	assert.Error(t, async.PhaseChanged(ctx, ch))
+	assert.Error(t, async.Close())
+	assert.Equal(t, []string{"ChannelRemoved", "Close"}, rec.ops())
+}
+
+func newRandomSource(rng *rand.Rand) *persistence.Channel {
+	params, state := ctest.NewRandomParamsAndState(rng)
+	return &persistence.Channel{
+		ParamsV:    params,
+		CurrentTXV: channel.Transaction{State: state},
+		PhaseV:     channel.InitActing,
+	}
+}
+
+// recorder is a Persister that records the operations and the phases of the
+// sources. The recorded phase is the last one.
+type recorder struct {
+	mu      sync.Mutex
+	log     []string
+	phase   channel.Phase
+	reg     *channel.RegisteredEvent // last registration of a RegistrationSource
+	err     error
+	gate    chan struct{} // taken by the next operation
+	release chan struct{} // closed by unblock
+	blocked chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{blocked: make(chan struct{}, 1)}
+}
+
+// block makes the next operation block until unblock is called.
+func (r *recorder) block() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.release = make(chan struct{})
+	r.gate = r.release
+}
+
+func (r *recorder) unblock() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	close(r.release)
+}
+
+func (r *recorder) record(op string, s channel.Source) error {
+	r.mu.Lock()
+	gate := r.gate
+	r.gate = nil
+	r.mu.Unlock()
+	if gate != nil {
+		r.blocked <- struct{}{}
+		<-gate
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, op)
+	if s != nil {
+		r.phase = s.Phase()
+	}
+	if rs, ok := s.(persistence.RegistrationSource); ok {
+		r.reg = rs.Registered()
+	}
+	return r.err
+}
+
+func (r *recorder) ops() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.log...)
+}
+
+func (r *recorder) lastPhase() channel.Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phase
+}
+
+func (r *recorder) ChannelCreated(_ context.Context, s channel.Source, _ []wire.Address) error {
+	return r.record("ChannelCreated", s)
+}
+func (r *recorder) ChannelRemoved(context.Context, channel.ID) error {
+	return r.record("ChannelRemoved", nil)
+}
+func (r *recorder) Staged(_ context.Context, s channel.Source) error { return r.record("Staged", s) }
+func (r *recorder) SigAdded(_ context.Context, s channel.Source, _ channel.Index) error {
+	return r.record("SigAdded", s)
+}
+func (r *recorder) Enabled(_ context.Context, s channel.Source) error { return r.record("Enabled", s) }
+func (r *recorder) PhaseChanged(_ context.Context, s channel.Source) error {
+	return r.record("PhaseChanged", s)
+}
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, "Close")
+	return nil
+}
+
+// syncRecorder is a recorder that also records syncs.
+type syncRecorder struct{ *recorder }
+
+func (r *syncRecorder) Sync() error { return r.record("Sync", nil) }
+
+// registeredSource is a RegistrationSource.
+type registeredSource struct {
+	*persistence.Channel
+	reg *channel.RegisteredEvent
+}
+
+func (s registeredSource) Registered() *channel.RegisteredEvent { return s.reg }
@@ -0,0 +1,524 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyvalue
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/key"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+type (
+	// ArchivedChannel is a removed channel in the archive of a
+	// PersistRestorer.
+	ArchivedChannel struct {
+		Idx     channel.Index       // Idx is the own index in the channel.
+		Params  *channel.Params     // Params are the channel parameters.
+		FinalTX channel.Transaction // FinalTX is the last current transaction.
+		Phase   channel.Phase       // Phase is the phase at removal.
+		Peers   []wire.Address      // Peers are the channel network peers.
+
+		// Registration is the last registration of the channel on the
+		// adjudicator, or nil if it was never registered.
+		Registration *Registration
+		// Withdrawal is the withdrawal of the channel, or nil if it was removed
+		// without being withdrawn.
+		Withdrawal *Withdrawal
+		// Removed is the time when the channel was removed.
+		Removed time.Time
+	}
+
+	// Registration is the registration of an archived channel.
+	Registration struct {
+		Time    time.Time // Time is when the registration was persisted.
+		Version uint64    // Version is the registered version.
+		// Timeout is the dispute timeout of the registration. Timeouts other
+		// than channel.ElapsedTimeout and channel.TimeTimeout are archived as
+		// ArchivedTimeout. It is nil if the persisted source did not provide
+		// the registered event.
+		Timeout channel.Timeout
+	}
+
+	// Withdrawal is the withdrawal of an archived channel.
+	Withdrawal struct {
+		Time    time.Time // Time is when the withdrawal was persisted.
+		Version uint64    // Version is the version of the withdrawn state.
+		// Balances are the own withdrawn balances, indexed like the assets of
+		// the withdrawn state.
+		Balances []channel.Bal
+	}
+
+	// ArchivedTimeout is an archived dispute timeout of a backend-specific
+	// type. Since archived channels are settled, it is always elapsed.
+	ArchivedTimeout struct {
+		Desc string // Desc is the String() of the original timeout.
+	}
+
+	// RetentionPolicy controls which archived channels are pruned. Zero
+	// values disable the respective limit.
+	RetentionPolicy struct {
+		// MaxAge is the maximal time for which removed channels are archived.
+		MaxAge time.Duration
+		// MaxChannels is the maximal number of archived channels. The oldest
+		// channels are pruned first.
+		MaxChannels int
+	}
+
+	// ArchiveQuery filters archived channels. Zero values match all
+	// channels.
+	ArchiveQuery struct {
+		// Peer must be a peer of the channel.
+		Peer wire.Address
+		// From and To limit the removal time to [From, To).
+		From, To time.Time
+		// Asset must be an asset of the final transaction.
+		Asset channel.Asset
+	}
+
+	archiveConfig struct {
+		policy RetentionPolicy
+		now    func() time.Time
+
+		mu sync.Mutex // mu serializes pruning and protects count.
+		// count is the number of archived channels, or -1 if it was not
+		// counted yet.
+		count int
+	}
+)
+
+var _ channel.Timeout = (*ArchivedTimeout)(nil)
+
+// EnableArchive enables the archive mode. Removed channels are then moved to
+// an archive instead of being deleted, together with the details of their
+// registration and withdrawal. The archive is pruned by the retention policy
+// after every removal. Pruning only reads the pruned channels. This method is
+// expected to be called once during the setup of the PersistRestorer and is
+// hence not thread-safe.
+func (pr *PersistRestorer) EnableArchive(policy RetentionPolicy) {
+	pr.archive = &archiveConfig{policy: policy, now: time.Now, count: -1}
+}
+
+// ArchivedChannels returns all archived channels that match the query,
+// sorted by their removal time. Only the archived channels in the queried
+// time range are read.
+func (pr *PersistRestorer) ArchivedChannels(q ArchiveQuery) ([]*ArchivedChannel, error) {
+	var start, end string
+	if !q.From.IsZero() {
+		start = archiveTimeKey(q.From)
+	}
+	if !q.To.IsZero() {
+		end = archiveTimeKey(q.To)
+	}
+
+	var chans []*ArchivedChannel
+	archive := sortedkv.NewTable(pr.db, prefix.ArchiveDB)
+	it := sortedkv.NewTable(pr.db, prefix.ArchiveIndexDB).NewIteratorWithRange(start, end)
+	for it.Next() {
+		id := it.Key()[archiveTimeKeyLen:]
+		b, err := archive.GetBytes(id)
+		if err != nil {
+			it.Close() // nolint: errcheck
+			return nil, errors.WithMessagef(err, "reading archived channel %x", id)
+		}
+		a := new(ArchivedChannel)
+		if err := a.decode(bytes.NewReader(b), pr.backends); err != nil {
+			it.Close() // nolint: errcheck
+			return nil, errors.WithMessagef(err, "decoding archived channel %x", id)
+		}
+		if q.matches(a) {
+			chans = append(chans, a)
+		}
+	}
+	return chans, errors.WithMessage(it.Close(), "iterating archive index")
+}
+
+// PruneArchive deletes the archived channels that exceed the retention
+// policy. It returns the number of pruned channels.
+func (pr *PersistRestorer) PruneArchive() (int, error) {
+	if pr.archive == nil {
+		return 0, nil
+	}
+	pr.archive.mu.Lock()
+	defer pr.archive.mu.Unlock()
+
+	count, err := pr.archiveCount()
+	if err != nil {
+		return 0, err
+	}
+	policy := pr.archive.policy
+	var cutoff string
+	if policy.MaxAge > 0 {
+		cutoff = archiveTimeKey(pr.archive.now().Add(-policy.MaxAge))
+	}
+	excess := 0
+	if policy.MaxChannels > 0 {
+		excess = count - policy.MaxChannels
+	}
+
+	// The index is sorted by removal time, so only the pruned channels and
+	// the first kept one are read.
+	batch := pr.db.NewBatch()
+	archive := sortedkv.NewTableBatch(batch, prefix.ArchiveDB)
+	index := sortedkv.NewTableBatch(batch, prefix.ArchiveIndexDB)
+	var pruned int
+	it := sortedkv.NewTable(pr.db, prefix.ArchiveIndexDB).NewIterator()
+	for it.Next() && (pruned < excess || it.Key() < cutoff) {
+		id := it.Key()[archiveTimeKeyLen:]
+		if err := archive.Delete(id); err != nil {
+			it.Close() // nolint: errcheck
+			return 0, errors.WithMessage(err, "deleting archived channel")
+		}
+		if err := index.Delete(it.Key()); err != nil {
+			it.Close() // nolint: errcheck
+			return 0, errors.WithMessage(err, "deleting archive index")
+		}
+		pruned++
+	}
+	if err := it.Close(); err != nil {
+		return 0, errors.WithMessage(err, "iterating archive index")
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	if err := batch.Apply(); err != nil {
+		return 0, errors.WithMessage(err, "applying batch")
+	}
+	pr.archive.count -= pruned
+	return pruned, nil
+}
+
+// archiveCount returns the number of archived channels. It is counted once and
+// then kept up to date. The archive mutex must be held.
+func (pr *PersistRestorer) archiveCount() (int, error) {
+	if pr.archive.count < 0 {
+		n, err := pr.db.Count(prefix.ArchiveIndexDB, key.IncPrefix(prefix.ArchiveIndexDB))
+		if err != nil {
+			return 0, errors.WithMessage(err, "counting archived channels")
+		}
+		pr.archive.count = n
+	}
+	return pr.archive.count, nil
+}
+
+// archived records that a channel was added to the archive and prunes the
+// archive. Pruning errors are logged, since the channel is already removed.
+func (pr *PersistRestorer) archived() {
+	pr.archive.mu.Lock()
+	if pr.archive.count >= 0 {
+		pr.archive.count++
+	}
+	pr.archive.mu.Unlock()
+
+	if _, err := pr.PruneArchive(); err != nil {
+		log.Warnf("Pruning channel archive: %v", err)
+	}
+}
+
+// archiveChannel writes the archive entry of a channel that is removed and
+// deletes its history to batch.
+func (pr *PersistRestorer) archiveChannel(batch sortedkv.Batch, id channel.ID, params *channel.Params, peers []wire.Address) error {
+	db := pr.channelDB(id)
+	a := &ArchivedChannel{
+		Params:  params,
+		Peers:   peers,
+		Removed: pr.archive.now(),
+	}
+
+	b, err := db.GetBytes("current")
+	if err != nil {
+		return errors.WithMessage(err, "reading current transaction")
+	}
+	if a.FinalTX, err = pr.backends.DecodeTransaction(bytes.NewReader(b)); err != nil {
+		return errors.WithMessage(err, "decoding current transaction")
+	}
+	if err := dbGet(db, "index", &a.Idx); err != nil {
+		return err
+	}
+	if err := dbGet(db, "phase", &a.Phase); err != nil {
+		return err
+	}
+
+	hist := sortedkv.NewTable(pr.db, historyPrefix(id))
+	reg, wdr := new(Registration), new(Withdrawal)
+	if ok, err := dbGetOpt(hist, "registered", reg); err != nil {
+		return err
+	} else if ok {
+		a.Registration = reg
+	}
+	if ok, err := dbGetOpt(hist, "withdrawn", wdr); err != nil {
+		return err
+	} else if ok {
+		a.Withdrawal = wdr
+	}
+
+	if err := batch.DeletePrefix(historyPrefix(id)); err != nil {
+		return errors.WithMessage(err, "deleting history")
+	}
+	index := sortedkv.NewTableBatch(batch, prefix.ArchiveIndexDB)
+	if err := index.Put(archiveTimeKey(a.Removed)+string(id[:]), ""); err != nil {
+		return errors.WithMessage(err, "putting archive index")
+	}
+	return dbPut(sortedkv.NewTableBatch(batch, prefix.ArchiveDB), string(id[:]), a)
+}
+
+// archiveTimeKeyLen is the length of an archive index time key.
+const archiveTimeKeyLen = 8
+
+// archiveTimeKey encodes t so that the keys of later times sort after those of
+// earlier times. The archive index is keyed by the removal time followed by
+// the channel ID.
+func archiveTimeKey(t time.Time) string {
+	var b [archiveTimeKeyLen]byte
+	binary.BigEndian.PutUint64(b[:], uint64(t.UnixNano())^(1<<63))
+	return string(b[:])
+}
+
+// putHistory records the registration or withdrawal of the channel, if archive
+// mode is enabled. The registered version and timeout are taken from the
+// registered event if s is a persistence.RegistrationSource, otherwise the
+// current version is recorded.
+func (pr *PersistRestorer) putHistory(batch sortedkv.Batch, s channel.Source) error {
+	if pr.archive == nil {
+		return nil
+	}
+	hist := sortedkv.NewTableBatch(batch, historyPrefix(s.ID()))
+	tx := s.CurrentTX()
+	switch s.Phase() {
+	case channel.Registered:
+		reg := Registration{Time: pr.archive.now()}
+		if tx.State != nil {
+			reg.Version = tx.Version
+		}
+		if rs, ok := s.(persistence.RegistrationSource); ok && rs.Registered() != nil {
+			reg.Version, reg.Timeout = rs.Registered().Version, rs.Registered().Timeout
+		}
+		return dbPut(hist, "registered", reg)
+	case channel.Withdrawn:
+		wdr := Withdrawal{Time: pr.archive.now()}
+		if tx.State != nil {
+			wdr.Version = tx.Version
+			for _, bals := range tx.Balances {
+				wdr.Balances = append(wdr.Balances, bals[s.Idx()])
+			}
+		}
+		return dbPut(hist, "withdrawn", wdr)
+	}
+	return nil
+}
+
+func historyPrefix(id channel.ID) string {
+	return prefix.HistoryDB + string(id[:]) + ":"
+}
+
+func (q ArchiveQuery) matches(a *ArchivedChannel) bool {
+	if !q.From.IsZero() && a.Removed.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !a.Removed.Before(q.To) {
+		return false
+	}
+	if q.Peer != nil && !containsAddress(a.Peers, q.Peer) {
+		return false
+	}
+	if q.Asset != nil && !hasAsset(a.FinalTX, q.Asset) {
+		return false
+	}
+	return true
+}
+
+func containsAddress(addrs []wire.Address, addr wire.Address) bool {
+	for _, a := range addrs {
+		if a.Equals(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAsset returns whether asset is an asset of tx, compared by encoding.
+func hasAsset(tx channel.Transaction, asset channel.Asset) bool {
+	if tx.State == nil {
+		return false
+	}
+	var want bytes.Buffer
+	if err := asset.Encode(&want); err != nil {
+		return false
+	}
+	for _, a := range tx.Allocation.Assets {
+		var got bytes.Buffer
+		if err := a.Encode(&got); err == nil && bytes.Equal(got.Bytes(), want.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode encodes an archived channel.
+func (a ArchivedChannel) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, a.Idx, a.Params, a.FinalTX, a.Phase,
+		wallet.AddressesWithLen(a.Peers), a.Registration != nil, a.Withdrawal != nil); err != nil {
+		return err
+	}
+	if a.Registration != nil {
+		if err := a.Registration.Encode(w); err != nil {
+			return errors.WithMessage(err, "encoding registration")
+		}
+	}
+	if a.Withdrawal != nil {
+		if err := a.Withdrawal.Encode(w); err != nil {
+			return errors.WithMessage(err, "encoding withdrawal")
+		}
+	}
+	return perunio.Encode(w, a.Removed)
+}
+
+func (a *ArchivedChannel) decode(r io.Reader, b channel.Backends) (err error) {
+	if err := perunio.Decode(r, &a.Idx); err != nil {
+		return errors.WithMessage(err, "decoding index")
+	}
+	if a.Params, err = b.DecodeParams(r); err != nil {
+		return errors.WithMessage(err, "decoding params")
+	}
+	if a.FinalTX, err = b.DecodeTransaction(r); err != nil {
+		return errors.WithMessage(err, "decoding final transaction")
+	}
+	var peers wallet.AddressesWithLen
+	var registered, withdrawn bool
+	if err := perunio.Decode(r, &a.Phase, &peers, &registered, &withdrawn); err != nil {
+		return err
+	}
+	a.Peers = peers
+	if registered {
+		a.Registration = new(Registration)
+		if err := a.Registration.Decode(r); err != nil {
+			return errors.WithMessage(err, "decoding registration")
+		}
+	}
+	if withdrawn {
+		a.Withdrawal = new(Withdrawal)
+		if err := a.Withdrawal.Decode(r); err != nil {
+			return errors.WithMessage(err, "decoding withdrawal")
+		}
+	}
+	return perunio.Decode(r, &a.Removed)
+}
+
+// Timeout encodings of a Registration.
+const (
+	timeoutNone byte = iota
+	timeoutElapsed
+	timeoutTime
+	timeoutArchived
+)
+
+// Encode encodes a registration.
+func (r Registration) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, r.Time, r.Version); err != nil {
+		return err
+	}
+	switch t := r.Timeout.(type) {
+	case nil:
+		return perunio.Encode(w, timeoutNone)
+	case *channel.ElapsedTimeout:
+		return perunio.Encode(w, timeoutElapsed)
+	case *channel.TimeTimeout:
+		return perunio.Encode(w, timeoutTime, t.Time)
+	default:
+		return perunio.Encode(w, timeoutArchived, fmt.Sprint(t))
+	}
+}
+
+// Decode decodes a registration.
+func (r *Registration) Decode(rd io.Reader) error {
+	var kind byte
+	if err := perunio.Decode(rd, &r.Time, &r.Version, &kind); err != nil {
+		return err
+	}
+	switch kind {
+	case timeoutNone:
+		r.Timeout = nil
+	case timeoutElapsed:
+		r.Timeout = new(channel.ElapsedTimeout)
+	case timeoutTime:
+		t := new(channel.TimeTimeout)
+		r.Timeout = t
+		return perunio.Decode(rd, &t.Time)
+	case timeoutArchived:
+		t := new(ArchivedTimeout)
+		r.Timeout = t
+		return perunio.Decode(rd, &t.Desc)
+	default:
+		return errors.Errorf("unknown timeout encoding %d", kind)
+	}
+	return nil
+}
+
+// Encode encodes a withdrawal.
+func (wd Withdrawal) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, wd.Time, wd.Version, channel.Index(len(wd.Balances))); err != nil {
+		return err
+	}
+	for _, bal := range wd.Balances {
+		if err := perunio.Encode(w, bal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode decodes a withdrawal.
+func (wd *Withdrawal) Decode(r io.Reader) error {
+	var n channel.Index
+	if err := perunio.Decode(r, &wd.Time, &wd.Version, &n); err != nil {
+		return err
+	}
+	if n > channel.MaxNumAssets {
+		return errors.Errorf("too many withdrawn balances: %d", n)
+	}
+	wd.Balances = make([]channel.Bal, n)
+	for i := range wd.Balances {
+		wd.Balances[i] = new(big.Int)
+		if err := perunio.Decode(r, &wd.Balances[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsElapsed returns true, since archived channels are settled.
+func (*ArchivedTimeout) IsElapsed(context.Context) bool { return true }
+
+// Wait returns nil immediately, since the timeout is elapsed.
+func (*ArchivedTimeout) Wait(context.Context) error { return nil }
+
+// String returns the description of the original timeout.
+func (t *ArchivedTimeout) String() string { return t.Desc }
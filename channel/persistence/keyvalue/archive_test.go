@@ -0,0 +1,203 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyvalue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence/test"
+	"perun.network/go-perun/pkg/sortedkv/key"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+func TestPersistRestorer_Archive(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	data := make(map[string]string)
+	pr, err := NewPersistRestorer(memorydb.FromData(data))
+	require.NoError(t, err)
+	pr.EnableArchive(RetentionPolicy{MaxAge: 10 * time.Hour, MaxChannels: 3})
+	start := time.Unix(1600000000, 0)
+	now := start
+	pr.archive.now = func() time.Time { return now }
+
+	c := test.NewClient(ctx, t, rng, pr)
+	peers := wtest.NewRandomAddresses(rng, 2)
+	var chans []*test.Channel
+	for i := 0; i < 4; i++ {
+		ch := c.NewChannel(t, peers[i%2])
+		ch.Init(t, rng)
+		ch.SignAll(t)
+		ch.EnableInit(t)
+		ch.SetFunded(t)
+		chans = append(chans, ch)
+	}
+
+	// Settle the first three channels one hour apart.
+	for i, ch := range chans[:3] {
+		now = start.Add(time.Duration(i) * time.Hour)
+		settle(t, ch)
+		require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+	}
+	// Nothing about the removed channels is left outside of the archive.
+	for key := range data {
+		assert.False(t, strings.HasPrefix(key, prefix.HistoryDB), "history not removed")
+		for _, ch := range chans[:3] {
+			id := ch.ID()
+			assert.False(t, strings.HasPrefix(key, prefix.ChannelDB+string(id[:])), "channel data not removed")
+			assert.False(t, strings.HasPrefix(key, prefix.PeerDB) && strings.HasSuffix(key, string(id[:])),
+				"peer entry not removed")
+		}
+	}
+
+	all, err := pr.ArchivedChannels(ArchiveQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	for i, a := range all {
+		ch := chans[i]
+		assert.Equal(t, ch.ID(), a.Params.ID())
+		assert.Equal(t, ch.Idx(), a.Idx)
+		assert.Equal(t, ch.CurrentTX(), a.FinalTX)
+		assert.Equal(t, channel.Withdrawn, a.Phase)
+		assert.Len(t, a.Peers, 2)
+		removed := start.Add(time.Duration(i) * time.Hour)
+		require.NotNil(t, a.Registration)
+		assert.Equal(t, ch.CurrentTX().Version, a.Registration.Version)
+		assert.Equal(t, settleTimeout, a.Registration.Timeout)
+		assert.True(t, a.Registration.Time.Equal(removed))
+		require.NotNil(t, a.Withdrawal)
+		assert.Equal(t, ch.CurrentTX().Version, a.Withdrawal.Version)
+		require.Len(t, a.Withdrawal.Balances, len(ch.CurrentTX().Assets))
+		for j, bal := range a.Withdrawal.Balances {
+			assert.Zero(t, ch.CurrentTX().Balances[j][ch.Idx()].Cmp(bal))
+		}
+		assert.True(t, a.Withdrawal.Time.Equal(removed))
+		assert.True(t, a.Removed.Equal(removed))
+	}
+
+	// Queries by peer, time range and asset.
+	byPeer, err := pr.ArchivedChannels(ArchiveQuery{Peer: peers[1]})
+	require.NoError(t, err)
+	require.Len(t, byPeer, 1)
+	assert.Equal(t, chans[1].ID(), byPeer[0].Params.ID())
+
+	byTime, err := pr.ArchivedChannels(ArchiveQuery{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, byTime, 1)
+	assert.Equal(t, chans[1].ID(), byTime[0].Params.ID())
+
+	asset := chans[2].CurrentTX().Allocation.Assets[0]
+	byAsset, err := pr.ArchivedChannels(ArchiveQuery{Asset: asset})
+	require.NoError(t, err)
+	require.Len(t, byAsset, 1)
+	assert.Equal(t, chans[2].ID(), byAsset[0].Params.ID())
+
+	// Time range queries only read the archived channels in the range.
+	id0 := chans[0].ID()
+	require.NoError(t, pr.db.Put(prefix.ArchiveDB+string(id0[:]), "corrupt"))
+	byTime, err = pr.ArchivedChannels(ArchiveQuery{From: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, byTime, 2)
+	assert.Equal(t, chans[1].ID(), byTime[0].Params.ID())
+	assert.Equal(t, chans[2].ID(), byTime[1].Params.ID())
+	_, err = pr.ArchivedChannels(ArchiveQuery{To: start.Add(time.Hour)})
+	assert.Error(t, err)
+
+	// Removing a fourth channel prunes the oldest one.
+	now = start.Add(3 * time.Hour)
+	settle(t, chans[3])
+	require.NoError(t, pr.ChannelRemoved(ctx, chans[3].ID()))
+	all, err = pr.ArchivedChannels(ArchiveQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, chans[1].ID(), all[0].Params.ID())
+
+	// Channels older than MaxAge are pruned.
+	now = start.Add(12*time.Hour + time.Minute)
+	n, err := pr.PruneArchive()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	all, err = pr.ArchivedChannels(ArchiveQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, chans[3].ID(), all[0].Params.ID())
+	n, err = pr.db.Count(prefix.ArchiveIndexDB, key.IncPrefix(prefix.ArchiveIndexDB))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "archive index entries not pruned")
+	assert.Equal(t, 1, pr.archive.count)
+}
+
+// settleTimeout is the registration timeout used by settle.
+var settleTimeout = &channel.TimeTimeout{Time: time.Unix(1700000000, 0)}
+
+// settle registers and withdraws a channel with a final state.
+func settle(t *testing.T, ch *test.Channel) {
+	statef := ch.State().Clone()
+	statef.Version++
+	statef.IsFinal = true
+	require.NoError(t, ch.Update(t, statef, ch.Idx()))
+	ch.SignAll(t)
+	ch.EnableFinal(t)
+	ch.SetRegistering(t)
+	ch.SetRegistered(t, &channel.RegisteredEvent{
+		ID:      ch.ID(),
+		Version: statef.Version,
+		Timeout: settleTimeout,
+	})
+	ch.SetWithdrawing(t)
+	ch.SetWithdrawn(t)
+}
+
+func TestRegistration_Timeout(t *testing.T) {
+	for _, timeout := range []channel.Timeout{
+		nil,
+		new(channel.ElapsedTimeout),
+		&channel.TimeTimeout{Time: time.Unix(1600000000, 0)},
+		&ArchivedTimeout{Desc: "<Timeout: block 7>"},
+		blockTimeout(7),
+	} {
+		reg := Registration{Time: time.Unix(1600000000, 0), Version: 3, Timeout: timeout}
+		var buf bytes.Buffer
+		require.NoError(t, reg.Encode(&buf))
+		var dec Registration
+		require.NoError(t, dec.Decode(&buf))
+		assert.True(t, reg.Time.Equal(dec.Time))
+		assert.Equal(t, reg.Version, dec.Version)
+		if bt, ok := timeout.(blockTimeout); ok {
+			assert.Equal(t, &ArchivedTimeout{Desc: bt.String()}, dec.Timeout)
+			assert.True(t, dec.Timeout.IsElapsed(context.Background()))
+			continue
+		}
+		assert.Equal(t, timeout, dec.Timeout)
+	}
+}
+
+// blockTimeout is a backend-specific timeout.
+type blockTimeout uint64
+
+func (blockTimeout) IsElapsed(context.Context) bool { return false }
+func (blockTimeout) Wait(context.Context) error     { return nil }
+func (t blockTimeout) String() string               { return fmt.Sprintf("<Timeout: block %d>", t) }
@@ -0,0 +1,273 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyvalue
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	ctest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+var errCrashed = errors.New("crashed")
+
+// crashDB simulates a crash after a fixed number of key writes. All writes
+// after the crash fail. Batches are applied atomically: a batch that would
+// cross the crash point is dropped completely.
+type crashDB struct {
+	sortedkv.Database
+	writesLeft int
+}
+
+type crashBatch struct {
+	sortedkv.Batch
+	db  *crashDB
+	ops int
+}
+
+func (d *crashDB) write() error {
+	if d.writesLeft == 0 {
+		return errCrashed
+	}
+	d.writesLeft--
+	return nil
+}
+
+func (d *crashDB) Put(key, value string) error {
+	if err := d.write(); err != nil {
+		return err
+	}
+	return d.Database.Put(key, value)
+}
+
+func (d *crashDB) PutBytes(key string, value []byte) error {
+	if err := d.write(); err != nil {
+		return err
+	}
+	return d.Database.PutBytes(key, value)
+}
+
+func (d *crashDB) Delete(key string) error {
+	if err := d.write(); err != nil {
+		return err
+	}
+	return d.Database.Delete(key)
+}
+
+func (d *crashDB) DeleteRange(start, end string) error {
+	if err := d.write(); err != nil {
+		return err
+	}
+	return d.Database.DeleteRange(start, end)
+}
+
+func (d *crashDB) DeletePrefix(prefix string) error {
+	if err := d.write(); err != nil {
+		return err
+	}
+	return d.Database.DeletePrefix(prefix)
+}
+
+func (d *crashDB) NewBatch() sortedkv.Batch {
+	return &crashBatch{Batch: d.Database.NewBatch(), db: d}
+}
+
+func (b *crashBatch) Put(key, value string) error {
+	b.ops++
+	return b.Batch.Put(key, value)
+}
+
+func (b *crashBatch) PutBytes(key string, value []byte) error {
+	b.ops++
+	return b.Batch.PutBytes(key, value)
+}
+
+func (b *crashBatch) Delete(key string) error {
+	b.ops++
+	return b.Batch.Delete(key)
+}
+
+func (b *crashBatch) DeleteRange(start, end string) error {
+	b.ops++
+	return b.Batch.DeleteRange(start, end)
+}
+
+func (b *crashBatch) DeletePrefix(prefix string) error {
+	b.ops++
+	return b.Batch.DeletePrefix(prefix)
+}
+
+func (b *crashBatch) Apply() error {
+	if b.ops > b.db.writesLeft {
+		b.db.writesLeft = 0
+		return errCrashed
+	}
+	b.db.writesLeft -= b.ops
+	return b.Batch.Apply()
+}
+
+func (b *crashBatch) Reset() {
+	b.ops = 0
+	b.Batch.Reset()
+}
+
+// TestPersistRestorer_CrashConsistency runs a channel's life cycle and
+// crashes the database at every key boundary. The channel restored after the
+// crash must equal the channel after the last completed persister callback.
+func TestPersistRestorer_CrashConsistency(t *testing.T) {
+	for crashAt := 0; ; crashAt++ {
+		db := memorydb.NewDatabase()
+		restorer, err := NewPersistRestorer(db) // initializes the schema version
+		require.NoError(t, err)
+		pr, err := NewPersistRestorer(&crashDB{Database: db, writesLeft: crashAt})
+		require.NoError(t, err)
+		rng := pkgtest.Prng(t, crashAt)
+
+		last, peers, id, done := runCrashScenario(t, rng, pr)
+		requireConsistent(t, restorer, last, peers, id)
+		if done {
+			t.Logf("checked %d crash points", crashAt)
+			return
+		}
+	}
+}
+
+// runCrashScenario runs a channel through its life cycle until the persister
+// fails. It returns the channel after the last successful persister callback,
+// which is nil if the channel is not persisted, and whether the scenario
+// completed without a crash.
+func runCrashScenario(t *testing.T, rng *rand.Rand, pr *PersistRestorer) (
+	last *persistence.Channel, peers []wire.Address, id channel.ID, done bool) {
+	ctx := context.Background()
+	accs, parts := wtest.NewRandomAccounts(rng, 2)
+	params := ctest.NewRandomParams(rng, ctest.WithParts(parts...))
+	csm, err := channel.NewStateMachine(accs[0], *params)
+	require.NoError(t, err)
+	sm := persistence.FromStateMachine(csm, pr)
+	peers = wtest.NewRandomAddresses(rng, 2)
+	id = sm.ID()
+
+	addSig := func(idx channel.Index) func() error {
+		return func() error {
+			sig, err := channel.Sign(accs[idx], sm.Params(), sm.StagingState())
+			require.NoError(t, err)
+			return sm.AddSig(ctx, idx, sig)
+		}
+	}
+	ownSig := func() error {
+		_, err := sm.Sig(ctx)
+		return err
+	}
+	update := func(final bool) func() error {
+		return func() error {
+			state := sm.State().Clone()
+			state.Version++
+			state.IsFinal = final
+			return sm.Update(ctx, state, 1)
+		}
+	}
+	initAlloc := *ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+
+	steps := []func() error{
+		func() error { return pr.ChannelCreated(ctx, sm, peers) },
+		func() error { return sm.Init(ctx, initAlloc, channel.NewMockOp(channel.OpValid)) },
+		ownSig, addSig(1),
+		func() error { return sm.EnableInit(ctx) },
+		func() error { return sm.SetFunded(ctx) },
+		update(false), ownSig, addSig(1),
+		func() error { return sm.EnableUpdate(ctx) },
+		update(false), ownSig,
+		func() error { return sm.DiscardUpdate(ctx) },
+		update(false), ownSig, addSig(1),
+		func() error { return sm.EnableUpdate(ctx) },
+		update(true), ownSig, addSig(1),
+		func() error { return sm.EnableFinal(ctx) },
+		func() error { return sm.SetRegistering(ctx) },
+		func() error {
+			return sm.SetRegistered(ctx, &channel.RegisteredEvent{
+				ID:      id,
+				Version: sm.State().Version,
+				Timeout: new(channel.ElapsedTimeout),
+			})
+		},
+		func() error { return sm.SetWithdrawing(ctx) },
+		func() error { return sm.SetWithdrawn(ctx) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			require.True(t, errors.Is(err, errCrashed), "unexpected error: %v", err)
+			return last, peers, id, false
+		}
+		last = persistence.CloneSource(sm)
+	}
+
+	if err := pr.ChannelRemoved(ctx, id); err != nil {
+		require.True(t, errors.Is(err, errCrashed), "unexpected error: %v", err)
+		return last, peers, id, false
+	}
+	return nil, peers, id, true
+}
+
+// requireConsistent checks that pr restores exactly the expected channel and
+// the matching peer entries. If expected is nil, the channel must not exist.
+func requireConsistent(t *testing.T, pr *PersistRestorer, expected *persistence.Channel, peers []wire.Address, id channel.ID) {
+	ctx := context.Background()
+	activePeers, err := pr.ActivePeers(ctx)
+	require.NoError(t, err)
+
+	restored, err := pr.RestoreChannel(ctx, id)
+	if expected == nil {
+		require.Error(t, err)
+		require.Empty(t, activePeers)
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, activePeers, len(peers))
+
+	require.Equal(t, expected.Idx(), restored.Idx(), "Idx")
+	require.Equal(t, expected.Params(), restored.Params(), "Params")
+	require.Equal(t, expected.CurrentTX(), restored.CurrentTX(), "CurrentTX")
+	require.Equal(t, expected.Phase(), restored.Phase(), "Phase")
+	require.Equal(t, expected.StagingTX().State, restored.StagingTX().State, "StagingTX.State")
+	require.Equal(t, nilIfNoSigs(expected.StagingTX().Sigs), nilIfNoSigs(restored.StagingTX().Sigs), "StagingTX.Sigs")
+
+	it, err := pr.RestorePeer(peers[1])
+	require.NoError(t, err)
+	require.True(t, it.Next(ctx), "channel missing in peer table")
+	require.Equal(t, id, it.Channel().ID())
+	require.NoError(t, it.Close())
+}
+
+// nilIfNoSigs returns nil if sigs contains no signature.
+func nilIfNoSigs(sigs []wallet.Sig) []wallet.Sig {
+	for _, sig := range sigs {
+		if sig != nil {
+			return sigs
+		}
+	}
+	return nil
+}
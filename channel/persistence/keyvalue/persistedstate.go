@@ -17,12 +17,15 @@ package keyvalue
 import (
 	"io"
 
+	"github.com/pkg/errors"
+
 	"perun.network/go-perun/channel"
 	perunio "perun.network/go-perun/pkg/io"
 )
 
 var _ perunio.Encoder = PersistedState{}
 var _ perunio.Decoder = (*PersistedState)(nil)
+var _ perunio.Serializer = (*persistedStaging)(nil)
 
 // PersistedState is a helper struct to allow for de-/encoding of empty states.
 type PersistedState struct {
@@ -43,3 +46,39 @@ func (s *PersistedState) Decode(r io.Reader) error {
 	*s.State = new(channel.State)
 	return (*s.State).Decode(r)
 }
+
+// persistedStaging is a helper struct to allow for de-/encoding of the staged
+// state together with the request ID of the staging transaction. The request
+// ID is only encoded if it is set, so staged states that were persisted without
+// a request ID can still be decoded.
+type persistedStaging struct {
+	TX *channel.Transaction
+	// Backends are used for decoding the staged state.
+	Backends channel.Backends
+}
+
+// Encode writes the staged state and, if set, the request ID to a stream.
+func (s persistedStaging) Encode(w io.Writer) error {
+	if s.TX.State == nil {
+		return nil
+	}
+	if err := (PersistedState{&s.TX.State}).Encode(w); err != nil {
+		return err
+	}
+	if s.TX.RequestID.IsZero() {
+		return nil
+	}
+	return s.TX.RequestID.Encode(w)
+}
+
+// Decode reads the staged state and an optional request ID from a stream.
+func (s *persistedStaging) Decode(r io.Reader) (err error) {
+	if s.TX.State, err = s.Backends.DecodeState(r); err != nil {
+		return err
+	}
+	s.TX.RequestID = channel.RequestID{}
+	if _, err := io.ReadFull(r, s.TX.RequestID[:]); err != nil && err != io.EOF {
+		return errors.WithMessage(err, "decoding request ID")
+	}
+	return nil
+}
@@ -33,17 +33,21 @@ import (
 
 // ChannelCreated inserts a channel into the database.
 func (pr *PersistRestorer) ChannelCreated(_ context.Context, s channel.Source, peers []wire.Address) error {
-	db := pr.channelDB(s.ID()).NewBatch()
+	batch := pr.db.NewBatch()
+	db := channelBatch(batch, s.ID())
 	// Write the channel data in the "Channel" table.
 	numParts := len(s.Params().Parts)
-	keys := append([]string{"current", "index", "params", "peers", "phase", "staging:state"},
+	keys := append([]string{"current", "index", "params", "phase", "staging:state"},
 		sigKeys(numParts)...)
 	if err := dbPutSource(db, s, keys...); err != nil {
 		return err
 	}
+	if err := dbPut(db, prefix.Peers, wire.AddressesWithLen(peers)); err != nil {
+		return err
+	}
 
 	// Register the channel in the "Peer" table.
-	peerdb := sortedkv.NewTable(pr.db, prefix.PeerDB).NewBatch()
+	peerdb := sortedkv.NewTableBatch(batch, prefix.PeerDB)
 	for _, peer := range peers {
 		key, err := peerChannelKey(peer, s.ID())
 		if err != nil {
@@ -54,10 +58,7 @@ func (pr *PersistRestorer) ChannelCreated(_ context.Context, s channel.Source, p
 		}
 	}
 
-	if err := db.Apply(); err != nil {
-		return errors.WithMessage(err, "applying channel batch")
-	}
-	return errors.WithMessage(peerdb.Apply(), "applying peer batch")
+	return errors.WithMessage(batch.Apply(), "applying batch")
 }
 
 // sigKey creates a key for given idx and number of channel
@@ -69,25 +70,24 @@ func sigKey(idx, numParts int) string {
 
 // ChannelRemoved deletes a channel from the database.
 func (pr *PersistRestorer) ChannelRemoved(_ context.Context, id channel.ID) error {
-	db := pr.channelDB(id).NewBatch()
-	peerdb := sortedkv.NewTable(pr.db, prefix.PeerDB).NewBatch()
-	// All keys a channel has.
-	params, err := pr.getParamsForChan(id)
+	batch := pr.db.NewBatch()
+	peerdb := sortedkv.NewTableBatch(batch, prefix.PeerDB)
+	peers, err := pr.peersForChan(id)
 	if err != nil {
-		return err
+		return errors.WithMessage(err, "retrieving peers for channel")
 	}
-	keys := append([]string{"current", "index", "params", "peers", "phase", "staging:state"},
-		sigKeys(len(params.Parts))...)
-
-	for _, key := range keys {
-		if err := db.Delete(key); err != nil {
-			return errors.WithMessage(err, "batch deletion of "+key)
+	if pr.archive != nil {
+		params, err := pr.getParamsForChan(id)
+		if err != nil {
+			return err
+		}
+		if err := pr.archiveChannel(batch, id, &params, peers); err != nil {
+			return errors.WithMessage(err, "archiving channel")
 		}
 	}
 
-	peers, err := pr.peersForChan(id)
-	if err != nil {
-		return errors.WithMessage(err, "retrieving peers for channel")
+	if err := batch.DeletePrefix(channelPrefix(id)); err != nil {
+		return errors.WithMessage(err, "deleting channel")
 	}
 	for _, peer := range peers {
 		key, err := peerChannelKey(peer, id)
@@ -99,34 +99,41 @@ func (pr *PersistRestorer) ChannelRemoved(_ context.Context, id channel.ID) erro
 		}
 	}
 
-	if err := db.Apply(); err != nil {
-		return errors.WithMessage(err, "applying channel batch")
+	if err := batch.Apply(); err != nil {
+		return errors.WithMessage(err, "applying batch")
 	}
-	return errors.WithMessage(peerdb.Apply(), "applying peer batch")
+	if pr.archive != nil {
+		pr.archived()
+	}
+	return nil
 }
 
 // peersForChan returns a slice of peer addresses for a given channel id from
 // the db of PersistRestorer.
 func (pr *PersistRestorer) peersForChan(id channel.ID) ([]wire.Address, error) {
 	var ps wire.AddressesWithLen
-	peers, err := pr.channelDB(id).Get(prefix.Peers)
+	peers, err := pr.channelDB(id).GetBytes(prefix.Peers)
 	if err != nil {
 		return nil, errors.WithMessage(err, "unable to get peerlist from db")
 	}
-	return []wire.Address(ps), errors.WithMessage(perunio.Decode(bytes.NewBuffer([]byte(peers)), &ps),
-		"decoding peerlist")
+	if err := perunio.Decode(bytes.NewBuffer(peers), &ps); err != nil {
+		return nil, errors.WithMessage(err, "decoding peerlist")
+	}
+	return []wire.Address(ps), nil
 }
 
 // getParamsForChan returns the channel parameters for a given channel id from
 // the db.
 func (pr *PersistRestorer) getParamsForChan(id channel.ID) (channel.Params, error) {
-	params := channel.Params{}
 	b, err := pr.channelDB(id).GetBytes("params")
 	if err != nil {
-		return params, errors.WithMessage(err, "unable to retrieve params from db")
+		return channel.Params{}, errors.WithMessage(err, "unable to retrieve params from db")
+	}
+	params, err := pr.backends.DecodeParams(bytes.NewBuffer(b))
+	if err != nil {
+		return channel.Params{}, errors.WithMessage(err, "unable to decode channel parameters")
 	}
-	return params, errors.WithMessage(perunio.Decode(bytes.NewBuffer(b), &params),
-		"unable to decode channel parameters")
+	return *params, nil
 }
 
 // sigKeys generates all db keys for signatures and returns them as a
@@ -140,11 +147,15 @@ func sigKeys(numParts int) []string {
 	return keys
 }
 
-// Staged persists the staging transaction as well as the channel's phase.
+// Staged persists the staging transaction as well as the channel's phase. The
+// signatures are overwritten as well, so that no signatures of a previously
+// staged or discarded state remain.
 func (pr *PersistRestorer) Staged(_ context.Context, s channel.Source) error {
 	db := pr.channelDB(s.ID()).NewBatch()
 
-	if err := dbPutSource(db, s, "staging:state", "phase"); err != nil {
+	numParts := len(s.Params().Parts)
+	keys := append([]string{"staging:state", "phase"}, sigKeys(numParts)...)
+	if err := dbPutSource(db, s, keys...); err != nil {
 		return err
 	}
 
@@ -178,7 +189,15 @@ func (pr *PersistRestorer) Enabled(_ context.Context, s channel.Source) error {
 
 // PhaseChanged persists the channel's phase.
 func (pr *PersistRestorer) PhaseChanged(_ context.Context, s channel.Source) error {
-	return dbPut(pr.channelDB(s.ID()), "phase", s.Phase())
+	batch := pr.db.NewBatch()
+
+	if err := dbPutSource(channelBatch(batch, s.ID()), s, "phase"); err != nil {
+		return err
+	}
+	if err := pr.putHistory(batch, s); err != nil {
+		return err
+	}
+	return errors.WithMessage(batch.Apply(), "applying batch")
 }
 
 func dbPutSource(db sortedkv.Writer, s channel.Source, keys ...string) error {
@@ -198,13 +217,11 @@ func dbPutSourceField(db sortedkv.Writer, s channel.Source, key string) error {
 		return dbPut(db, key, s.Idx())
 	case "params":
 		return dbPut(db, key, s.Params())
-	case "peers":
-		return dbPut(db, key, wire.AddressesWithLen(s.Params().Parts))
 	case "phase":
 		return dbPut(db, key, s.Phase())
 	case "staging:state":
-		stagingState := s.StagingTX().State
-		return dbPut(db, key, PersistedState{&stagingState})
+		stagingTX := s.StagingTX()
+		return dbPut(db, key, persistedStaging{TX: &stagingTX})
 	}
 	if idx, ok := sigKeyIndex(key); ok {
 		tx := s.StagingTX()
@@ -229,6 +246,26 @@ func dbPut(db sortedkv.Writer, key string, v interface{}) error {
 	return nil
 }
 
+// dbGet reads and decodes the value of key from a database.
+func dbGet(db sortedkv.Reader, key string, v interface{}) error {
+	b, err := db.GetBytes(key)
+	if err != nil {
+		return errors.WithMessage(err, "getting "+key)
+	}
+	return errors.WithMessage(perunio.Decode(bytes.NewReader(b), v), "decoding "+key)
+}
+
+// dbGetOpt is like dbGet but leaves v unchanged if key is not present. It
+// returns whether key was present.
+func dbGetOpt(db sortedkv.Reader, key string, v interface{}) (bool, error) {
+	if has, err := db.Has(key); err != nil {
+		return false, errors.WithMessage(err, "checking "+key)
+	} else if !has {
+		return false, nil
+	}
+	return true, dbGet(db, key, v)
+}
+
 var sigRegex = regexp.MustCompile(`^` + prefix.SigKey + `\d+$`)
 
 func sigKeyIndex(key string) (int, bool) {
@@ -264,5 +301,14 @@ func peerChannelKey(p wire.Address, ch channel.ID) (string, error) {
 
 // channelDB creates a prefixed database for persisting a channel's data.
 func (pr *PersistRestorer) channelDB(id channel.ID) sortedkv.Database {
-	return sortedkv.NewTable(pr.db, prefix.ChannelDB+string(id[:])+":")
+	return sortedkv.NewTable(pr.db, channelPrefix(id))
+}
+
+// channelBatch creates a prefixed view on batch for writing a channel's data.
+func channelBatch(batch sortedkv.Batch, id channel.ID) sortedkv.Batch {
+	return sortedkv.NewTableBatch(batch, channelPrefix(id))
+}
+
+func channelPrefix(id channel.ID) string {
+	return prefix.ChannelDB + string(id[:]) + ":"
 }
@@ -15,16 +15,26 @@
 package keyvalue
 
 import (
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
 	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/pkg/sortedkv"
 )
 
-var _ persistence.PersistRestorer = (*PersistRestorer)(nil)
+var (
+	_ persistence.PersistRestorer = (*PersistRestorer)(nil)
+	_ persistence.Syncer          = (*PersistRestorer)(nil)
+	_ persistence.Snapshotter     = (*PersistRestorer)(nil)
+)
 
 // PersistRestorer implements both the persister and the restorer interface
 // using a sorted key-value store.
 type PersistRestorer struct {
-	db sortedkv.Database
+	db       sortedkv.Database
+	backends channel.Backends
+	archive  *archiveConfig    // nil if archive mode is disabled.
+	view     sortedkv.Snapshot // set for Snapshot views.
 }
 
 // Close closes the PersistRestorer and releases all resources it holds.
@@ -35,16 +45,41 @@ func (pr *PersistRestorer) Close() error {
 	return nil
 }
 
+// Sync flushes all writes to stable storage if the database is a
+// sortedkv.Syncer. Otherwise, it is a noop.
+func (pr *PersistRestorer) Sync() error {
+	if db, ok := pr.db.(sortedkv.Syncer); ok {
+		return errors.WithMessage(db.Sync(), "syncing database")
+	}
+	return nil
+}
+
 // NewPersistRestorer creates a new PersistRestorer for the supplied database.
-func NewPersistRestorer(db sortedkv.Database) *PersistRestorer {
+// The database is migrated to the latest SchemaVersion.
+func NewPersistRestorer(db sortedkv.Database) (*PersistRestorer, error) {
+	if err := migrate(db); err != nil {
+		return nil, errors.WithMessage(err, "migrating database")
+	}
 	return &PersistRestorer{
 		db: db,
-	}
+	}, nil
+}
+
+// SetBackends sets the backends that are used for decoding restored channels.
+// By default, the global backends are used. This method is expected to be
+// called once during the setup of the PersistRestorer and is hence not
+// thread-safe.
+func (pr *PersistRestorer) SetBackends(b channel.Backends) {
+	pr.backends = b
 }
 
-var prefix = struct{ ChannelDB, PeerDB, SigKey, Peers string }{
-	ChannelDB: "Chan:",
-	PeerDB:    "Peer:",
-	SigKey:    "staging:sig:",
-	Peers:     "peers",
+var prefix = struct{ ChannelDB, PeerDB, HistoryDB, ArchiveDB, ArchiveIndexDB, SigKey, Peers, Version string }{
+	ChannelDB:      "Chan:",
+	PeerDB:         "Peer:",
+	HistoryDB:      "Hist:",
+	ArchiveDB:      "Arch:",
+	ArchiveIndexDB: "ArIdx:",
+	SigKey:         "staging:sig:",
+	Peers:          "peers",
+	Version:        "SchemaVersion",
 }
@@ -15,19 +15,27 @@
 package keyvalue
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	_ "perun.network/go-perun/backend/sim"
+	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/channel/persistence/test"
 	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/bolt"
+	"perun.network/go-perun/pkg/sortedkv/encrypted"
 	"perun.network/go-perun/pkg/sortedkv/leveldb"
 	"perun.network/go-perun/pkg/sortedkv/memorydb"
 	pkgtest "perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
 )
 
 func TestPersistRestorer_Generic(t *testing.T) {
@@ -35,16 +43,25 @@ func TestPersistRestorer_Generic(t *testing.T) {
 	require.NoError(t, err)
 	lvldb, err := leveldb.LoadDatabase(tmpdir)
 	require.NoError(t, err)
+	boltdb, err := bolt.LoadDatabase(filepath.Join(tmpdir, "bolt.db"))
+	require.NoError(t, err)
+
+	encdb, err := encrypted.NewDatabase(memorydb.NewDatabase(),
+		encrypted.Key{ID: 1, Secret: []byte("perun-test-secret")})
+	require.NoError(t, err)
 
 	dbs := []sortedkv.Database{
 		lvldb,
+		boltdb,
 		memorydb.NewDatabase(),
+		encdb,
 	}
 
 	for i, db := range dbs {
 		func(i int64) {
 			defer func() { require.NoError(t, db.Close()) }()
-			pr := NewPersistRestorer(db)
+			pr, err := NewPersistRestorer(db)
+			require.NoError(t, err)
 			rng := pkgtest.Prng(t, i)
 			test.GenericPersistRestorerTest(
 				context.Background(),
@@ -64,3 +81,119 @@ func TestChannelIterator_Next_Empty(t *testing.T) {
 	assert.False(t, success)
 	assert.NoError(t, it.err)
 }
+
+// snapshotCounter counts the snapshots taken and released on a database.
+type snapshotCounter struct {
+	sortedkv.Database
+	taken, released int
+}
+
+func (db *snapshotCounter) Snapshot() (sortedkv.Snapshot, error) {
+	s, err := db.Database.(sortedkv.Snapshotter).Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	db.taken++
+	return &countedSnapshot{s, db}, nil
+}
+
+type countedSnapshot struct {
+	sortedkv.Snapshot
+	db *snapshotCounter
+}
+
+func (s *countedSnapshot) Release() {
+	s.db.released++
+	s.Snapshot.Release()
+}
+
+func TestPersistRestorer_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	db := &snapshotCounter{Database: memorydb.NewDatabase()}
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+
+	c := test.NewClient(ctx, t, rng, pr)
+	peer := wtest.NewRandomAddress(rng)
+	var chans []*test.Channel
+	for i := 0; i < 2; i++ {
+		ch := c.NewChannel(t, peer)
+		ch.Init(t, rng)
+		chans = append(chans, ch)
+	}
+
+	for name, restore := range map[string]func() (persistence.ChannelIterator, error){
+		"RestoreAll":  pr.RestoreAll,
+		"RestorePeer": func() (persistence.ChannelIterator, error) { return pr.RestorePeer(peer) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			it, err := restore()
+			require.NoError(t, err)
+			require.Equal(t, db.taken, db.released+1)
+
+			// Channels that are removed during the iteration are still restored
+			// completely from the snapshot.
+			for _, ch := range chans {
+				require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+			}
+			restored := 0
+			for it.Next(ctx) {
+				restored++
+			}
+			require.NoError(t, it.Close())
+			assert.Equal(t, len(chans), restored)
+			assert.Equal(t, db.taken, db.released)
+
+			for _, ch := range chans {
+				require.NoError(t, pr.ChannelCreated(ctx, ch, []wire.Address{peer}))
+			}
+		})
+	}
+
+	_, err = pr.RestoreChannel(ctx, chans[0].ID())
+	require.NoError(t, err)
+	assert.Equal(t, db.taken, db.released)
+
+	t.Run("Snapshot", func(t *testing.T) {
+		snap, err := pr.Snapshot()
+		require.NoError(t, err)
+		for _, ch := range chans {
+			require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+		}
+
+		// The snapshot restores the removed channels.
+		peers, err := snap.ActivePeers(ctx)
+		require.NoError(t, err)
+		assert.NotEmpty(t, peers)
+		for _, ch := range chans {
+			restored, err := snap.RestoreChannel(ctx, ch.ID())
+			require.NoError(t, err)
+			ch.RequireEqual(t, restored)
+		}
+		assert.Equal(t, db.taken, db.released+1)
+		require.NoError(t, snap.Close())
+		assert.Equal(t, db.taken, db.released)
+
+		var archive bytes.Buffer
+		n, err := persistence.ExportArchive(ctx, &archive, pr)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Equal(t, db.taken, db.released)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		encdb, err := encrypted.NewDatabase(memorydb.NewDatabase(),
+			encrypted.Key{ID: 1, Secret: []byte("perun-test-secret")})
+		require.NoError(t, err)
+		pr, err := NewPersistRestorer(encdb)
+		require.NoError(t, err)
+		_, err = pr.Snapshot()
+		assert.True(t, errors.Is(err, persistence.ErrSnapshotUnsupported))
+
+		// Archives are still exported channel by channel.
+		var archive bytes.Buffer
+		_, err = persistence.ExportArchive(ctx, &archive, pr)
+		assert.NoError(t, err)
+	})
+}
@@ -34,16 +34,22 @@ var _ persistence.ChannelIterator = (*ChannelIterator)(nil)
 
 // ChannelIterator implements the persistence.ChannelIterator interface.
 type ChannelIterator struct {
-	err error
-	ch  *persistence.Channel
-	its []sortedkv.Iterator
+	err  error
+	ch   *persistence.Channel
+	its  []sortedkv.Iterator
+	snap sortedkv.Snapshot
 
 	restorer *PersistRestorer
 }
 
 // ActivePeers returns a list of all peers with which a channel is persisted.
 func (pr *PersistRestorer) ActivePeers(context.Context) ([]wire.Address, error) {
-	it := sortedkv.NewTable(pr.db, prefix.PeerDB).NewIterator()
+	snap, err := pr.snapshot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "taking snapshot")
+	}
+	defer snap.Release()
+	it := sortedkv.NewSnapshotTable(snap, prefix.PeerDB).NewIterator()
 
 	peermap := make(map[wallet.AddrKey]wire.Address)
 	for it.Next() {
@@ -63,28 +69,39 @@ func (pr *PersistRestorer) ActivePeers(context.Context) ([]wire.Address, error)
 
 // RestoreAll should return an iterator over all persisted channels.
 func (pr *PersistRestorer) RestoreAll() (persistence.ChannelIterator, error) {
+	snap, err := pr.snapshot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "taking snapshot")
+	}
 	return &ChannelIterator{
 		restorer: pr,
-		its:      []sortedkv.Iterator{sortedkv.NewTable(pr.db, prefix.ChannelDB).NewIterator()},
+		its:      []sortedkv.Iterator{sortedkv.NewSnapshotTable(snap, prefix.ChannelDB).NewIterator()},
+		snap:     snap,
 	}, nil
 }
 
 // RestorePeer should return an iterator over all persisted channels which
 // the given peer is a part of.
 func (pr *PersistRestorer) RestorePeer(addr wire.Address) (persistence.ChannelIterator, error) {
-	it := &ChannelIterator{restorer: pr}
-	chandb := sortedkv.NewTable(pr.db, prefix.ChannelDB)
-
 	key, err := peerChannelsKey(addr)
 	if err != nil {
 		return nil, errors.WithMessage(err, "restoring peer")
 	}
-	itPeer := sortedkv.NewTable(pr.db, prefix.PeerDB+key).NewIterator()
+
+	snap, err := pr.snapshot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "taking snapshot")
+	}
+	it := &ChannelIterator{restorer: pr, snap: snap}
+	chandb := sortedkv.NewSnapshotTable(snap, prefix.ChannelDB)
+
+	itPeer := sortedkv.NewSnapshotTable(snap, prefix.PeerDB+key).NewIterator()
 	defer itPeer.Close() // nolint: errcheck
 
 	var id channel.ID
 	for itPeer.Next() {
 		if err := perunio.Decode(bytes.NewBufferString(itPeer.Key()), &id); err != nil {
+			it.Close() // nolint: errcheck
 			return nil, errors.WithMessage(err, "decode channel id")
 		}
 		it.its = append(it.its, chandb.NewIteratorWithPrefix(string(id[:])))
@@ -93,6 +110,79 @@ func (pr *PersistRestorer) RestorePeer(addr wire.Address) (persistence.ChannelIt
 	return it, nil
 }
 
+// Snapshot returns a Restorer that restores the channels as they are persisted
+// at the time of the call. The database must be a sortedkv.Snapshotter.
+func (pr *PersistRestorer) Snapshot() (persistence.RestorerSnapshot, error) {
+	db, ok := pr.db.(sortedkv.Snapshotter)
+	if !ok {
+		return nil, errors.WithMessagef(persistence.ErrSnapshotUnsupported, "database %T", pr.db)
+	}
+	snap, err := db.Snapshot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "taking snapshot")
+	}
+	view := *pr
+	view.view = snap
+	return &restorerSnapshot{pr: &view}, nil
+}
+
+// restorerSnapshot is a Restorer on a fixed database snapshot.
+type restorerSnapshot struct {
+	pr *PersistRestorer
+}
+
+// ActivePeers returns the peers of the snapshot.
+func (s *restorerSnapshot) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	return s.pr.ActivePeers(ctx)
+}
+
+// RestorePeer restores the channels of a peer from the snapshot.
+func (s *restorerSnapshot) RestorePeer(addr wire.Address) (persistence.ChannelIterator, error) {
+	return s.pr.RestorePeer(addr)
+}
+
+// RestoreChannel restores a channel from the snapshot.
+func (s *restorerSnapshot) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	return s.pr.RestoreChannel(ctx, id)
+}
+
+// Close releases the snapshot.
+func (s *restorerSnapshot) Close() error {
+	s.pr.view.Release()
+	return nil
+}
+
+// snapshot returns a consistent read view of the database, so that channels
+// are not restored torn by concurrent writes. If the PersistRestorer is a
+// snapshot view, its snapshot is used and not released by the caller. If the
+// database does not support snapshots, the view reads from the database
+// directly.
+func (pr *PersistRestorer) snapshot() (sortedkv.Snapshot, error) {
+	if pr.view != nil {
+		return unreleased{pr.view}, nil
+	}
+	if db, ok := pr.db.(sortedkv.Snapshotter); ok {
+		return db.Snapshot()
+	}
+	return liveView{pr.db}, nil
+}
+
+// liveView is a Snapshot that reads from the database directly.
+type liveView struct {
+	sortedkv.Database
+}
+
+// Release is a noop.
+func (liveView) Release() {}
+
+// unreleased is a Snapshot whose Release is a noop, so that it can be shared.
+type unreleased struct {
+	sortedkv.Snapshot
+}
+
+// Release is a noop.
+func (unreleased) Release() {}
+
 // peerChannelsKey creates a db-key-string for a given wire.Address.
 // nolint: interfacer
 func peerChannelsKey(addr wire.Address) (string, error) {
@@ -106,10 +196,15 @@ func peerChannelsKey(addr wire.Address) (string, error) {
 
 // RestoreChannel restores a single channel.
 func (pr *PersistRestorer) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
-	chandb := sortedkv.NewTable(pr.db, prefix.ChannelDB)
+	snap, err := pr.snapshot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "taking snapshot")
+	}
+	chandb := sortedkv.NewSnapshotTable(snap, prefix.ChannelDB)
 	it := &ChannelIterator{
 		restorer: pr,
 		its:      []sortedkv.Iterator{chandb.NewIteratorWithPrefix(string(id[:]))},
+		snap:     snap,
 	}
 
 	if it.Next(ctx) {
@@ -122,7 +217,6 @@ func (pr *PersistRestorer) RestoreChannel(ctx context.Context, id channel.ID) (*
 }
 
 // decodePeerChanID decodes the channel.ID and peer.Address from a key.
-// nolint: deadcode, unused
 func decodePeerChanID(key string) (wire.Address, channel.ID, error) {
 	buf := bytes.NewBufferString(key)
 	addr, err := wire.DecodeAddress(buf)
@@ -140,7 +234,6 @@ func decodePeerChanID(key string) (wire.Address, channel.ID, error) {
 
 // eatExpect consumes bytes from a Reader and asserts that they are equal to
 // the expected string.
-// nolint: deadcode, unused
 func eatExpect(r io.Reader, tok string) error {
 	buf := make([]byte, len(tok))
 	if _, err := io.ReadFull(r, buf); err != nil {
@@ -173,23 +266,40 @@ func (i *ChannelIterator) Next(context.Context) bool {
 		return false
 	}
 
-	i.ch = &persistence.Channel{ParamsV: new(channel.Params)}
+	i.ch = new(persistence.Channel)
+	b := i.restorer.backends
 
-	if !i.decodeNext("current", &i.ch.CurrentTXV, allowEnd) ||
+	if !i.decodeNext("current", decoderFunc(func(r io.Reader) (err error) {
+		i.ch.CurrentTXV, err = b.DecodeTransaction(r)
+		return
+	}), allowEnd) ||
 		!i.decodeNext("index", &i.ch.IdxV, noOpts) ||
-		!i.decodeNext("params", i.ch.ParamsV, noOpts) ||
+		!i.decodeNext("params", decoderFunc(func(r io.Reader) (err error) {
+			i.ch.ParamsV, err = b.DecodeParams(r)
+			return
+		}), noOpts) ||
 		!i.decodeNext("peers", nil, skip) ||
 		!i.decodeNext("phase", &i.ch.PhaseV, noOpts) {
 		return false
 	}
 	i.ch.StagingTXV.Sigs = make([]wallet.Sig, len(i.ch.ParamsV.Parts))
 	for idx, key := range sigKeys(len(i.ch.ParamsV.Parts)) {
-		i.decodeNext(key, wallet.SigDec{Sig: &i.ch.StagingTXV.Sigs[idx]}, allowEmpty)
+		sig := &i.ch.StagingTXV.Sigs[idx]
+		i.decodeNext(key, decoderFunc(func(r io.Reader) (err error) {
+			*sig, err = b.DecodeSig(r)
+			return
+		}), allowEmpty)
 	}
 
-	return i.decodeNext("staging:state", &PersistedState{&i.ch.StagingTXV.State}, allowEmpty)
+	return i.decodeNext("staging:state", &persistedStaging{TX: &i.ch.StagingTXV, Backends: b}, allowEmpty)
 }
 
+// decoderFunc is an adapter to use a function as perunio.Decoder.
+type decoderFunc func(io.Reader) error
+
+// Decode calls the decoder function.
+func (f decoderFunc) Decode(r io.Reader) error { return f(r) }
+
 // recoverFromEmptyIterator is called when there is no iterator or when the
 // current iterator just ended. allowEnd signifies whether this situation is
 // allowed. It returns whether it could recover a new iterator.
@@ -257,6 +367,10 @@ func (i *ChannelIterator) Close() error {
 		}
 	}
 	i.its = nil
+	if i.snap != nil {
+		i.snap.Release()
+		i.snap = nil
+	}
 
 	return i.err
 }
@@ -0,0 +1,137 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyvalue
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/wire"
+)
+
+// SchemaVersion is the version of the key layout and encodings that the
+// PersistRestorer writes. It is stored under the key "SchemaVersion".
+// Databases without version record have version 0.
+//
+// The versions are
+//  - 0: unversioned layout; the "peers" key of a channel may contain the
+//    channel participants instead of the network peers,
+//  - 1: the "peers" key contains the network peers of the channel.
+var SchemaVersion = uint32(len(migrations))
+
+// A migration upgrades a database by one version. It reads from db and
+// writes all changes to batch, which is applied together with the new
+// version record.
+type migration func(db sortedkv.Database, batch sortedkv.Batch) error
+
+// migrations[i] upgrades a database from version i to i+1. Existing
+// migrations must never be changed; new ones are appended.
+var migrations = []migration{
+	migratePeers,
+}
+
+// migrate upgrades db step by step to SchemaVersion. An empty database is
+// initialized with the current version.
+func migrate(db sortedkv.Database) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return errors.Errorf("database schema version %d is newer than supported version %d",
+			version, SchemaVersion)
+	}
+	if version == 0 {
+		if empty, err := isEmpty(db); err != nil {
+			return err
+		} else if empty {
+			return dbPut(db, prefix.Version, SchemaVersion)
+		}
+	}
+
+	for ; version < SchemaVersion; version++ {
+		batch := db.NewBatch()
+		if err := migrations[version](db, batch); err != nil {
+			return errors.WithMessagef(err, "migrating to version %d", version+1)
+		}
+		if err := dbPut(batch, prefix.Version, version+1); err != nil {
+			return err
+		}
+		if err := batch.Apply(); err != nil {
+			return errors.WithMessagef(err, "applying migration to version %d", version+1)
+		}
+	}
+	return nil
+}
+
+// schemaVersion reads the schema version of db.
+func schemaVersion(db sortedkv.Database) (uint32, error) {
+	has, err := db.Has(prefix.Version)
+	if err != nil {
+		return 0, errors.WithMessage(err, "reading schema version")
+	} else if !has {
+		return 0, nil
+	}
+
+	b, err := db.GetBytes(prefix.Version)
+	if err != nil {
+		return 0, errors.WithMessage(err, "reading schema version")
+	}
+	var version uint32
+	return version, errors.WithMessage(perunio.Decode(bytes.NewReader(b), &version), "decoding schema version")
+}
+
+func isEmpty(db sortedkv.Database) (bool, error) {
+	it := db.NewIterator()
+	empty := !it.Next()
+	return empty, errors.WithMessage(it.Close(), "closing iterator")
+}
+
+// migratePeers rewrites the "peers" key of every channel with the peers of
+// the channel in the peer table, ordered by their encoding.
+func migratePeers(db sortedkv.Database, batch sortedkv.Batch) error {
+	peers := make(map[channel.ID]wire.AddressesWithLen)
+	it := sortedkv.NewTable(db, prefix.PeerDB).NewIterator()
+	for it.Next() {
+		addr, id, err := decodePeerChanID(it.Key())
+		if err != nil {
+			it.Close() // nolint: errcheck
+			return errors.WithMessage(err, "decoding peer channel key")
+		}
+		peers[id] = append(peers[id], addr)
+	}
+	if err := it.Close(); err != nil {
+		return errors.WithMessage(err, "iterating peer table")
+	}
+
+	var id channel.ID
+	it = sortedkv.NewTable(db, prefix.ChannelDB).NewIterator()
+	for it.Next() {
+		// Channel keys have the form "<id>:<name>".
+		if len(it.Key()) != len(id)+1+len(prefix.Peers) || it.Key()[len(id)+1:] != prefix.Peers {
+			continue
+		}
+		copy(id[:], it.Key())
+		if err := dbPut(channelBatch(batch, id), prefix.Peers, peers[id]); err != nil {
+			it.Close() // nolint: errcheck
+			return err
+		}
+	}
+	return errors.WithMessage(it.Close(), "iterating channel table")
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyvalue
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	"perun.network/go-perun/wire"
+)
+
+// TestMigrate_V0 migrates a fixture database of the unversioned layout. It
+// contains three 2-party channels: one only created, one funded and one
+// funded with a staged update. The "peers" keys contain the participants.
+func TestMigrate_V0(t *testing.T) {
+	ctx := context.Background()
+	data := loadFixture(t, "testdata/v0.json")
+	pr, err := NewPersistRestorer(memorydb.FromData(data))
+	require.NoError(t, err)
+	requireVersion(t, pr.db, SchemaVersion)
+
+	it, err := pr.RestoreAll()
+	require.NoError(t, err)
+	var chans []*persistence.Channel
+	for it.Next(ctx) {
+		chans = append(chans, it.Channel())
+	}
+	require.NoError(t, it.Close())
+	require.Len(t, chans, 3)
+
+	var staged int
+	for _, ch := range chans {
+		peers, err := pr.peersForChan(ch.ID())
+		require.NoError(t, err)
+		require.Len(t, peers, 2, "peers migrated from the peer table")
+		for _, p := range peers {
+			requirePeerChannel(t, pr, p, ch.ID())
+		}
+
+		if ch.CurrentTXV.State != nil {
+			assert.NoError(t, channel.Backends{}.VerifyTx(ch.ParamsV, ch.CurrentTXV))
+		}
+		if ch.StagingTXV.State != nil {
+			staged++
+		}
+	}
+	assert.Equal(t, 1, staged)
+
+	// Migrated channels can be removed without leftovers.
+	for _, ch := range chans {
+		require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+	}
+	assert.Equal(t, []string{prefix.Version}, keys(data))
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		db := memorydb.NewDatabase()
+		_, err := NewPersistRestorer(db)
+		require.NoError(t, err)
+		requireVersion(t, db, SchemaVersion)
+
+		// Reopening is a noop.
+		_, err = NewPersistRestorer(db)
+		require.NoError(t, err)
+		requireVersion(t, db, SchemaVersion)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		db := memorydb.NewDatabase()
+		require.NoError(t, dbPut(db, prefix.Version, SchemaVersion+1))
+		_, err := NewPersistRestorer(db)
+		assert.Error(t, err)
+	})
+}
+
+func requireVersion(t *testing.T, db sortedkv.Database, version uint32) {
+	b, err := db.GetBytes(prefix.Version)
+	require.NoError(t, err)
+	var v uint32
+	require.NoError(t, perunio.Decode(bytes.NewReader(b), &v))
+	require.Equal(t, version, v)
+}
+
+func requirePeerChannel(t *testing.T, pr *PersistRestorer, p wire.Address, id channel.ID) {
+	it, err := pr.RestorePeer(p)
+	require.NoError(t, err)
+	defer it.Close()
+	for it.Next(context.Background()) {
+		if it.Channel().ID() == id {
+			return
+		}
+	}
+	t.Errorf("channel %x not found for peer %v", id, p)
+}
+
+// loadFixture loads a database fixture, a JSON object of hex-encoded keys
+// and values.
+func loadFixture(t *testing.T, file string) map[string]string {
+	b, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	var fixture map[string]string
+	require.NoError(t, json.Unmarshal(b, &fixture))
+
+	data := make(map[string]string, len(fixture))
+	for k, v := range fixture {
+		key, err := hex.DecodeString(k)
+		require.NoError(t, err)
+		value, err := hex.DecodeString(v)
+		require.NoError(t, err)
+		data[string(key)] = string(value)
+	}
+	return data
+}
+
+func keys(data map[string]string) []string {
+	ks := make([]string, 0, len(data))
+	for k := range data {
+		ks = append(ks, k)
+	}
+	return ks
+}
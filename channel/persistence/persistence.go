@@ -18,6 +18,8 @@ import (
 	"context"
 	"io"
 
+	"github.com/pkg/errors"
+
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/wire"
 )
@@ -44,7 +46,8 @@ type (
 		// Staged is called when a new valid state got set as the new staging
 		// state. It may already contain one valid signature, either by a remote
 		// peer or us locally. Hence, this only needs to persist a channel's staged
-		// state, all its currently known signatures and the phase.
+		// state, all its currently known signatures, the request ID of the
+		// staging transaction and the phase.
 		Staged(context.Context, channel.Source) error
 
 		// SigAdded is called when a new signature is added to the current staging
@@ -81,6 +84,22 @@ type (
 		RestoreChannel(context.Context, channel.ID) (*Channel, error)
 	}
 
+	// A Snapshotter is a Restorer that can restore its data as it was at a
+	// single point in time, although it is written concurrently.
+	Snapshotter interface {
+		// Snapshot returns a RestorerSnapshot of the current data. It returns
+		// an error wrapping ErrSnapshotUnsupported if the underlying data
+		// source cannot take snapshots.
+		Snapshot() (RestorerSnapshot, error)
+	}
+
+	// A RestorerSnapshot is a Restorer that restores the data as it was when
+	// it was taken. Close releases the snapshot.
+	RestorerSnapshot interface {
+		Restorer
+		io.Closer
+	}
+
 	// PersistRestorer is a Persister and Restorer on the same data source and
 	// data sink.
 	PersistRestorer interface {
@@ -109,6 +128,16 @@ type (
 		io.Closer
 	}
 
+	// A RegistrationSource is a channel.Source that also knows the channel's
+	// last registration on the adjudicator, like the channel.StateMachine.
+	// Persisters may check for it in PhaseChanged to persist the details of a
+	// registration.
+	RegistrationSource interface {
+		channel.Source
+		// Registered returns the last registered event, or nil.
+		Registered() *channel.RegisteredEvent
+	}
+
 	// A Channel holds all data that is necessary for restoring a channel
 	// controller.
 	Channel struct {
@@ -120,7 +149,14 @@ type (
 	}
 )
 
-var _ channel.Source = (*Channel)(nil)
+var (
+	_ channel.Source     = (*Channel)(nil)
+	_ RegistrationSource = (*channel.StateMachine)(nil)
+)
+
+// ErrSnapshotUnsupported is returned by Snapshotters whose data source cannot
+// take snapshots.
+var ErrSnapshotUnsupported = errors.New("snapshots not supported")
 
 // CloneSource creates a new Channel object whose fields are clones of the data
 // coming from Source s.
@@ -0,0 +1,28 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sql contains an implementation of the channel persister and restorer
+// interfaces on top of database/sql. Channels, their peers, transactions and
+// signatures are stored in a normalized schema, which is created and migrated
+// by NewPersistRestorer. Queries use `?` placeholders and standard SQL, so any
+// driver with these conventions can be used, e.g., SQLite.
+//
+// States are stored as encoded blobs, from which channels are restored. For
+// querying with standard tooling, the version and finality of each state are
+// also stored in the transactions table, and its allocation in the assets,
+// balances and locked_balances tables. Balances are decimal strings, because
+// they may exceed the range of SQL integers. The columns of transactions that
+// were stored with schema version 1 are NULL until the transaction is stored
+// again.
+package sql // import "perun.network/go-perun/channel/persistence/sql"
@@ -0,0 +1,242 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wire"
+)
+
+// txKind distinguishes the current and staging transaction of a channel in
+// the transactions and signatures tables.
+type txKind uint8
+
+const (
+	currentTX txKind = iota
+	stagingTX
+)
+
+// ChannelCreated inserts a channel into the database.
+func (pr *PersistRestorer) ChannelCreated(ctx context.Context, s channel.Source, peers []wire.Address) error {
+	params, err := encode(s.Params())
+	if err != nil {
+		return errors.WithMessage(err, "encoding params")
+	}
+	id := s.ID()
+
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO channels (id, idx, params, phase) VALUES (?, ?, ?, ?)`,
+			id[:], s.Idx(), params, s.Phase()); err != nil {
+			return errors.WithMessage(err, "inserting channel")
+		}
+		for i, peer := range peers {
+			addr, err := encode(peer)
+			if err != nil {
+				return errors.WithMessage(err, "encoding peer")
+			}
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO peers (channel_id, position, peer) VALUES (?, ?, ?)`,
+				id[:], i, addr); err != nil {
+				return errors.WithMessage(err, "inserting peer")
+			}
+		}
+		if err := putTx(ctx, tx, id, currentTX, s.CurrentTX()); err != nil {
+			return err
+		}
+		return putTx(ctx, tx, id, stagingTX, s.StagingTX())
+	})
+}
+
+// ChannelRemoved deletes a channel from the database.
+func (pr *PersistRestorer) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"locked_balances", "balances", "assets", "signatures", "transactions", "peers"} {
+			if _, err := tx.ExecContext(ctx,
+				`DELETE FROM `+table+` WHERE channel_id = ?`, id[:]); err != nil {
+				return errors.WithMessage(err, "deleting from "+table)
+			}
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM channels WHERE id = ?`, id[:])
+		if err != nil {
+			return errors.WithMessage(err, "deleting channel")
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return errors.WithMessage(err, "deleting channel")
+		} else if n == 0 {
+			return errors.Errorf("could not find channel %x", id)
+		}
+		return nil
+	})
+}
+
+// Staged persists the staging transaction as well as the channel's phase.
+func (pr *PersistRestorer) Staged(ctx context.Context, s channel.Source) error {
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		if err := putTx(ctx, tx, s.ID(), stagingTX, s.StagingTX()); err != nil {
+			return err
+		}
+		return putPhase(ctx, tx, s)
+	})
+}
+
+// SigAdded persists the signature of the given participant on the staging
+// transaction.
+func (pr *PersistRestorer) SigAdded(ctx context.Context, s channel.Source, idx channel.Index) error {
+	sigs := s.StagingTX().Sigs
+	if int(idx) >= len(sigs) {
+		return errors.Errorf("no signature at index %d", idx)
+	}
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		return putSig(ctx, tx, s.ID(), stagingTX, int(idx), sigs[idx])
+	})
+}
+
+// Enabled persists the channel's staging and current transaction, and phase.
+func (pr *PersistRestorer) Enabled(ctx context.Context, s channel.Source) error {
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		if err := putTx(ctx, tx, s.ID(), currentTX, s.CurrentTX()); err != nil {
+			return err
+		}
+		if err := putTx(ctx, tx, s.ID(), stagingTX, s.StagingTX()); err != nil {
+			return err
+		}
+		return putPhase(ctx, tx, s)
+	})
+}
+
+// PhaseChanged persists the channel's phase.
+func (pr *PersistRestorer) PhaseChanged(ctx context.Context, s channel.Source) error {
+	return pr.inTx(ctx, func(tx *sql.Tx) error {
+		return putPhase(ctx, tx, s)
+	})
+}
+
+func putPhase(ctx context.Context, tx *sql.Tx, s channel.Source) error {
+	id := s.ID()
+	_, err := tx.ExecContext(ctx, `UPDATE channels SET phase = ? WHERE id = ?`, s.Phase(), id[:])
+	return errors.WithMessage(err, "updating phase")
+}
+
+// putTx replaces the transaction of the given kind with its allocation and
+// all its signatures.
+func putTx(ctx context.Context, tx *sql.Tx, id channel.ID, kind txKind, t channel.Transaction) error {
+	for _, table := range []string{"locked_balances", "balances", "assets", "signatures", "transactions"} {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE channel_id = ? AND kind = ?`, id[:], kind); err != nil {
+			return errors.WithMessage(err, "deleting from "+table)
+		}
+	}
+
+	var state, reqID, aggSig []byte
+	var version, isFinal interface{} // NULL without state
+	if t.State != nil {
+		var err error
+		if state, err = encode(t.State); err != nil {
+			return errors.WithMessage(err, "encoding state")
+		}
+		version, isFinal = int64(t.Version), t.IsFinal
+		if err := putAllocation(ctx, tx, id, kind, &t.Allocation); err != nil {
+			return err
+		}
+	}
+	if !t.RequestID.IsZero() {
+		reqID = t.RequestID[:]
+	}
+	if t.AggSig != nil {
+		aggSig = t.AggSig
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO transactions (channel_id, kind, state, request_id, agg_sig, version, is_final) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		id[:], kind, state, reqID, aggSig, version, isFinal); err != nil {
+		return errors.WithMessage(err, "inserting transaction")
+	}
+
+	for i, sig := range t.Sigs {
+		if sig == nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO signatures (channel_id, kind, idx, sig) VALUES (?, ?, ?, ?)`,
+			id[:], kind, i, []byte(sig)); err != nil {
+			return errors.WithMessage(err, "inserting signature")
+		}
+	}
+	return nil
+}
+
+// putAllocation inserts the assets, balances and locked balances of the
+// transaction of the given kind. The caller is expected to have deleted the
+// previous ones.
+func putAllocation(ctx context.Context, tx *sql.Tx, id channel.ID, kind txKind, alloc *channel.Allocation) error {
+	for a, asset := range alloc.Assets {
+		enc, err := encode(asset)
+		if err != nil {
+			return errors.WithMessagef(err, "encoding asset %d", a)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO assets (channel_id, kind, asset_idx, asset) VALUES (?, ?, ?, ?)`,
+			id[:], kind, a, enc); err != nil {
+			return errors.WithMessage(err, "inserting asset")
+		}
+		for i, bal := range alloc.Balances[a] {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO balances (channel_id, kind, asset_idx, idx, balance) VALUES (?, ?, ?, ?, ?)`,
+				id[:], kind, a, i, bal.String()); err != nil {
+				return errors.WithMessage(err, "inserting balance")
+			}
+		}
+	}
+	for _, sub := range alloc.Locked {
+		for a, bal := range sub.Bals {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO locked_balances (channel_id, kind, sub_id, asset_idx, balance) VALUES (?, ?, ?, ?, ?)`,
+				id[:], kind, sub.ID[:], a, bal.String()); err != nil {
+				return errors.WithMessage(err, "inserting locked balance")
+			}
+		}
+	}
+	return nil
+}
+
+// putSig replaces the signature of the given kind and index.
+func putSig(ctx context.Context, tx *sql.Tx, id channel.ID, kind txKind, idx int, sig []byte) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM signatures WHERE channel_id = ? AND kind = ? AND idx = ?`,
+		id[:], kind, idx); err != nil {
+		return errors.WithMessage(err, "deleting signature")
+	}
+	if sig == nil {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO signatures (channel_id, kind, idx, sig) VALUES (?, ?, ?, ?)`,
+		id[:], kind, idx, sig)
+	return errors.WithMessage(err, "inserting signature")
+}
+
+// encode encodes v into a byte slice.
+func encode(v perunio.Encoder) ([]byte, error) {
+	var buf bytes.Buffer
+	err := v.Encode(&buf)
+	return buf.Bytes(), err
+}
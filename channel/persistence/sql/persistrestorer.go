@@ -0,0 +1,70 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+)
+
+var _ persistence.PersistRestorer = (*PersistRestorer)(nil)
+
+// PersistRestorer implements both the persister and the restorer interface
+// using an SQL database.
+type PersistRestorer struct {
+	db       *sql.DB
+	backends channel.Backends
+}
+
+// NewPersistRestorer creates a new PersistRestorer for the supplied database.
+// The database schema is created or migrated to the latest SchemaVersion.
+func NewPersistRestorer(ctx context.Context, db *sql.DB) (*PersistRestorer, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, errors.WithMessage(err, "migrating database")
+	}
+	return &PersistRestorer{db: db}, nil
+}
+
+// SetBackends sets the backends that are used for decoding restored channels.
+// By default, the global backends are used. This method is expected to be
+// called once during the setup of the PersistRestorer and is hence not
+// thread-safe.
+func (pr *PersistRestorer) SetBackends(b channel.Backends) {
+	pr.backends = b
+}
+
+// Close closes the PersistRestorer and its database.
+func (pr *PersistRestorer) Close() error {
+	return pr.db.Close()
+}
+
+// inTx runs f in a database transaction, which is committed if f succeeds and
+// rolled back otherwise.
+func (pr *PersistRestorer) inTx(ctx context.Context, f func(*sql.Tx) error) error {
+	tx, err := pr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "beginning transaction")
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback() // nolint: errcheck
+		return err
+	}
+	return errors.WithMessage(tx.Commit(), "committing transaction")
+}
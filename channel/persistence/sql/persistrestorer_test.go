@@ -0,0 +1,133 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build cgo
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim"
+	"perun.network/go-perun/channel/persistence/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+func TestPersistRestorer_Generic(t *testing.T) {
+	ctx := context.Background()
+	pr, err := NewPersistRestorer(ctx, newDB(t))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericPersistRestorerTest(ctx, t, pkgtest.Prng(t), pr, 4, 16)
+}
+
+func TestPersistRestorer_Queryable(t *testing.T) {
+	ctx := context.Background()
+	rng := pkgtest.Prng(t)
+	db := newDB(t)
+	pr, err := NewPersistRestorer(ctx, db)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	ch := test.NewRandomChannel(ctx, t, pr, 0, wtest.NewRandomAddresses(rng, 2), rng)
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+	id, state := ch.ID(), ch.State()
+
+	var version uint64
+	var isFinal bool
+	require.NoError(t, db.QueryRow(
+		`SELECT version, is_final FROM transactions WHERE channel_id = ? AND kind = ?`,
+		id[:], currentTX).Scan(&version, &isFinal))
+	assert.Equal(t, state.Version, version)
+	assert.Equal(t, state.IsFinal, isFinal)
+
+	for a, asset := range state.Assets {
+		var enc []byte
+		require.NoError(t, db.QueryRow(
+			`SELECT asset FROM assets WHERE channel_id = ? AND kind = ? AND asset_idx = ?`,
+			id[:], currentTX, a).Scan(&enc))
+		expected, err := encode(asset)
+		require.NoError(t, err)
+		assert.Equal(t, expected, enc)
+
+		for i, bal := range state.Balances[a] {
+			var b string
+			require.NoError(t, db.QueryRow(
+				`SELECT balance FROM balances WHERE channel_id = ? AND kind = ? AND asset_idx = ? AND idx = ?`,
+				id[:], currentTX, a, i).Scan(&b))
+			assert.Equal(t, bal.String(), b)
+		}
+	}
+
+	var numLocked int
+	require.NoError(t, db.QueryRow(
+		`SELECT COUNT(*) FROM locked_balances WHERE channel_id = ? AND kind = ?`,
+		id[:], currentTX).Scan(&numLocked))
+	assert.Equal(t, len(state.Locked)*len(state.Assets), numLocked)
+
+	require.NoError(t, pr.ChannelRemoved(ctx, id))
+	for _, table := range []string{"assets", "balances", "locked_balances"} {
+		var n int
+		require.NoError(t, db.QueryRow(
+			`SELECT COUNT(*) FROM `+table+` WHERE channel_id = ?`, id[:]).Scan(&n))
+		assert.Zero(t, n, "rows of removed channel in "+table)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	defer db.Close()
+
+	require.NoError(t, migrate(ctx, db))
+	version, err := schemaVersion(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	// Migrating again is a noop.
+	require.NoError(t, migrate(ctx, db))
+	version, err = schemaVersion(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	_, err = db.Exec(`UPDATE schema_version SET version = ?`, SchemaVersion+1)
+	require.NoError(t, err)
+	assert.Error(t, migrate(ctx, db), "unknown newer schema version")
+}
+
+// newDB opens a fresh SQLite database in a temporary directory. Only one
+// connection is used, so concurrent transactions are serialized.
+func newDB(t *testing.T) *sql.DB {
+	dir, err := ioutil.TempDir("", "perun-test-sqlpersistrestorer-db-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "perun.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	return db
+}
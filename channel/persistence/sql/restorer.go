@@ -0,0 +1,204 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+var _ persistence.ChannelIterator = (*ChannelIterator)(nil)
+
+// ChannelIterator implements the persistence.ChannelIterator interface. It
+// loads the channels of a fixed list of IDs one by one.
+type ChannelIterator struct {
+	err error
+	ch  *persistence.Channel
+	ids []channel.ID
+
+	restorer *PersistRestorer
+}
+
+// ActivePeers returns a list of all peers with which a channel is persisted.
+func (pr *PersistRestorer) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	rows, err := pr.db.QueryContext(ctx, `SELECT DISTINCT peer FROM peers`)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying peers")
+	}
+	defer rows.Close()
+
+	var peers []wire.Address
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, errors.WithMessage(err, "scanning peer")
+		}
+		addr, err := wire.DecodeAddress(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "decoding peer (%x)", b)
+		}
+		peers = append(peers, addr)
+	}
+	return peers, errors.WithMessage(rows.Err(), "iterating peers")
+}
+
+// RestorePeer returns an iterator over all persisted channels which the given
+// peer is a part of.
+func (pr *PersistRestorer) RestorePeer(addr wire.Address) (persistence.ChannelIterator, error) {
+	peer, err := encode(addr)
+	if err != nil {
+		return nil, errors.WithMessage(err, "encoding peer")
+	}
+	rows, err := pr.db.Query(`SELECT DISTINCT channel_id FROM peers WHERE peer = ?`, peer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying peer channels")
+	}
+	defer rows.Close()
+
+	it := &ChannelIterator{restorer: pr}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, errors.WithMessage(err, "scanning channel id")
+		}
+		var id channel.ID
+		if len(b) != len(id) {
+			return nil, errors.Errorf("invalid channel id length %d", len(b))
+		}
+		copy(id[:], b)
+		it.ids = append(it.ids, id)
+	}
+	return it, errors.WithMessage(rows.Err(), "iterating peer channels")
+}
+
+// RestoreChannel restores a single channel.
+func (pr *PersistRestorer) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	var ch *persistence.Channel
+	err := pr.inTx(ctx, func(tx *sql.Tx) (err error) {
+		ch, err = pr.loadChannel(ctx, tx, id)
+		return
+	})
+	if err == sql.ErrNoRows {
+		return nil, errors.Errorf("could not find channel %x", id)
+	}
+	return ch, errors.WithMessagef(err, "restoring channel %x", id)
+}
+
+// Next advances the iterator and returns whether there is another channel.
+func (i *ChannelIterator) Next(ctx context.Context) bool {
+	if i.err != nil || len(i.ids) == 0 {
+		return false
+	}
+	i.ch, i.err = i.restorer.RestoreChannel(ctx, i.ids[0])
+	i.ids = i.ids[1:]
+	return i.err == nil
+}
+
+// Channel returns the iterator's current channel.
+func (i *ChannelIterator) Channel() *persistence.Channel {
+	return i.ch
+}
+
+// Close closes the iterator. It returns the last error that occurred when
+// advancing the iterator.
+func (i *ChannelIterator) Close() error {
+	i.ids = nil
+	return i.err
+}
+
+// loadChannel reads a channel within tx. It returns sql.ErrNoRows if the
+// channel does not exist.
+func (pr *PersistRestorer) loadChannel(ctx context.Context, tx *sql.Tx, id channel.ID) (*persistence.Channel, error) {
+	var params []byte
+	ch := new(persistence.Channel)
+	if err := tx.QueryRowContext(ctx, `SELECT idx, params, phase FROM channels WHERE id = ?`, id[:]).
+		Scan(&ch.IdxV, &params, &ch.PhaseV); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if ch.ParamsV, err = pr.backends.DecodeParams(bytes.NewReader(params)); err != nil {
+		return nil, errors.WithMessage(err, "decoding params")
+	}
+	numParts := len(ch.ParamsV.Parts)
+	if ch.CurrentTXV, err = pr.loadTx(ctx, tx, id, currentTX, numParts); err != nil {
+		return nil, errors.WithMessage(err, "loading current transaction")
+	}
+	if ch.StagingTXV, err = pr.loadTx(ctx, tx, id, stagingTX, numParts); err != nil {
+		return nil, errors.WithMessage(err, "loading staging transaction")
+	}
+	return ch, nil
+}
+
+// loadTx reads the transaction of the given kind and its signatures. A
+// transaction without state has no signatures.
+func (pr *PersistRestorer) loadTx(ctx context.Context, tx *sql.Tx, id channel.ID, kind txKind, numParts int) (
+	t channel.Transaction, err error) {
+	var state, reqID, aggSig []byte
+	if err := tx.QueryRowContext(ctx,
+		`SELECT state, request_id, agg_sig FROM transactions WHERE channel_id = ? AND kind = ?`,
+		id[:], kind).Scan(&state, &reqID, &aggSig); err != nil {
+		return t, errors.WithMessage(err, "querying transaction")
+	}
+	if state == nil {
+		return t, nil
+	}
+
+	if t.State, err = pr.backends.DecodeState(bytes.NewReader(state)); err != nil {
+		return t, errors.WithMessage(err, "decoding state")
+	}
+	if reqID != nil {
+		if len(reqID) != len(t.RequestID) {
+			return t, errors.Errorf("invalid request ID length %d", len(reqID))
+		}
+		copy(t.RequestID[:], reqID)
+	}
+	if aggSig != nil {
+		t.AggSig = aggSig
+	}
+	t.Sigs, err = loadSigs(ctx, tx, id, kind, numParts)
+	return t, err
+}
+
+func loadSigs(ctx context.Context, tx *sql.Tx, id channel.ID, kind txKind, numParts int) ([]wallet.Sig, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT idx, sig FROM signatures WHERE channel_id = ? AND kind = ?`, id[:], kind)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying signatures")
+	}
+	defer rows.Close()
+
+	sigs := make([]wallet.Sig, numParts)
+	for rows.Next() {
+		var idx int
+		var sig []byte
+		if err := rows.Scan(&idx, &sig); err != nil {
+			return nil, errors.WithMessage(err, "scanning signature")
+		}
+		if idx < 0 || idx >= numParts {
+			return nil, errors.Errorf("invalid signature index %d", idx)
+		}
+		sigs[idx] = sig
+	}
+	return sigs, errors.WithMessage(rows.Err(), "iterating signatures")
+}
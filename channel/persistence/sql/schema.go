@@ -0,0 +1,142 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// migrations contains the statements that migrate the schema from version i
+// to version i+1. Existing migrations must never be changed, new ones are
+// appended.
+var migrations = [][]string{
+	{ // Version 1: initial schema.
+		`CREATE TABLE channels (
+			id BLOB NOT NULL PRIMARY KEY,
+			idx INTEGER NOT NULL,
+			params BLOB NOT NULL,
+			phase INTEGER NOT NULL)`,
+		`CREATE TABLE peers (
+			channel_id BLOB NOT NULL,
+			position INTEGER NOT NULL,
+			peer BLOB NOT NULL,
+			PRIMARY KEY (channel_id, position))`,
+		`CREATE INDEX peers_peer ON peers (peer)`,
+		`CREATE TABLE transactions (
+			channel_id BLOB NOT NULL,
+			kind INTEGER NOT NULL,
+			state BLOB,
+			request_id BLOB,
+			agg_sig BLOB,
+			PRIMARY KEY (channel_id, kind))`,
+		`CREATE TABLE signatures (
+			channel_id BLOB NOT NULL,
+			kind INTEGER NOT NULL,
+			idx INTEGER NOT NULL,
+			sig BLOB NOT NULL,
+			PRIMARY KEY (channel_id, kind, idx))`,
+	},
+	{ // Version 2: queryable versions and allocations of the transactions.
+		`ALTER TABLE transactions ADD COLUMN version INTEGER`,
+		`ALTER TABLE transactions ADD COLUMN is_final BOOLEAN`,
+		`CREATE TABLE assets (
+			channel_id BLOB NOT NULL,
+			kind INTEGER NOT NULL,
+			asset_idx INTEGER NOT NULL,
+			asset BLOB NOT NULL,
+			PRIMARY KEY (channel_id, kind, asset_idx))`,
+		`CREATE INDEX assets_asset ON assets (asset)`,
+		`CREATE TABLE balances (
+			channel_id BLOB NOT NULL,
+			kind INTEGER NOT NULL,
+			asset_idx INTEGER NOT NULL,
+			idx INTEGER NOT NULL,
+			balance TEXT NOT NULL,
+			PRIMARY KEY (channel_id, kind, asset_idx, idx))`,
+		`CREATE TABLE locked_balances (
+			channel_id BLOB NOT NULL,
+			kind INTEGER NOT NULL,
+			sub_id BLOB NOT NULL,
+			asset_idx INTEGER NOT NULL,
+			balance TEXT NOT NULL,
+			PRIMARY KEY (channel_id, kind, sub_id, asset_idx))`,
+	},
+}
+
+// SchemaVersion is the schema version that NewPersistRestorer migrates to.
+var SchemaVersion = len(migrations)
+
+// migrate creates the schema_version table if it does not exist and applies
+// all missing migrations, each in its own transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return errors.WithMessage(err, "creating schema_version table")
+	}
+
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return errors.Errorf("database schema version %d is newer than supported version %d",
+			version, SchemaVersion)
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if err := applyMigration(ctx, db, version); err != nil {
+			return errors.WithMessagef(err, "migrating to version %d", version+1)
+		}
+	}
+	return nil
+}
+
+// schemaVersion reads the schema version of db. A database without version
+// entry has version 0.
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, errors.WithMessage(err, "reading schema version")
+}
+
+// applyMigration migrates the schema from version to version+1.
+func applyMigration(ctx context.Context, db *sql.DB, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "beginning transaction")
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	for _, stmt := range migrations[version] {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return errors.WithMessage(err, "executing migration")
+		}
+	}
+	if version == 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, version+1)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE schema_version SET version = ?`, version+1)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "updating schema version")
+	}
+	return tx.Commit()
+}
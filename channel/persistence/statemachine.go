@@ -66,6 +66,24 @@ func (m StateMachine) SetRegistered(ctx context.Context, reg *channel.Registered
 	return errors.WithMessage(m.pr.PhaseChanged(ctx, m.StateMachine), "Persister.PhaseChanged")
 }
 
+// SetProgressing calls SetProgressing on the channel.StateMachine and then
+// persists the changed staging state.
+func (m StateMachine) SetProgressing(ctx context.Context, state *channel.State) error {
+	if err := m.StateMachine.SetProgressing(state); err != nil {
+		return err
+	}
+	return errors.WithMessage(m.pr.Staged(ctx, m.StateMachine), "Persister.Staged")
+}
+
+// SetProgressed calls SetProgressed on the channel.StateMachine and then
+// persists the changed current transaction.
+func (m StateMachine) SetProgressed(ctx context.Context, e *channel.RegisteredEvent) error {
+	if err := m.StateMachine.SetProgressed(e); err != nil {
+		return err
+	}
+	return errors.WithMessage(m.pr.Enabled(ctx, m.StateMachine), "Persister.Enabled")
+}
+
 // SetWithdrawing calls SetWithdrawing on the channel.StateMachine and then
 // persists the changed phase.
 func (m StateMachine) SetWithdrawing(ctx context.Context) error {
@@ -106,6 +124,20 @@ func (m StateMachine) Update(
 	return errors.WithMessage(m.pr.Staged(ctx, m.StateMachine), "Persister.Staged")
 }
 
+// UpdateWithRequestID calls UpdateWithRequestID on the channel.StateMachine and
+// then persists the changed staging state, including the request ID.
+func (m StateMachine) UpdateWithRequestID(
+	ctx context.Context,
+	stagingState *channel.State,
+	actor channel.Index,
+	id channel.RequestID,
+) error {
+	if err := m.StateMachine.UpdateWithRequestID(stagingState, actor, id); err != nil {
+		return err
+	}
+	return errors.WithMessage(m.pr.Staged(ctx, m.StateMachine), "Persister.Staged")
+}
+
 // Sig calls Sig on the channel.StateMachine and then persists the added
 // signature.
 func (m StateMachine) Sig(ctx context.Context) (sig wallet.Sig, err error) {
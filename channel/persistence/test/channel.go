@@ -97,6 +97,7 @@ func (c *Channel) RequireEqual(t require.TestingT, ch channel.Source) {
 // which it is compared to also has a nil slice OR a slice of nil sigs.
 func requireEqualStagingTX(t require.TestingT, expected, actual channel.Transaction) {
 	require.Equal(t, expected.State, actual.State, "StagingTX.State")
+	require.Equal(t, expected.RequestID, actual.RequestID, "StagingTX.RequestID")
 	requireEqualSigs(t, expected.Sigs, actual.Sigs)
 }
 
@@ -132,7 +133,7 @@ func isNilSigs(s []wallet.Sig) bool {
 func (c *Channel) Init(t require.TestingT, rng *rand.Rand) {
 	initAlloc := *ctest.NewRandomAllocation(rng, ctest.WithNumParts(len(c.accounts)))
 	initData := channel.NewMockOp(channel.OpValid)
-	err := c.StateMachine.Init(nil, initAlloc, initData)
+	err := c.StateMachine.Init(c.ctx, initAlloc, initData)
 	require.NoError(t, err)
 	c.AssertPersisted(c.ctx, t)
 }
@@ -146,14 +147,14 @@ func (c *Channel) EnableInit(t require.TestingT) {
 
 // SignAll signs the current staged state by all parties.
 func (c *Channel) SignAll(t require.TestingT) {
-	_, err := c.Sig(nil) // trigger local signing
+	_, err := c.Sig(c.ctx) // trigger local signing
 	require.NoError(t, err)
 	c.AssertPersisted(c.ctx, t)
 	// remote signers
 	for i := range c.accounts {
 		sig, err := channel.Sign(c.accounts[i], c.Params(), c.StagingState())
 		require.NoError(t, err)
-		c.AddSig(nil, channel.Index(i), sig)
+		c.AddSig(c.ctx, channel.Index(i), sig)
 		c.AssertPersisted(c.ctx, t)
 	}
 }
@@ -171,6 +172,14 @@ func (c *Channel) Update(t require.TestingT, state *channel.State, idx channel.I
 	return err
 }
 
+// UpdateWithRequestID calls UpdateWithRequestID on the state machine and then
+// checks the persistence.
+func (c *Channel) UpdateWithRequestID(t require.TestingT, state *channel.State, idx channel.Index, id channel.RequestID) error {
+	err := c.StateMachine.UpdateWithRequestID(c.ctx, state, idx, id)
+	c.AssertPersisted(c.ctx, t)
+	return err
+}
+
 // EnableUpdate calls EnableUpdate on the state machine and then checks the persistence.
 func (c *Channel) EnableUpdate(t require.TestingT) {
 	require.NoError(t, c.StateMachine.EnableUpdate(c.ctx))
@@ -24,6 +24,7 @@ import (
 
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/channel/persistence"
+	ctest "perun.network/go-perun/channel/test"
 	"perun.network/go-perun/log"
 	pkgtest "perun.network/go-perun/pkg/test"
 	wtest "perun.network/go-perun/wallet/test"
@@ -124,7 +125,7 @@ func GenericPersistRestorerTest(
 				state1.Version++
 				ch.Update(t, state1, ch.Idx())
 				ch.DiscardUpdate(t)
-				ch.Update(t, state1, ch.Idx())
+				ch.UpdateWithRequestID(t, state1, ch.Idx(), ctest.NewRandomRequestID(rng))
 				ch.SignAll(t)
 				ch.EnableUpdate(t)
 
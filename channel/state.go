@@ -20,7 +20,6 @@ import (
 	"github.com/pkg/errors"
 
 	perunio "perun.network/go-perun/pkg/io"
-	"perun.network/go-perun/wallet"
 )
 
 type (
@@ -109,19 +108,30 @@ func (s State) Encode(w io.Writer) error {
 	return errors.WithMessage(err, "state encode")
 }
 
-// Decode decodes a state from an `io.Reader` or returns an `error`.
+// Decode decodes a state from an `io.Reader` or returns an `error`. The global
+// backends are used, see Backends.DecodeState.
 func (s *State) Decode(r io.Reader) error {
+	return s.decode(r, Backends{})
+}
+
+func (s *State) decode(r io.Reader, b Backends) error {
 	// Decode ID, Version, Allocation, IsFinal
-	if err := perunio.Decode(r, &s.ID, &s.Version, &s.Allocation, &s.IsFinal); err != nil {
+	if err := perunio.Decode(r, &s.ID, &s.Version); err != nil {
 		return errors.WithMessage(err, "id or version decode")
 	}
+	if err := s.Allocation.decode(r, b); err != nil {
+		return errors.WithMessage(err, "allocation decode")
+	}
+	if err := perunio.Decode(r, &s.IsFinal); err != nil {
+		return errors.WithMessage(err, "isFinal decode")
+	}
 	// Decode app
 	var err error
-	def, err := wallet.DecodeAddress(r)
+	def, err := b.DecodeAddress(r)
 	if err != nil {
 		return errors.WithMessage(err, "app definition decode")
 	}
-	s.App, err = AppFromDefinition(def)
+	s.App, err = b.AppFromDefinition(def)
 	if err != nil {
 		return errors.WithMessage(err, "app from definition")
 	}
@@ -87,6 +87,12 @@ func (m *StateMachine) Init(initBals Allocation, initData Data) error {
 // Update makes the provided state the staging state.
 // It is checked whether this is a valid state transition.
 func (m *StateMachine) Update(stagingState *State, actor Index) error {
+	return m.UpdateWithRequestID(stagingState, actor, RequestID{})
+}
+
+// UpdateWithRequestID makes the provided state the staging state, like Update,
+// and records the given request ID in the staging transaction.
+func (m *StateMachine) UpdateWithRequestID(stagingState *State, actor Index, id RequestID) error {
 	if err := m.expect(PhaseTransition{Acting, Signing}); err != nil {
 		return err
 	}
@@ -96,6 +102,31 @@ func (m *StateMachine) Update(stagingState *State, actor Index) error {
 	}
 
 	m.setStaging(Signing, stagingState)
+	m.stagingTX.RequestID = id
+	return nil
+}
+
+// SetProgressing moves the machine into the Progressing phase, in which the
+// passed state is progressed on-chain by the own participant, and makes it
+// the staging state, signed by the own participant. It is checked whether
+// this is a valid app transition by the own participant. This phase can only
+// be reached from the Registered, Progressing and Progressed phases.
+func (m *StateMachine) SetProgressing(state *State) error {
+	if !inPhase(m.phase, []Phase{Registered, Progressing, Progressed}) {
+		return m.phaseErrorf(PhaseTransition{m.phase, Progressing}, "can only progress after registering")
+	}
+
+	if err := m.validTransition(state, m.idx); err != nil {
+		return err
+	}
+
+	sig, err := m.params.backends.Sign(m.acc, &m.params, state)
+	if err != nil {
+		return errors.WithMessage(err, "signing progressed state")
+	}
+	m.setStaging(Progressing, state)
+	m.stagingTX.Sigs[m.idx] = sig
+	m.notifySigAdded(m.idx)
 	return nil
 }
 
@@ -110,7 +141,7 @@ func (m *StateMachine) CheckUpdate(
 		return err
 	}
 
-	if ok, err := Verify(m.params.Parts[sigIdx], &m.params, state, sig); err != nil {
+	if ok, err := m.params.backends.Verify(m.params.Parts[sigIdx], &m.params, state, sig); err != nil {
 		return errors.WithMessagef(err, "verifying signature[%d]", sigIdx)
 	} else if !ok {
 		return errors.Errorf("invalid signature[%d]", sigIdx)
@@ -45,10 +45,12 @@ type Setup struct {
 	RandomAddress addressCreator
 }
 
-// GenericBackendTest tests the interface functions of the global channel.Backend with the passed test data.
+// GenericBackendTest tests the interface functions of the channel.Backend with
+// the passed test data. The backends of the setup's Params are tested, which
+// are the global backends by default.
 func GenericBackendTest(t *testing.T, s *Setup) {
 	require := require.New(t)
-	ID := channel.CalcID(s.Params)
+	ID := s.Params.Backends().CalcID(s.Params)
 	require.Equal(ID, s.State.ID, "ChannelID(params) should match the States ID")
 	require.Equal(ID, s.Params.ID(), "ChannelID(params) should match the Params ID")
 	require.NotNil(s.State.Data, "State data can not be nil")
@@ -69,33 +71,33 @@ func GenericBackendTest(t *testing.T, s *Setup) {
 
 func genericChannelIDTest(t *testing.T, s *Setup) {
 	require.NotNil(t, s.Params.Parts, "params.Parts can not be nil")
-	assert.Panics(t, func() { channel.CalcID(nil) }, "ChannelID(nil) should panic")
+	assert.Panics(t, func() { s.Params.Backends().CalcID(nil) }, "ChannelID(nil) should panic")
 
 	// Check that modifying the state changes the id
 	for _, modParams := range buildModifiedParams(s.Params, s.Params2, s) {
 		params := modParams
-		ID := channel.CalcID(&params)
+		ID := params.Backends().CalcID(&params)
 		assert.NotEqual(t, ID, s.State.ID, "Channel ids should differ")
 	}
 }
 
 func genericSignTest(t *testing.T, s *Setup) {
-	_, err := channel.Sign(s.Account, s.Params, s.State)
+	_, err := s.Params.Backends().Sign(s.Account, s.Params, s.State)
 	assert.NoError(t, err, "Sign should not return an error")
 }
 
 func genericVerifyTest(t *testing.T, s *Setup) {
-	addr := s.Account.Address()
-	require.Equal(t, channel.CalcID(s.Params), s.Params.ID(), "Invalid test params")
-	sig, err := channel.Sign(s.Account, s.Params, s.State)
+	addr, b := s.Account.Address(), s.Params.Backends()
+	require.Equal(t, b.CalcID(s.Params), s.Params.ID(), "Invalid test params")
+	sig, err := b.Sign(s.Account, s.Params, s.State)
 	require.NoError(t, err, "Sign should not return an error")
 
-	ok, err := channel.Verify(addr, s.Params, s.State, sig)
+	ok, err := b.Verify(addr, s.Params, s.State, sig)
 	assert.NoError(t, err, "Verify should not return an error")
 	assert.True(t, ok, "Verify should return true")
 
 	// Different state and same params
-	ok, err = channel.Verify(addr, s.Params, s.State2, sig)
+	ok, err = b.Verify(addr, s.Params, s.State2, sig)
 	assert.NoError(t, err, "Verify should not return an error")
 	assert.False(t, ok, "Verify should return false")
 
@@ -107,7 +109,7 @@ func genericVerifyTest(t *testing.T, s *Setup) {
 		modParams := _modParams
 		for _, _fakeState := range buildModifiedStates(s.State, s.State2, false) {
 			fakeState := _fakeState
-			ok, err = channel.Verify(addr, &modParams, &fakeState, sig)
+			ok, err = b.Verify(addr, &modParams, &fakeState, sig)
 			assert.False(t, ok, "Verify should return false")
 			if err2 := fakeState.Valid(); err2 != nil {
 				assert.Error(t, err, "Verify should return error on an invalid state")
@@ -119,7 +121,7 @@ func genericVerifyTest(t *testing.T, s *Setup) {
 
 	// Different address and same state and params
 	for i := 0; i < 10; i++ {
-		ok, err := channel.Verify(s.RandomAddress(), s.Params, s.State, sig)
+		ok, err := b.Verify(s.RandomAddress(), s.Params, s.State, sig)
 		assert.NoError(t, err, "Verify should not return an error")
 		assert.False(t, ok, "Verify should return false")
 	}
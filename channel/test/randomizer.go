@@ -212,6 +212,14 @@ func NewRandomChannelID(rng *rand.Rand, opts ...RandomOpt) (id channel.ID) {
 	return
 }
 
+// NewRandomRequestID generates a new random `channel.RequestID`.
+func NewRandomRequestID(rng *rand.Rand) (id channel.RequestID) {
+	if _, err := rng.Read(id[:]); err != nil {
+		log.Panic("could not read from rng")
+	}
+	return
+}
+
 // NewRandomBal generates a new random `channel.Bal`.
 // Options: `WithBalancesRange`.
 func NewRandomBal(rng *rand.Rand, opts ...RandomOpt) channel.Bal {
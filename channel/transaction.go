@@ -23,20 +23,50 @@ import (
 	"perun.network/go-perun/wallet"
 )
 
-// Transaction is a channel state together with valid signatures from the
-// channel participants.
-type Transaction struct {
-	*State
-	Sigs []wallet.Sig
-}
+type (
+	// Transaction is a channel state together with valid signatures from the
+	// channel participants.
+	Transaction struct {
+		*State
+		Sigs []wallet.Sig
+		// RequestID optionally identifies the update request that led to this
+		// transaction. It is the zero RequestID if the transaction was not
+		// created by an identified request.
+		RequestID RequestID
+		// AggSig optionally is the aggregate of the signatures of all
+		// participants if the channel backend is an AggregatingBackend. It
+		// allows to verify the transaction with a single check, see
+		// Backends.VerifyTx. The single signatures may then be omitted.
+		AggSig wallet.Sig
+	}
+
+	// A RequestID is a client-chosen identifier of a channel update request.
+	// It allows to recognize retried update requests so that each request is
+	// applied at most once.
+	RequestID [32]byte
+)
+
+// transaction encoding flags, stored in the first byte of an encoded
+// Transaction.
+const (
+	txStateSet     uint8 = 1 << iota // the State is set
+	txRequestIDSet                   // the RequestID is set
+	txAggSigSet                      // the AggSig is set
+)
 
 var _ perunio.Serializer = (*Transaction)(nil)
 
 // Clone returns a deep copy of Transaction.
 func (t Transaction) Clone() Transaction {
+	var aggSig wallet.Sig
+	if t.AggSig != nil {
+		aggSig = append(wallet.Sig{}, t.AggSig...)
+	}
 	return Transaction{
-		State: t.State.Clone(),
-		Sigs:  wallet.CloneSigs(t.Sigs),
+		State:     t.State.Clone(),
+		Sigs:      wallet.CloneSigs(t.Sigs),
+		RequestID: t.RequestID,
+		AggSig:    aggSig,
 	}
 }
 
@@ -47,32 +77,90 @@ func (t Transaction) Encode(w io.Writer) error {
 		return perunio.Encode(w, uint8(0))
 	}
 
-	// Encode stateSet and state
-	if err := perunio.Encode(w, uint8(1), t.State); err != nil {
-		return errors.WithMessage(err, "encoding stateSet bit and State")
+	flags := txStateSet
+	if !t.RequestID.IsZero() {
+		flags |= txRequestIDSet
 	}
-	return wallet.EncodeSparseSigs(w, t.Sigs)
+	if t.AggSig != nil {
+		flags |= txAggSigSet
+	}
+
+	// Encode flags and state
+	if err := perunio.Encode(w, flags, t.State); err != nil {
+		return errors.WithMessage(err, "encoding flags and State")
+	}
+	sigs := t.Sigs
+	if sigs == nil { // only aggregated signature
+		sigs = make([]wallet.Sig, t.State.NumParts())
+	}
+	if err := wallet.EncodeSparseSigs(w, sigs); err != nil {
+		return err
+	}
+	if flags&txRequestIDSet != 0 {
+		if err := t.RequestID.Encode(w); err != nil {
+			return errors.WithMessage(err, "encoding RequestID")
+		}
+	}
+	if flags&txAggSigSet == 0 {
+		return nil
+	}
+	return errors.WithMessage(perunio.Encode(w, []byte(t.AggSig)), "encoding AggSig")
 }
 
-// Decode decodes a transaction from an `io.Reader` or returns an `error`.
+// Decode decodes a transaction from an `io.Reader` or returns an `error`. The
+// global backends are used, see Backends.DecodeTransaction.
 func (t *Transaction) Decode(r io.Reader) error {
-	// Decode stateSet
-	var stateSet uint8
-	if err := perunio.Decode(r, &stateSet); err != nil {
-		return errors.WithMessage(err, "decoding stateSet bit")
+	return t.decode(r, Backends{})
+}
+
+func (t *Transaction) decode(r io.Reader, b Backends) error {
+	// Decode flags
+	var flags uint8
+	if err := perunio.Decode(r, &flags); err != nil {
+		return errors.WithMessage(err, "decoding flags")
 	}
-	if (stateSet % 2) == 0 {
+	t.RequestID, t.AggSig = RequestID{}, nil
+	if flags&txStateSet == 0 {
 		t.State = nil
 		return nil
 	}
 
 	// Decode State
 	t.State = new(State)
-	if err := perunio.Decode(r, t.State); err != nil {
+	if err := t.State.decode(r, b); err != nil {
 		return errors.WithMessage(err, "decoding state")
 	}
 
 	t.Sigs = make([]wallet.Sig, t.State.NumParts())
 
-	return wallet.DecodeSparseSigs(r, &t.Sigs)
+	if err := wallet.DecodeSparseSigsWith(r, &t.Sigs, b.DecodeSig); err != nil {
+		return err
+	}
+	if flags&txRequestIDSet != 0 {
+		if err := t.RequestID.Decode(r); err != nil {
+			return errors.WithMessage(err, "decoding RequestID")
+		}
+	}
+	if flags&txAggSigSet == 0 {
+		return nil
+	}
+	var err error
+	t.AggSig, err = b.DecodeSig(r)
+	return errors.WithMessage(err, "decoding AggSig")
+}
+
+// IsZero returns whether the RequestID is the zero RequestID, which is used
+// for unidentified requests.
+func (id RequestID) IsZero() bool {
+	return id == RequestID{}
+}
+
+// Encode encodes the RequestID into an io.Writer.
+func (id RequestID) Encode(w io.Writer) error {
+	return perunio.Encode(w, [32]byte(id))
+}
+
+// Decode decodes a RequestID from an io.Reader.
+func (id *RequestID) Decode(r io.Reader) error {
+	return perunio.Decode(r, (*[32]byte)(id))
 }
@@ -40,6 +40,8 @@ func TestTransactionSerialization(t *testing.T) {
 	for _, tt := range tests {
 		tx := test.NewRandomTransaction(rng, tt)
 		iotest.GenericSerializerTest(t, tx)
+		tx.RequestID = test.NewRandomRequestID(rng)
+		iotest.GenericSerializerTest(t, tx)
 	}
 
 	tx := new(channel.Transaction)
@@ -0,0 +1,236 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// TranscriptVersion is the version of the transcript encoding written by
+// Transcript.Encode.
+const TranscriptVersion uint8 = 1
+
+var _ perunio.Serializer = (*Transcript)(nil)
+
+// A Transcript is the history of a channel. It can be handed to a third party,
+// e.g., an auditor, who checks it with VerifyTranscript.
+//
+// A Transcript is encoded in the following portable format, using the pkg/io
+// encoding of the respective types:
+//  1. the version TranscriptVersion as uint8,
+//  2. the channel Params,
+//  3. the number of transactions as uint32, followed by the transactions,
+//  4. the number of phase transitions as uint32, followed by the transitions,
+//     each of which is encoded as its From and To Phase.
+type Transcript struct {
+	// Params are the parameters of the channel.
+	Params *Params
+	// Transactions are the fully signed transactions of the channel, ordered
+	// by version.
+	Transactions []Transaction
+	// Phases are the phase transitions of the channel, in the order in which
+	// they happened.
+	Phases []PhaseTransition
+}
+
+// Encode encodes the transcript into an io.Writer.
+func (t Transcript) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, TranscriptVersion, t.Params, uint32(len(t.Transactions))); err != nil {
+		return errors.WithMessage(err, "encoding version and params")
+	}
+	for i, tx := range t.Transactions {
+		if err := tx.Encode(w); err != nil {
+			return errors.WithMessagef(err, "encoding transaction %d", i)
+		}
+	}
+	if err := perunio.Encode(w, uint32(len(t.Phases))); err != nil {
+		return errors.WithMessage(err, "encoding number of phase transitions")
+	}
+	for _, pt := range t.Phases {
+		if err := perunio.Encode(w, pt.From, pt.To); err != nil {
+			return errors.WithMessage(err, "encoding phase transition")
+		}
+	}
+	return nil
+}
+
+// Decode decodes a transcript from an io.Reader. The global backends are used,
+// see Backends.DecodeTranscript.
+func (t *Transcript) Decode(r io.Reader) error {
+	return t.decode(r, Backends{})
+}
+
+func (t *Transcript) decode(r io.Reader, b Backends) (err error) {
+	var version uint8
+	if err := perunio.Decode(r, &version); err != nil {
+		return errors.WithMessage(err, "decoding version")
+	}
+	if version != TranscriptVersion {
+		return errors.Errorf("unsupported transcript version %d", version)
+	}
+	if t.Params, err = b.DecodeParams(r); err != nil {
+		return errors.WithMessage(err, "decoding params")
+	}
+
+	var n uint32
+	if err := perunio.Decode(r, &n); err != nil {
+		return errors.WithMessage(err, "decoding number of transactions")
+	}
+	t.Transactions = make([]Transaction, n)
+	for i := range t.Transactions {
+		if t.Transactions[i], err = b.DecodeTransaction(r); err != nil {
+			return errors.WithMessagef(err, "decoding transaction %d", i)
+		}
+	}
+
+	if err := perunio.Decode(r, &n); err != nil {
+		return errors.WithMessage(err, "decoding number of phase transitions")
+	}
+	t.Phases = make([]PhaseTransition, n)
+	for i := range t.Phases {
+		if err := perunio.Decode(r, &t.Phases[i].From, &t.Phases[i].To); err != nil {
+			return errors.WithMessagef(err, "decoding phase transition %d", i)
+		}
+	}
+	return nil
+}
+
+// VerifyTranscript checks that the transcript is a valid channel history. It
+// checks that
+//   - the channel ID matches the Params,
+//   - all transactions are of this channel and signed by all participants,
+//     which is checked with a single aggregated signature if possible,
+//   - a first state of version 0 is a valid initial state of the channel's
+//     StateApp,
+//   - the versions of the transactions are strictly increasing,
+//   - consecutive states are valid transitions: no transition starts at a
+//     final state, the allocations are valid and preserve the sums of the
+//     assets, like the checks of the channel machine, and the transition is
+//     valid for the channel's StateApp.
+//
+// Since the actor of a transition is not part of a transaction, a transition
+// is valid if it is valid for any participant as actor. Transcripts of
+// restored channels start at the restored state, whose validity as an initial
+// state is not checked if its version is not 0.
+//
+// Signatures are verified with the backends of the Params.
+func VerifyTranscript(t *Transcript) error {
+	if t.Params == nil {
+		return errors.New("transcript has no params")
+	}
+	params, b := t.Params, t.Params.Backends()
+	if id := b.CalcID(params); id != params.ID() {
+		return errors.Errorf("channel ID %x does not match params (%x)", params.ID(), id)
+	}
+
+	for i, tx := range t.Transactions {
+		if err := verifyTranscriptTx(params, tx); err != nil {
+			return errors.WithMessagef(err, "transaction %d", i)
+		}
+		if i == 0 {
+			if err := validTranscriptInit(params, tx.State); err != nil {
+				return errors.WithMessage(err, "initial transaction")
+			}
+			continue
+		}
+		prev := t.Transactions[i-1]
+		if tx.Version <= prev.Version {
+			return errors.Errorf("transaction %d: version %d not greater than %d", i, tx.Version, prev.Version)
+		}
+		if err := validTranscriptTransition(params, prev.State, tx.State); err != nil {
+			return errors.WithMessagef(err, "transition to transaction %d", i)
+		}
+	}
+	return nil
+}
+
+// verifyTranscriptTx checks that tx is a transaction of the channel with the
+// passed params and that it is signed by all participants.
+func verifyTranscriptTx(params *Params, tx Transaction) error {
+	if tx.State == nil {
+		return errors.New("no state")
+	}
+	if tx.ID != params.ID() {
+		return errors.Errorf("state of channel %x", tx.ID)
+	}
+	return params.Backends().VerifyTx(params, tx)
+}
+
+// validTranscriptInit checks that s has a valid allocation and, if it is of
+// version 0 and the channel's app is a StateApp, that it is a valid initial
+// state of the app.
+func validTranscriptInit(params *Params, s *State) error {
+	if err := s.Allocation.Valid(); err != nil {
+		return errors.WithMessage(err, "invalid allocation")
+	}
+	app, ok := params.App.(StateApp)
+	if !ok || s.Version != 0 {
+		return nil
+	}
+	return errors.WithMessage(app.ValidInit(params, s), "invalid initial state")
+}
+
+// validTranscriptTransition checks that to is a valid transition from, as
+// checked by the channel machine, and by any participant if the channel's app
+// is a StateApp.
+func validTranscriptTransition(params *Params, from, to *State) error {
+	if err := validFrameworkTransition(params, from, to, false); err != nil {
+		return err
+	}
+	app, ok := params.App.(StateApp)
+	if !ok {
+		return nil
+	}
+	var err error
+	for actor := range params.Parts {
+		if err = app.ValidTransition(params, from, to, Index(actor)); err == nil {
+			return nil
+		}
+	}
+	return errors.WithMessage(err, "no participant can make the transition")
+}
+
+// Transcript returns the transcript of the channel. It contains the fully
+// signed transactions and the phase transitions that the machine knows of.
+// Restored machines only know the history from the restored current
+// transaction onward.
+func (m *machine) Transcript() *Transcript {
+	t := &Transcript{
+		Params: m.params.Clone(),
+		Phases: append([]PhaseTransition(nil), m.phases...),
+	}
+	for _, tx := range m.prevTXs {
+		t.addTx(tx)
+	}
+	t.addTx(m.currentTX)
+	return t
+}
+
+// addTx appends a clone of tx to the transcript if it is fully signed.
+func (t *Transcript) addTx(tx Transaction) {
+	if tx.State == nil {
+		return
+	}
+	for _, sig := range tx.Sigs {
+		if sig == nil {
+			return
+		}
+	}
+	t.Transactions = append(t.Transactions, tx.Clone())
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestTranscript(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	accs, parts := wallettest.NewRandomAccounts(rng, 2)
+	params := test.NewRandomParams(rng, test.WithParts(parts...))
+
+	sign := func(s *channel.State) []wallet.Sig {
+		sigs := make([]wallet.Sig, len(accs))
+		for i, acc := range accs {
+			var err error
+			sigs[i], err = channel.Sign(acc, params, s)
+			require.NoError(t, err)
+		}
+		return sigs
+	}
+	addSigs := func(m *channel.StateMachine) {
+		for i, sig := range sign(m.StagingState()) {
+			require.NoError(t, m.AddSig(channel.Index(i), sig))
+		}
+	}
+
+	m, err := channel.NewStateMachine(accs[0], *params)
+	require.NoError(t, err)
+	initBals := test.NewRandomAllocation(rng, test.WithNumParts(2))
+	require.NoError(t, m.Init(*initBals, channel.NewMockOp(channel.OpValid)))
+	addSigs(m)
+	require.NoError(t, m.EnableInit())
+	require.NoError(t, m.SetFunded())
+	for i := 0; i < 2; i++ {
+		s := m.State().Clone()
+		s.Version++
+		require.NoError(t, m.Update(s, 0))
+		addSigs(m)
+		require.NoError(t, m.EnableUpdate())
+	}
+
+	tr := m.Transcript()
+	require.Len(t, tr.Transactions, 3)
+	assert.Equal(t, channel.PhaseTransition{From: channel.InitActing, To: channel.InitSigning}, tr.Phases[0])
+	assert.Equal(t, channel.Acting, tr.Phases[len(tr.Phases)-1].To)
+	require.NoError(t, channel.VerifyTranscript(tr))
+
+	t.Run("encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, tr.Encode(&buf))
+		dec, err := params.Backends().DecodeTranscript(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, tr.Params.ID(), dec.Params.ID())
+		assert.Equal(t, tr.Phases, dec.Phases)
+		require.Len(t, dec.Transactions, len(tr.Transactions))
+		for i, tx := range dec.Transactions {
+			assert.NoError(t, tx.State.Equal(tr.Transactions[i].State))
+			assert.Equal(t, tr.Transactions[i].Sigs, tx.Sigs)
+		}
+		assert.NoError(t, channel.VerifyTranscript(dec))
+
+		buf.Reset()
+		buf.WriteByte(channel.TranscriptVersion + 1)
+		_, err = params.Backends().DecodeTranscript(&buf)
+		assert.Error(t, err, "unknown version should be rejected")
+	})
+
+	// tampered returns a copy of the transcript that was modified by f.
+	tampered := func(f func(*channel.Transcript)) *channel.Transcript {
+		c := &channel.Transcript{Params: tr.Params, Phases: tr.Phases}
+		for _, tx := range tr.Transactions {
+			c.Transactions = append(c.Transactions, tx.Clone())
+		}
+		f(c)
+		return c
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, f := range map[string]func(*channel.Transcript){
+			"params": func(c *channel.Transcript) {
+				c.Params = test.NewRandomParams(rng, test.WithParts(parts...))
+			},
+			"signature": func(c *channel.Transcript) {
+				c.Transactions[1].Sigs[1] = c.Transactions[0].Sigs[1]
+			},
+			"missing signature": func(c *channel.Transcript) {
+				c.Transactions[2].Sigs[0] = nil
+			},
+			"version order": func(c *channel.Transcript) {
+				c.Transactions[1], c.Transactions[2] = c.Transactions[2], c.Transactions[1]
+			},
+			"transition": func(c *channel.Transcript) {
+				tx := &c.Transactions[1]
+				tx.Data = channel.NewMockOp(channel.OpErr)
+				tx.Sigs = sign(tx.State)
+			},
+			"initial state": func(c *channel.Transcript) {
+				c.Transactions = c.Transactions[:1]
+				tx := &c.Transactions[0]
+				tx.Data = channel.NewMockOp(channel.OpErr)
+				tx.Sigs = sign(tx.State)
+			},
+			"burnt funds": func(c *channel.Transcript) {
+				tx := &c.Transactions[2]
+				tx.Balances[0][0].Sub(tx.Balances[0][0], big.NewInt(1))
+				tx.Sigs = sign(tx.State)
+			},
+			"transition after final": func(c *channel.Transcript) {
+				tx := &c.Transactions[1]
+				tx.IsFinal = true
+				tx.Sigs = sign(tx.State)
+			},
+		} {
+			assert.Error(t, channel.VerifyTranscript(tampered(f)), name)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/backend/sim/bls"
+	simchannel "perun.network/go-perun/backend/sim/channel"
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wirenet "perun.network/go-perun/wire/net"
+	"perun.network/go-perun/wire/net/simple"
+)
+
+// blsBackends are the backends of the BLS clients. App definitions are BLS
+// addresses that resolve to mock apps.
+var blsBackends = channel.Backends{
+	Channel: new(simchannel.BLSBackend),
+	Wallet:  new(bls.Backend),
+	App:     new(channel.MockAppBackend),
+}
+
+// TestClient_SetBackends runs a pair of clients with the global simulated
+// backends and a pair of clients with BLS backends side by side over TCP.
+func TestClient_SetBackends(t *testing.T) {
+	rng := test.Prng(t)
+	pairs := []*backendsPair{
+		newBackendsPair(t, rng, channel.Backends{}, wtest.NewWallet, wtest.NewRandomAddress(rng)),
+		newBackendsPair(t, rng, blsBackends, newBLSWallet, bls.NewRandomAddress(rng)),
+	}
+	for _, p := range pairs {
+		p.run(t, rng)
+	}
+	assert.IsType(t, new(bls.Address), pairs[1].accs[0].Address())
+}
+
+// backendsPair is a pair of clients that use the same backends and are
+// connected over TCP.
+type backendsPair struct {
+	backends channel.Backends
+	appDef   wallet.Address
+	wallets  [2]wtest.Wallet
+	accs     [2]wallet.Account
+	clients  [2]*client.Client
+}
+
+func newBackendsPair(t *testing.T, rng *rand.Rand, b channel.Backends, newWallet func() wtest.Wallet, appDef wallet.Address) *backendsPair {
+	p := &backendsPair{backends: b, appDef: appDef}
+	var (
+		listeners [2]*simple.Listener
+		dialers   [2]*simple.Dialer
+	)
+	for i := range p.clients {
+		p.wallets[i] = newWallet()
+		p.accs[i] = p.wallets[i].NewRandomAccount(rng)
+		l, err := simple.NewTCPListener("127.0.0.1:0")
+		require.NoError(t, err)
+		l.SetBackends(b)
+		listeners[i] = l
+		dialers[i] = simple.NewTCPDialer(defaultTimeout)
+		dialers[i].SetBackends(b)
+	}
+
+	for i := range p.clients {
+		dialers[i].Register(p.accs[1-i].Address(), listeners[1-i].Addr().String())
+		bus := wirenet.NewBus(p.accs[i], dialers[i])
+		go bus.Listen(listeners[i])
+		t.Cleanup(func() { bus.Close() })
+
+		c, err := client.New(p.accs[i].Address(), bus,
+			&logFunder{log.WithField("client", i)},
+			&logAdjudicator{log.WithField("client", i)},
+			p.wallets[i])
+		require.NoError(t, err)
+		c.SetBackends(b)
+		t.Cleanup(func() { c.Close() })
+		p.clients[i] = c
+	}
+	return p
+}
+
+// run opens a channel between the clients and updates it to a final state.
+func (p *backendsPair) run(t *testing.T, rng *rand.Rand) {
+	part := p.wallets[1].NewRandomAccount(rng).Address()
+	accepted := make(chan struct{})
+	go p.clients[1].Handle(client.ProposalHandlerFunc(
+		func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+			defer cancel()
+			_, err := res.Accept(ctx, client.ProposalAcc{Participant: part})
+			assert.NoError(t, err)
+			close(accepted)
+		}),
+		client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+			defer cancel()
+			assert.NoError(t, res.Accept(ctx))
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	ch, err := p.clients[0].ProposeChannel(ctx, &client.ChannelProposal{
+		ChallengeDuration: 60,
+		Nonce:             big.NewInt(rng.Int63()),
+		ParticipantAddr:   p.wallets[0].NewRandomAccount(rng).Address(),
+		AppDef:            p.appDef,
+		InitData:          channel.NewMockOp(channel.OpValid),
+		InitBals: &channel.Allocation{
+			Assets:   []channel.Asset{chtest.NewRandomAsset(rng)},
+			Balances: [][]channel.Bal{{big.NewInt(100), big.NewInt(100)}},
+		},
+		PeerAddrs: []wire.Address{p.accs[0].Address(), p.accs[1].Address()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, p.backends, ch.Params().Backends())
+	<-accepted // the peer must know the channel before it is updated
+	require.NoError(t, ch.UpdateBy(ctx, func(s *channel.State) { s.IsFinal = true }))
+	assert.True(t, ch.State().IsFinal)
+}
+
+// blsWallet is a wallet of BLS accounts.
+type blsWallet struct {
+	mu   sync.Mutex
+	accs map[wallet.AddrKey]*bls.Account
+}
+
+func newBLSWallet() wtest.Wallet {
+	return &blsWallet{accs: make(map[wallet.AddrKey]*bls.Account)}
+}
+
+func (w *blsWallet) NewRandomAccount(rng *rand.Rand) wallet.Account {
+	acc := bls.NewRandomAccount(rng)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.accs[wallet.Key(acc.Address())] = acc
+	return acc
+}
+
+func (w *blsWallet) Unlock(a wallet.Address) (wallet.Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	acc, ok := w.accs[wallet.Key(a)]
+	if !ok {
+		return nil, errors.Errorf("unknown account %v", a)
+	}
+	return acc, nil
+}
+
+func (*blsWallet) LockAll()                      {}
+func (*blsWallet) IncrementUsage(wallet.Address) {}
+func (*blsWallet) DecrementUsage(wallet.Address) {}
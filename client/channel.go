@@ -16,6 +16,7 @@ package client
 
 import (
 	"context"
+	"io"
 
 	"github.com/pkg/errors"
 
@@ -41,6 +42,8 @@ type Channel struct {
 	updateSub   chan<- *channel.State
 	adjudicator channel.Adjudicator
 	wallet      wallet.Wallet
+	pr          persistence.Persister
+	escalator   escalator
 }
 
 // newChannel is internally used by the Client to create a new channel
@@ -92,6 +95,7 @@ func (c *Client) channelFromMachine(machine *channel.StateMachine, peers ...wire
 		machine:     pmachine,
 		adjudicator: c.adjudicator,
 		wallet:      c.wallet,
+		pr:          c.pr,
 	}, nil
 }
 
@@ -146,6 +150,31 @@ func (c *Channel) Phase() channel.Phase {
 	return c.machine.Phase()
 }
 
+// ExportTranscript writes the transcript of the channel to w, see
+// channel.Transcript for the format. It contains the channel parameters, all
+// fully signed transactions and the phase transitions known to the channel
+// controller. The transcript can be checked with channel.VerifyTranscript.
+//
+// The history of a restored channel starts at its restored state.
+func (c *Channel) ExportTranscript(w io.Writer) error {
+	c.machMtx.Lock()
+	t := c.machine.Transcript()
+	c.machMtx.Unlock()
+
+	return errors.WithMessage(t.Encode(w), "encoding transcript")
+}
+
+// AddObserver adds an observer that is notified about all future phase
+// transitions, added signatures and enabled states of the channel's state
+// machine. The observer is called while the channel is locked, so it must not
+// call any other methods of the channel.
+func (c *Channel) AddObserver(o channel.MachineObserver) {
+	c.machMtx.Lock()
+	defer c.machMtx.Unlock()
+
+	c.machine.AddObserver(o)
+}
+
 // Peers returns the Perun network addresses of all remote peers, in the order
 // of the peers as channel participants. The own address is omitted.
 func (c *Channel) Peers() []wire.Address {
@@ -0,0 +1,103 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chprtest "perun.network/go-perun/channel/persistence/test"
+	"perun.network/go-perun/client"
+	ctest "perun.network/go-perun/client/test"
+	"perun.network/go-perun/pkg/test"
+)
+
+// openChannelPair creates clients for the first two setups, Alice and Bob, and
+// opens a channel with the proposal prop from Alice to Bob. The clients use
+// the funders, adjudicators and, if set, the PersistRestorers of the setups.
+// Both handle updates with uh. If it is nil, updates are ignored. Bob accepts
+// all proposals. The clients are closed when the test finishes.
+func openChannelPair(
+	t *testing.T,
+	rng *rand.Rand,
+	setups []ctest.RoleSetup,
+	prop *client.ChannelProposal,
+	uh client.UpdateHandler,
+) (alice, bob *client.Channel) {
+	var cls [2]*client.Client
+	for i := range cls {
+		c := newTestClient(t, setups[i])
+		if setups[i].PR != nil {
+			c.EnablePersistence(setups[i].PR)
+		}
+		cls[i] = c
+		t.Cleanup(func() { c.Close() })
+	}
+
+	if uh == nil {
+		uh = client.UpdateHandlerFunc(func(client.ChannelUpdate, *client.UpdateResponder) {})
+	}
+	bobCh := make(chan *client.Channel, 1)
+	go cls[1].Handle(client.ProposalHandlerFunc(
+		func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+			defer cancel()
+			part := setups[1].Wallet.NewRandomAccount(rng).Address()
+			ch, err := res.Accept(ctx, client.ProposalAcc{Participant: part})
+			assert.NoError(t, err)
+			bobCh <- ch
+		}), uh)
+	go cls[0].Handle(client.ProposalHandlerFunc(
+		func(*client.ChannelProposal, *client.ProposalResponder) {}), uh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	alice, err := cls[0].ProposeChannel(ctx, prop)
+	require.NoError(t, err)
+	select {
+	case bob = <-bobCh:
+	case <-ctx.Done():
+		t.Fatal("expected Bob's channel")
+	}
+	return alice, bob
+}
+
+// testChannelPair opens a channel between Alice and Bob with persistence
+// enabled and returns both channel controllers and persisters. Both handle
+// updates with the passed UpdateHandler. If it is nil, updates are ignored.
+func testChannelPair(t *testing.T, uh client.UpdateHandler) (alice, bob *client.Channel, prs [2]*chprtest.PersistRestorer) {
+	return testChannelPairWith(t, uh, nil)
+}
+
+// testChannelPairWith is like testChannelPair but lets modify the channel
+// proposal with the passed function, if it is not nil.
+func testChannelPairWith(t *testing.T, uh client.UpdateHandler, modify func(*client.ChannelProposal)) (alice, bob *client.Channel, prs [2]*chprtest.PersistRestorer) {
+	rng := test.Prng(t)
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	for i := range prs {
+		prs[i] = chprtest.NewPersistRestorer(t)
+		setups[i].PR = prs[i]
+	}
+	prop := newProposal(rng, setups)
+	if modify != nil {
+		modify(prop)
+	}
+	alice, bob = openChannelPair(t, rng, setups, prop, uh)
+	return alice, bob, prs
+}
@@ -87,6 +87,19 @@ func (r *chanRegistry) Get(id channel.ID) (*Channel, bool) {
 	return v, ok
 }
 
+// Channels returns all channels currently in the registry, in no particular
+// order.
+func (r *chanRegistry) Channels() []*Channel {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	chs := make([]*Channel, 0, len(r.values))
+	for _, ch := range r.values {
+		chs = append(chs, ch)
+	}
+	return chs
+}
+
 // Delete deletes a channel from the registry.
 // If the channel did not exist, does nothing. Returns whether the channel
 // existed.
@@ -16,6 +16,7 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -41,6 +42,8 @@ type Client struct {
 	adjudicator channel.Adjudicator
 	wallet      wallet.Wallet
 	pr          persistence.PersistRestorer
+	backends    channel.Backends
+	limiter     requestLimiter
 	log         log.Logger // structured logger for this client
 
 	sync.Closer
@@ -132,6 +135,24 @@ func (c *Client) EnablePersistence(pr persistence.PersistRestorer) {
 	c.pr = pr
 }
 
+// Network buses decode messages with channel.Backends, see SetBackends.
+var _ wire.Backends = channel.Backends{}
+
+// SetBackends sets the channel, wallet and app backends that the client uses
+// for creating channels and signing and verifying their states, instead of the
+// global backends. This allows to run clients with different backends side by
+// side. This method is expected to be called once during the setup of the
+// client and is hence not thread-safe.
+//
+// Channels restored from persistence use the backends that the PersistRestorer
+// decodes them with, so it should be set up with the same backends. Likewise,
+// wire messages are decoded by the bus, so a network bus must decode them with
+// the same backends, e.g., by setting them on the dialer and listener of
+// package wire/net/simple.
+func (c *Client) SetBackends(b channel.Backends) {
+	c.backends = b
+}
+
 // Channel queries a channel by its ID.
 func (c *Client) Channel(id channel.ID) (*Channel, error) {
 	if ch, ok := c.channels.Get(id); ok {
@@ -141,9 +162,13 @@ func (c *Client) Channel(id channel.ID) (*Channel, error) {
 }
 
 // Handle is the incoming request handler routine. It handles channel proposals
-// and channel update requests. It must be started exactly once by the user,
+// and channel update requests. Cooperative close requests are handled
+// automatically. It must be started exactly once by the user,
 // during the setup of the Client. Incoming requests are handled by the passed
 // respecive handlers.
+//
+// Requests that exceed the client's RequestLimits are rejected without calling
+// the handlers.
 func (c *Client) Handle(ph ProposalHandler, uh UpdateHandler) {
 	if ph == nil || uh == nil {
 		c.log.Panic("handlers must not be nil")
@@ -155,18 +180,32 @@ func (c *Client) Handle(ph ProposalHandler, uh UpdateHandler) {
 			c.log.Debug("request receiver closed: ", err)
 			return
 		}
-		msg := env.Msg
-
-		switch msg.Type() {
-		case wire.ChannelProposal:
-			go c.handleChannelProposal(ph, env.Sender, msg.(*ChannelProposal))
-		case wire.ChannelUpdate:
-			go c.handleChannelUpdate(uh, env.Sender, msg.(*msgChannelUpdate))
-		case wire.ChannelSync:
-			go c.handleSyncMsg(env.Sender, msg.(*msgChannelSync))
-		default:
-			c.log.Error("Unexpected %T message received in request loop")
+
+		release, err := c.limiter.acquire(env.Sender, time.Now())
+		if err != nil {
+			c.rejectReq(env, err)
+			continue
 		}
+		go func() {
+			defer release()
+			c.handleReq(ph, uh, env)
+		}()
+	}
+}
+
+// handleReq dispatches the request in env to the respective handler.
+func (c *Client) handleReq(ph ProposalHandler, uh UpdateHandler, env *wire.Envelope) {
+	switch msg := env.Msg; msg.Type() {
+	case wire.ChannelProposal:
+		c.handleChannelProposal(ph, env.Sender, msg.(*ChannelProposal))
+	case wire.ChannelUpdate:
+		c.handleChannelUpdate(uh, env.Sender, msg.(*msgChannelUpdate))
+	case wire.ChannelSync:
+		c.handleSyncMsg(env.Sender, msg.(*msgChannelSync))
+	case wire.ChannelClose:
+		c.handleChannelClose(env.Sender, msg.(*msgChannelClose))
+	default:
+		c.log.Errorf("Unexpected %T message received in request loop", msg)
 	}
 }
 
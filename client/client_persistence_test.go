@@ -45,6 +45,24 @@ func TestPersistencePetraRobert(t *testing.T) {
 	executeTwoPartyTest(roles, cfg)
 }
 
+func TestPersistenceArchivePetraRobert(t *testing.T) {
+	rng := test.Prng(t)
+	setups := NewSetupsPersistence(t, rng, []string{"Petra", "Robert"})
+	petra, robert := ctest.NewPetra(setups[0], t), ctest.NewRobert(setups[1], t)
+	petra.MigrateOnRestart(chprtest.NewPersistRestorer(t))
+	robert.MigrateOnRestart(chprtest.NewPersistRestorer(t))
+
+	cfg := ctest.ExecConfig{
+		PeerAddrs:  [2]wire.Address{setups[0].Identity.Address(), setups[1].Identity.Address()},
+		Asset:      chtest.NewRandomAsset(rng),
+		InitBals:   [2]*big.Int{big.NewInt(100), big.NewInt(100)},
+		NumUpdates: [2]int{2, 2},
+		TxAmounts:  [2]*big.Int{big.NewInt(5), big.NewInt(3)},
+	}
+
+	executeTwoPartyTest([2]ctest.Executer{petra, robert}, cfg)
+}
+
 func NewSetupsPersistence(t *testing.T, rng *rand.Rand, names []string) []ctest.RoleSetup {
 	setups := NewSetups(rng, names)
 	for i := range names {
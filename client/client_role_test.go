@@ -102,6 +102,11 @@ func (a *logAdjudicator) Withdraw(ctx context.Context, req channel.AdjudicatorRe
 	return nil
 }
 
+func (a *logAdjudicator) Progress(ctx context.Context, req channel.ProgressReq) error {
+	a.log.Infof("Progress: %v", req)
+	return nil
+}
+
 func (a *logAdjudicator) SubscribeRegistered(ctx context.Context, params *channel.Params) (channel.RegisteredSubscription, error) {
 	a.log.Infof("SubscribeRegistered: %v", params)
 	return nil, nil
@@ -64,6 +64,11 @@ func (d *DummyAdjudicator) Withdraw(context.Context, channel.AdjudicatorReq) err
 	return errors.New("DummyAdjudicator.Withdraw called")
 }
 
+func (d *DummyAdjudicator) Progress(context.Context, channel.ProgressReq) error {
+	d.t.Error("DummyAdjudicator.Progress called")
+	return errors.New("DummyAdjudicator.Progress called")
+}
+
 func (d *DummyAdjudicator) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
 	d.t.Error("DummyAdjudicator.SubscribeRegistered called")
 	return nil, errors.New("DummyAdjudicator.SubscribeRegistered called")
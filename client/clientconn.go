@@ -63,7 +63,8 @@ func makeClientConn(address wire.Address, bus wire.Bus) (c clientConn, err error
 func isReqMsg(m *wire.Envelope) bool {
 	return m.Msg.Type() == wire.ChannelProposal ||
 		m.Msg.Type() == wire.ChannelUpdate ||
-		m.Msg.Type() == wire.ChannelSync
+		m.Msg.Type() == wire.ChannelSync ||
+		m.Msg.Type() == wire.ChannelClose
 }
 
 func (c clientConn) nextReq(ctx context.Context) (*wire.Envelope, error) {
@@ -0,0 +1,176 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wire"
+)
+
+// closeReplyTimeout is the time to wait for the peer to answer a cooperative
+// close request before falling back to a dispute.
+var closeReplyTimeout = 10 * time.Second
+
+// CloseCooperatively closes the channel in cooperation with the peer. The
+// current state is proposed as final state with a dedicated close request,
+// which the peer approves automatically. The final state is then withdrawn
+// without registering it first and the channel is removed from persistence.
+// If the channel already is in the Final phase, the proposal is skipped.
+//
+// If the peer rejects the close request or does not answer within the close
+// reply timeout, the channel is settled by a dispute instead. This call blocks
+// until the channel has been successfully withdrawn.
+//
+// The channel controller is not closed by this call.
+func (c *Channel) CloseCooperatively(ctx context.Context) error {
+	if ctx == nil {
+		return errors.New("context must not be nil")
+	}
+	if !c.machMtx.TryLockCtx(ctx) {
+		return errors.Errorf("locking machine mutex in time: %v", ctx.Err())
+	}
+	defer c.machMtx.Unlock()
+	// Wrap the context to make sure that the close call stops as soon as the
+	// channel controller is closed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.OnClose(cancel)
+
+	if c.machine.Phase() == channel.Acting {
+		if err := c.proposeFinal(ctx); err != nil {
+			c.Log().Warnf("Cooperative close failed, falling back to dispute: %v", err)
+		}
+	}
+
+	if c.machine.Phase() == channel.Final {
+		if err := c.withdrawFinal(ctx); err != nil {
+			return err
+		}
+	} else if err := c.settle(ctx); err != nil {
+		return errors.WithMessage(err, "settling")
+	}
+
+	return errors.WithMessage(c.pr.ChannelRemoved(ctx, c.ID()), "removing channel from persistence")
+}
+
+// proposeFinal sends a cooperative close request to the peer, proposing the
+// current state as final state.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) proposeFinal(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, closeReplyTimeout)
+	defer cancel()
+
+	state := c.machine.State().Clone()
+	state.Version++
+	state.IsFinal = true
+	return c.update(ctx, ChannelUpdate{State: state, ActorIdx: c.machine.Idx()}, true)
+}
+
+// withdrawFinal withdraws the final current state without registering it
+// first.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) withdrawFinal(ctx context.Context) error {
+	if err := c.withdraw(ctx); err != nil {
+		return errors.WithMessage(err, "withdrawing final state")
+	}
+	c.Log().Info("Withdrawal of final state successful.")
+	c.wallet.DecrementUsage(c.machine.Account().Address())
+	return nil
+}
+
+// handleChannelClose forwards incoming cooperative close requests to the
+// respective channel's close handler (Channel.handleCloseReq). If the channel
+// is unknown, the request is rejected.
+//
+// This handler is dispatched from the Client.Handle routine.
+func (c *Client) handleChannelClose(p wire.Address, m *msgChannelClose) {
+	ch, ok := c.channels.Get(m.ID())
+	if !ok {
+		log := c.logChan(m.ID()).WithField("peer", p)
+		log.Error("received close request for unknown channel")
+
+		ctx, cancel := context.WithTimeout(c.Ctx(), closeReplyTimeout)
+		defer cancel()
+		if err := c.rejectUpdate(ctx, p, &m.msgChannelUpdate, "unknown channel"); err != nil {
+			log.Errorf("error sending close rejection: %v", err)
+		}
+		return
+	}
+	pidx := ch.Idx() ^ 1
+	ch.handleCloseReq(pidx, m)
+}
+
+// handleCloseReq is called by the controller on incoming cooperative close
+// requests. Valid requests are accepted and the final state is withdrawn and
+// removed from persistence. Invalid requests are rejected.
+func (c *Channel) handleCloseReq(pidx channel.Index, req *msgChannelClose) {
+	log := c.logPeer(pidx)
+	ctx := c.Ctx()
+	// Lock machine while close is in progress.
+	if !c.machMtx.TryLockCtx(ctx) {
+		log.Errorf("Could not lock machine mutex in time: %v", ctx.Err())
+		return
+	}
+	defer c.machMtx.Unlock()
+
+	replyCtx, cancel := context.WithTimeout(ctx, closeReplyTimeout)
+	defer cancel()
+	if err := c.validCloseReq(req, pidx); err != nil {
+		log.Warnf("invalid close request received: %v", err)
+		// nolint:errcheck,gosec
+		c.handleUpdateRej(replyCtx, pidx, &req.msgChannelUpdate, err.Error()) // logs error
+		return
+	}
+	if err := c.handleUpdateAcc(replyCtx, pidx, &req.msgChannelUpdate); err != nil {
+		return // logged by handleUpdateAcc
+	}
+	log.Info("Accepted cooperative close request.")
+
+	if err := c.withdrawFinal(ctx); err != nil {
+		log.Errorf("Error withdrawing closed channel: %v", err)
+		return
+	}
+	if err := c.pr.ChannelRemoved(ctx, c.ID()); err != nil {
+		log.Errorf("Error removing closed channel from persistence: %v", err)
+	}
+}
+
+// validCloseReq checks that the close request is a valid update by the peer
+// that only finalizes the current state.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) validCloseReq(req *msgChannelClose, pidx channel.Index) error {
+	if c.machine.Phase() != channel.Acting {
+		return errors.Errorf("cannot close channel in phase %v", c.machine.Phase())
+	}
+	if err := c.validTwoPartyUpdate(req.ChannelUpdate, pidx); err != nil {
+		return err
+	}
+	if err := c.machine.CheckUpdate(req.State, req.ActorIdx, req.Sig, pidx); err != nil {
+		return err
+	}
+
+	final := c.machine.State().Clone()
+	final.Version++
+	final.IsFinal = true
+	return errors.WithMessage(req.State.Equal(final), "close request does not finalize current state")
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+)
+
+func TestChannel_CloseCooperatively(t *testing.T) {
+	t.Run("cooperative", func(t *testing.T) {
+		alice, bob, prs := testChannelPair(t, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+
+		require.NoError(t, alice.CloseCooperatively(ctx))
+		assert.True(t, alice.State().IsFinal)
+		assert.Equal(t, channel.Withdrawn, alice.Phase())
+		assert.Eventually(t, func() bool { return bob.Phase() == channel.Withdrawn },
+			defaultTimeout, 10*time.Millisecond)
+		assert.True(t, bob.State().IsFinal)
+		for _, pr := range prs {
+			_, err := pr.RestoreChannel(ctx, alice.ID())
+			assert.Error(t, err, "channel should be removed from persistence")
+		}
+	})
+
+	t.Run("dispute fallback", func(t *testing.T) {
+		alice, bob, prs := testChannelPair(t, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+
+		// Bob forgets about the channel, so he rejects the close request.
+		require.NoError(t, bob.Close())
+		require.NoError(t, alice.CloseCooperatively(ctx))
+		assert.False(t, alice.State().IsFinal)
+		assert.Equal(t, channel.Withdrawn, alice.Phase())
+		_, err := prs[0].RestoreChannel(ctx, alice.ID())
+		assert.Error(t, err, "channel should be removed from persistence")
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// An EscalationPolicy configures when a channel controller gives up on an
+	// unresponsive peer and escalates to an on-chain dispute. An update is
+	// unanswered if the update request could not be sent or no response was
+	// received before the update's context expired. A rejection is an answer.
+	//
+	// The zero value disables escalation.
+	EscalationPolicy struct {
+		// MaxUnanswered is the number of consecutive unanswered updates after
+		// which the channel is disputed. Zero disables this trigger.
+		MaxUnanswered int
+		// SilenceTimeout is the duration after the first of a series of
+		// unanswered updates after which the channel is disputed if the peer
+		// stayed silent. Zero disables this trigger.
+		SilenceTimeout time.Duration
+	}
+
+	// EscalationReason is the reason why a channel was escalated to an on-chain
+	// dispute.
+	EscalationReason uint8
+
+	// An EscalationEvent is emitted after a channel controller escalated to an
+	// on-chain dispute. The latest fully signed state was registered and
+	// settled, unless SettleErr is set.
+	EscalationEvent struct {
+		Reason     EscalationReason // Reason is the trigger of the escalation.
+		Unanswered int              // Unanswered is the number of consecutive unanswered updates.
+		UpdateErr  error            // UpdateErr is the error of the last unanswered update.
+		SettleErr  error            // SettleErr is the error of the settlement, if any.
+	}
+
+	// escalator tracks unanswered updates of a channel according to an
+	// EscalationPolicy.
+	escalator struct {
+		mu         sync.Mutex
+		policy     EscalationPolicy
+		handler    func(EscalationEvent)
+		unanswered int
+		lastErr    error
+		silence    *time.Timer
+		escalated  bool
+	}
+)
+
+// Escalation reasons.
+const (
+	// EscalationMaxUnanswered signals that EscalationPolicy.MaxUnanswered
+	// consecutive updates were not answered.
+	EscalationMaxUnanswered EscalationReason = iota
+	// EscalationSilence signals that the peer stayed silent for
+	// EscalationPolicy.SilenceTimeout.
+	EscalationSilence
+)
+
+func (r EscalationReason) String() string {
+	switch r {
+	case EscalationMaxUnanswered:
+		return "MaxUnanswered"
+	case EscalationSilence:
+		return "Silence"
+	}
+	return fmt.Sprintf("EscalationReason(%d)", uint8(r))
+}
+
+func (e EscalationEvent) String() string {
+	return fmt.Sprintf("escalated (%v) after %d unanswered updates: %v",
+		e.Reason, e.Unanswered, e.UpdateErr)
+}
+
+// SetEscalationPolicy sets the policy after which the channel is escalated to
+// an on-chain dispute if the peer stops answering updates. Repeated calls
+// replace the policy. Counting of unanswered updates starts anew. This function
+// may be safely called at any time.
+func (c *Channel) SetEscalationPolicy(p EscalationPolicy) {
+	c.escalator.mu.Lock()
+	defer c.escalator.mu.Unlock()
+
+	c.escalator.policy = p
+	c.escalator.reset()
+}
+
+// OnEscalation sets a callback to be called after the channel was escalated to
+// an on-chain dispute because of its EscalationPolicy. Only one such handler
+// can be set at a time, and repeated calls to this function will overwrite the
+// currently existing handler. This function may be safely called at any time.
+func (c *Channel) OnEscalation(handler func(EscalationEvent)) {
+	c.escalator.mu.Lock()
+	defer c.escalator.mu.Unlock()
+
+	c.escalator.handler = handler
+}
+
+// updateAnswered is called by Update when the peer answered an update.
+func (c *Channel) updateAnswered() {
+	c.escalator.mu.Lock()
+	defer c.escalator.mu.Unlock()
+
+	c.escalator.reset()
+}
+
+// updateUnanswered is called by Update when an update could not be sent or
+// no response was received. It escalates the channel in a new go routine if
+// the escalation policy says so.
+func (c *Channel) updateUnanswered(err error) {
+	e := &c.escalator
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.escalated {
+		return
+	}
+	e.unanswered++
+	e.lastErr = err
+
+	if e.unanswered == 1 && e.policy.SilenceTimeout > 0 {
+		e.silence = time.AfterFunc(e.policy.SilenceTimeout, func() {
+			c.escalate(EscalationSilence)
+		})
+	}
+	if e.policy.MaxUnanswered > 0 && e.unanswered >= e.policy.MaxUnanswered {
+		go c.escalate(EscalationMaxUnanswered)
+	}
+}
+
+// escalate settles the channel, registering the latest fully signed state, and
+// calls the escalation handler afterwards. A channel is escalated at most once.
+func (c *Channel) escalate(reason EscalationReason) {
+	e := &c.escalator
+	e.mu.Lock()
+	if e.escalated || c.IsClosed() {
+		e.mu.Unlock()
+		return
+	}
+	e.escalated = true
+	if e.silence != nil {
+		e.silence.Stop()
+	}
+	ev := EscalationEvent{
+		Reason:     reason,
+		Unanswered: e.unanswered,
+		UpdateErr:  e.lastErr,
+	}
+	e.mu.Unlock()
+
+	c.Log().Warnf("Peer unresponsive, escalating to dispute: %v", ev)
+	if ev.SettleErr = c.Settle(c.Ctx()); ev.SettleErr != nil {
+		c.Log().Errorf("Settling escalated channel: %v", ev.SettleErr)
+	}
+
+	e.mu.Lock()
+	handler := e.handler
+	e.mu.Unlock()
+	if handler != nil {
+		handler(ev)
+	}
+}
+
+// reset resets the count of unanswered updates and stops the silence timer.
+// The caller is expected to have locked the escalator mutex.
+func (e *escalator) reset() {
+	e.unanswered = 0
+	e.lastErr = nil
+	if e.silence != nil {
+		e.silence.Stop()
+		e.silence = nil
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/payment"
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	ctest "perun.network/go-perun/client/test"
+	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wire"
+)
+
+func TestChannel_Escalation(t *testing.T) {
+	t.Run("MaxUnanswered", func(t *testing.T) {
+		ev := testEscalation(t, client.EscalationPolicy{MaxUnanswered: 2}, 2)
+		assert.Equal(t, client.EscalationMaxUnanswered, ev.Reason)
+		assert.Equal(t, 2, ev.Unanswered)
+	})
+
+	t.Run("Silence", func(t *testing.T) {
+		ev := testEscalation(t, client.EscalationPolicy{SilenceTimeout: 100 * time.Millisecond}, 1)
+		assert.Equal(t, client.EscalationSilence, ev.Reason)
+		assert.Equal(t, 1, ev.Unanswered)
+	})
+}
+
+// testEscalation opens a channel to a peer that never answers updates, sends
+// numUpdates updates and returns the resulting EscalationEvent.
+func testEscalation(t *testing.T, policy client.EscalationPolicy, numUpdates int) client.EscalationEvent {
+	rng := test.Prng(t)
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	// Bob stays silent on updates.
+	ch, _ := openChannelPair(t, rng, setups, newProposal(rng, setups), nil)
+
+	events := make(chan client.EscalationEvent, 1)
+	ch.OnEscalation(func(ev client.EscalationEvent) { events <- ev })
+	ch.SetEscalationPolicy(policy)
+
+	for i := 0; i < numUpdates; i++ {
+		upCtx, upCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		err := ch.UpdateBy(upCtx, func(s *channel.State) {
+			s.Balances[0][0].Sub(s.Balances[0][0], big.NewInt(1))
+			s.Balances[0][1].Add(s.Balances[0][1], big.NewInt(1))
+		})
+		upCancel()
+		require.Error(t, err)
+	}
+
+	select {
+	case ev := <-events:
+		assert.Error(t, ev.UpdateErr)
+		assert.NoError(t, ev.SettleErr)
+		assert.Equal(t, channel.Withdrawn, ch.Phase())
+		return ev
+	case <-time.After(defaultTimeout):
+		t.Fatal("expected escalation")
+	}
+	return client.EscalationEvent{}
+}
+
+func newTestClient(t *testing.T, setup ctest.RoleSetup) *client.Client {
+	c, err := client.New(setup.Identity.Address(), setup.Bus, setup.Funder, setup.Adjudicator, setup.Wallet)
+	require.NoError(t, err)
+	return c
+}
+
+func newProposal(rng *rand.Rand, setups []ctest.RoleSetup) *client.ChannelProposal {
+	return &client.ChannelProposal{
+		ChallengeDuration: 60,
+		Nonce:             big.NewInt(rng.Int63()),
+		ParticipantAddr:   setups[0].Wallet.NewRandomAccount(rng).Address(),
+		AppDef:            payment.AppDef(),
+		InitData:          new(payment.NoData),
+		InitBals: &channel.Allocation{
+			Assets:   []channel.Asset{chtest.NewRandomAsset(rng)},
+			Balances: [][]channel.Bal{{big.NewInt(100), big.NewInt(100)}},
+		},
+		PeerAddrs: []wire.Address{setups[0].Identity.Address(), setups[1].Identity.Address()},
+	}
+}
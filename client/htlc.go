@@ -0,0 +1,95 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/channel"
+)
+
+// LockHTLC proposes to lock amount of the asset with index asset under hash h
+// for the peer in a channel of the HTLC app. The peer can claim the funds by
+// revealing the preimage of h with UnlockHTLC. After expiry, the funds can be
+// refunded with RefundHTLC.
+func (c *Channel) LockHTLC(ctx context.Context, asset int, amount channel.Bal, h htlc.Hash, expiry time.Time) error {
+	return c.updateHTLC(ctx, func(s *channel.State) error {
+		return htlc.AddLock(s, htlc.Lock{
+			Hash:     h,
+			Asset:    asset,
+			Amount:   amount,
+			Sender:   c.Idx(),
+			Receiver: c.Idx() ^ 1,
+			Expiry:   uint64(expiry.Unix()),
+		})
+	})
+}
+
+// UnlockHTLC proposes to resolve the lock of the hash of preimage p in favor of
+// its receiver by revealing p.
+func (c *Channel) UnlockHTLC(ctx context.Context, p htlc.Preimage) error {
+	return c.updateHTLC(ctx, func(s *channel.State) error {
+		return htlc.Unlock(s, p)
+	})
+}
+
+// RefundHTLC proposes to refund the expired lock with hash h to its sender.
+func (c *Channel) RefundHTLC(ctx context.Context, h htlc.Hash) error {
+	return c.updateHTLC(ctx, func(s *channel.State) error {
+		return htlc.Refund(s, h)
+	})
+}
+
+// CancelHTLC proposes to cancel the lock with hash h, of which the own
+// participant is the receiver, refunding its sender.
+func (c *Channel) CancelHTLC(ctx context.Context, h htlc.Hash) error {
+	return c.updateHTLC(ctx, func(s *channel.State) error {
+		return htlc.Cancel(s, h)
+	})
+}
+
+// HTLCs returns the pending locks of a channel of the HTLC app.
+func (c *Channel) HTLCs() ([]htlc.Lock, error) {
+	d, ok := c.State().Data.(*htlc.Data)
+	if !ok {
+		return nil, errors.Errorf("channel app data is not HTLC data, but %T", c.State().Data)
+	}
+	return d.Clone().(*htlc.Data).Locks, nil
+}
+
+// updateHTLC proposes the update of the current state by the HTLC update
+// function. The state's time is set to the current time first.
+func (c *Channel) updateHTLC(ctx context.Context, update func(*channel.State) error) error {
+	if _, ok := c.Params().App.(*htlc.App); !ok {
+		return errors.Errorf("channel app is not the HTLC app, but %T", c.Params().App)
+	}
+	state := c.State().Clone()
+	if err := htlc.SetTime(state, time.Now()); err != nil {
+		return err
+	}
+	if err := update(state); err != nil {
+		return err
+	}
+	state.Version++
+
+	return c.Update(ctx, ChannelUpdate{
+		State:    state,
+		ActorIdx: c.Idx(),
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/htlc"
+	simledger "perun.network/go-perun/backend/sim/ledger"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/test"
+)
+
+func TestChannel_HTLC(t *testing.T) {
+	rng := test.Prng(t)
+	acceptAll := client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		assert.NoError(t, res.Accept(ctx))
+	})
+	alice, bob, _ := testChannelPairWith(t, acceptAll, func(prop *client.ChannelProposal) {
+		prop.AppDef = htlc.AppDef()
+		prop.InitData = new(htlc.Data)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	balances := func() []int64 {
+		bals := alice.State().Balances[0]
+		return []int64{bals[0].Int64(), bals[1].Int64()}
+	}
+
+	// Alice locks 30 for Bob, Bob unlocks by revealing the preimage.
+	preimage := htlc.NewRandomPreimage(rng)
+	hash := htlc.HashPreimage(preimage)
+	require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(30), hash, time.Now().Add(time.Hour)))
+	locks, err := bob.HTLCs()
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	assert.Equal(t, hash, locks[0].Hash)
+	assert.Equal(t, []int64{70, 100}, balances(), "locking should move the funds out of the balance")
+	assert.Len(t, alice.State().Locked, 1)
+
+	assert.Error(t, bob.RefundHTLC(ctx, hash), "refund before expiry should fail")
+	assert.Error(t, bob.UnlockHTLC(ctx, htlc.NewRandomPreimage(rng)), "unlock with wrong preimage should fail")
+	require.NoError(t, bob.UnlockHTLC(ctx, preimage))
+	assert.Equal(t, []int64{70, 130}, balances())
+	assert.Empty(t, alice.State().Locked)
+	locks, err = alice.HTLCs()
+	require.NoError(t, err)
+	assert.Empty(t, locks)
+
+	// Expired locks cannot be added. Bob cancels a lock of 20 by Alice.
+	hash = htlc.HashPreimage(htlc.NewRandomPreimage(rng))
+	assert.Error(t, alice.LockHTLC(ctx, 0, big.NewInt(20), hash, time.Now().Add(-time.Second)),
+		"adding an expired lock should fail")
+	require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(20), hash, time.Now().Add(time.Hour)))
+	assert.Equal(t, []int64{50, 130}, balances())
+	require.NoError(t, bob.CancelHTLC(ctx, hash))
+	assert.Equal(t, []int64{70, 130}, balances())
+
+	// Locked funds cannot be transferred.
+	require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(60), hash, time.Now().Add(time.Hour)))
+	assert.Error(t, alice.UpdateBy(ctx, func(s *channel.State) {
+		s.Balances[0][0].Sub(s.Balances[0][0], big.NewInt(20))
+		s.Balances[0][1].Add(s.Balances[0][1], big.NewInt(20))
+	}), "transfer of locked funds should fail")
+}
+
+// TestChannel_HTLCForceRefund checks that a sender cannot refund a lock
+// on-chain before its expiry by setting the time of the state to the expiry.
+func TestChannel_HTLCForceRefund(t *testing.T) {
+	rng := test.Prng(t)
+	alice, _, _ := openHTLCLedgerPair(t, rng)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hash := htlc.HashPreimage(htlc.NewRandomPreimage(rng))
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(30), hash, expiry))
+	err := alice.ForceUpdate(ctx, func(s *channel.State) {
+		assert.NoError(t, htlc.SetTime(s, expiry))
+		assert.NoError(t, htlc.Refund(s, hash))
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ledger time")
+}
+
+// TestChannel_HTLCDispute settles HTLC channels with a pending lock on-chain.
+// The locked funds are refunded after the expiry or paid to the receiver if it
+// reveals the preimage on-chain.
+func TestChannel_HTLCDispute(t *testing.T) {
+	t.Run("refund", func(t *testing.T) {
+		rng := test.Prng(t)
+		alice, _, ledger := openHTLCLedgerPair(t, rng)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		hash := htlc.HashPreimage(htlc.NewRandomPreimage(rng))
+		expiry := time.Now().Add(2 * time.Second)
+		require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(30), hash, expiry))
+		time.Sleep(time.Until(expiry.Add(time.Second)))
+		require.NoError(t, alice.Settle(ctx))
+
+		asset := alice.State().Assets[0].(*simledger.Asset)
+		assert.Zero(t, big.NewInt(100).Cmp(ledger.Balance(alice.Params().Parts[0], asset)),
+			"locked funds should be refunded")
+	})
+
+	t.Run("reveal", func(t *testing.T) {
+		rng := test.Prng(t)
+		alice, bob, ledger := openHTLCLedgerPair(t, rng)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		preimage := htlc.NewRandomPreimage(rng)
+		require.NoError(t, alice.LockHTLC(ctx, 0, big.NewInt(30), htlc.HashPreimage(preimage), time.Now().Add(time.Hour)))
+		require.NoError(t, bob.ForceUpdate(ctx, func(s *channel.State) {
+			assert.NoError(t, htlc.SetTime(s, time.Now()))
+			assert.NoError(t, htlc.Unlock(s, preimage))
+		}))
+		require.NoError(t, bob.Settle(ctx))
+
+		asset := bob.State().Assets[0].(*simledger.Asset)
+		assert.Zero(t, big.NewInt(130).Cmp(ledger.Balance(bob.Params().Parts[1], asset)),
+			"locked funds should be paid to the receiver")
+	})
+}
+
+// openHTLCLedgerPair opens an HTLC channel with a challenge duration of one
+// second between Alice and Bob on a simulated ledger. Both accept all updates.
+func openHTLCLedgerPair(t *testing.T, rng *rand.Rand) (alice, bob *client.Channel, ledger *simledger.Ledger) {
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	ledger = simledger.NewLedger("chain")
+	for i := range setups {
+		setups[i].Funder, setups[i].Adjudicator = ledger, ledger
+	}
+	acceptAll := client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		assert.NoError(t, res.Accept(ctx))
+	})
+	prop := newProposal(rng, setups)
+	prop.ChallengeDuration = 1
+	prop.AppDef = htlc.AppDef()
+	prop.InitData = new(htlc.Data)
+	prop.InitBals.Assets = []channel.Asset{ledger.NewAsset(rng.Int63())}
+	alice, bob = openChannelPair(t, rng, setups, prop, acceptAll)
+	return alice, bob, ledger
+}
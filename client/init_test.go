@@ -19,6 +19,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"perun.network/go-perun/apps/htlc"
 	"perun.network/go-perun/apps/payment"
 	_ "perun.network/go-perun/backend/sim" // backend init
 	plogrus "perun.network/go-perun/log/logrus"
@@ -35,4 +36,5 @@ func init() {
 	rng := rand.New(rand.NewSource(pkgtest.Seed("test app def")))
 	appDef := wallettest.NewRandomAddress(rng)
 	payment.SetAppDef(appDef) // payment app address has to be set once at startup
+	htlc.SetAppDef(wallettest.NewRandomAddress(rng))
 }
@@ -0,0 +1,306 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+// rejectTimeout is the time to wait for sending the rejection of a request that
+// exceeds the RequestLimits.
+var rejectTimeout = 1 * time.Second
+
+// maxPendingRejections is the maximum number of rejections that are sent
+// concurrently. Rejections of further requests are dropped.
+const maxPendingRejections = 16
+
+// maxIdlePeerBuckets is the number of per-peer rate limiting buckets above
+// which full buckets are pruned.
+const maxIdlePeerBuckets = 1024
+
+var (
+	errConcurrencyLimit = errors.New("too many concurrent requests")
+	errRateLimit        = errors.New("request rate limit exceeded")
+)
+
+type (
+	// RequestLimits limit the handling of incoming channel proposals, update,
+	// close and sync requests in Client.Handle. Requests that exceed a limit are
+	// rejected. A zero value of any limit means no limit. The zero
+	// RequestLimits do not limit the handling of requests at all.
+	RequestLimits struct {
+		// MaxConcurrent is the maximum number of requests that are handled
+		// concurrently.
+		MaxConcurrent int
+		// MaxConcurrentPerPeer is the maximum number of requests from a single
+		// peer that are handled concurrently.
+		MaxConcurrentPerPeer int
+
+		// Rate is the maximum number of requests per second that are handled.
+		Rate float64
+		// Burst is the maximum number of requests that are handled at once,
+		// exceeding the Rate. If it is zero, it defaults to the Rate, rounded
+		// up.
+		Burst int
+		// RatePerPeer is the maximum number of requests per second from a
+		// single peer that are handled.
+		RatePerPeer float64
+		// BurstPerPeer is the maximum number of requests from a single peer
+		// that are handled at once, exceeding the RatePerPeer. If it is zero, it
+		// defaults to the RatePerPeer, rounded up.
+		BurstPerPeer int
+	}
+
+	// RequestStats are counters of the incoming requests received in
+	// Client.Handle.
+	RequestStats struct {
+		// Handled is the number of requests that were handled.
+		Handled uint64
+		// RejectedConcurrency is the number of requests that were rejected
+		// because of too many concurrently handled requests.
+		RejectedConcurrency uint64
+		// RejectedRate is the number of requests that were rejected because the
+		// request rate was exceeded.
+		RejectedRate uint64
+		// DroppedRejections is the number of rejected requests whose rejection
+		// message was not sent because too many rejections were pending.
+		DroppedRejections uint64
+	}
+
+	// requestLimiter enforces the RequestLimits. Its zero value is ready to use
+	// and does not limit requests.
+	requestLimiter struct {
+		mu          sync.Mutex
+		limits      RequestLimits
+		active      int
+		peerActive  map[wallet.AddrKey]int
+		bucket      tokenBucket
+		peerBuckets map[wallet.AddrKey]*tokenBucket
+		rejecting   int // number of pending rejections
+		stats       RequestStats
+	}
+
+	// tokenBucket is a token bucket for rate limiting. A full bucket holds
+	// burst tokens and it is refilled at a rate of rate tokens per second.
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// SetRequestLimits sets the limits for handling incoming requests. It may be
+// called at any time and takes effect for all subsequent requests.
+func (c *Client) SetRequestLimits(limits RequestLimits) {
+	c.limiter.setLimits(limits)
+}
+
+// RequestStats returns the current counters of incoming requests.
+func (c *Client) RequestStats() RequestStats {
+	return c.limiter.Stats()
+}
+
+// rejectReq rejects the request in env because it exceeds the request limits.
+// Channel proposals and update and close requests are answered with the
+// respective rejection message. Other requests are dropped.
+//
+// The rejection is sent in the background, so that a slow peer does not block
+// the request loop. If too many rejections are pending, the request is dropped
+// without a rejection.
+func (c *Client) rejectReq(env *wire.Envelope, reason error) {
+	log := c.logPeer(env.Sender)
+	log.Warnf("Rejecting %v request: %v", env.Msg.Type(), reason)
+
+	if !c.limiter.acquireRejection() {
+		log.Debug("Too many pending rejections, dropping rejection.")
+		return
+	}
+	go func() {
+		defer c.limiter.releaseRejection()
+		ctx, cancel := context.WithTimeout(c.Ctx(), rejectTimeout)
+		defer cancel()
+		var err error
+		switch msg := env.Msg.(type) {
+		case *ChannelProposal:
+			err = c.handleChannelProposalRej(ctx, env.Sender, msg, reason.Error())
+		case *msgChannelUpdate:
+			err = c.rejectUpdate(ctx, env.Sender, msg, reason.Error())
+		case *msgChannelClose:
+			err = c.rejectUpdate(ctx, env.Sender, &msg.msgChannelUpdate, reason.Error())
+		}
+		if err != nil {
+			log.Errorf("error sending rejection: %v", err)
+		}
+	}()
+}
+
+// rejectUpdate sends an update rejection for the update request m to peer p.
+// Contrary to Channel.handleUpdateRej, it does not require the channel to be
+// known.
+func (c *Client) rejectUpdate(ctx context.Context, p wire.Address, m *msgChannelUpdate, reason string) error {
+	msgRej := &msgChannelUpdateRej{
+		ChannelID: m.ID(),
+		Version:   m.State.Version,
+		Reason:    reason,
+	}
+	return c.conn.pubMsg(ctx, msgRej, p)
+}
+
+func (l *requestLimiter) setLimits(limits RequestLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	// Reset the buckets so that they are refilled according to the new limits.
+	l.bucket = tokenBucket{}
+	l.peerBuckets = nil
+}
+
+// Stats returns a snapshot of the request counters.
+func (l *requestLimiter) Stats() RequestStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// acquire checks whether a request from peer p may be handled at time now. If
+// so, it returns a function that must be called when handling the request is
+// done. Otherwise, errConcurrencyLimit or errRateLimit is returned.
+func (l *requestLimiter) acquire(p wire.Address, now time.Time) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := wallet.Key(p)
+	lim := l.limits
+	if (lim.MaxConcurrent > 0 && l.active >= lim.MaxConcurrent) ||
+		(lim.MaxConcurrentPerPeer > 0 && l.peerActive[key] >= lim.MaxConcurrentPerPeer) {
+		l.stats.RejectedConcurrency++
+		return nil, errConcurrencyLimit
+	}
+
+	global := l.bucket.refill(now, lim.Rate, lim.Burst)
+	peer := l.peerBucket(key, now)
+	if global < 1 || peer < 1 {
+		l.stats.RejectedRate++
+		return nil, errRateLimit
+	}
+	if lim.Rate > 0 {
+		l.bucket.tokens--
+	}
+	if lim.RatePerPeer > 0 {
+		l.peerBuckets[key].tokens--
+	}
+
+	if l.peerActive == nil {
+		l.peerActive = make(map[wallet.AddrKey]int)
+	}
+	l.active++
+	l.peerActive[key]++
+	l.stats.Handled++
+
+	var once sync.Once
+	return func() { once.Do(func() { l.release(key) }) }, nil
+}
+
+func (l *requestLimiter) release(key wallet.AddrKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.peerActive[key]--; l.peerActive[key] <= 0 {
+		delete(l.peerActive, key)
+	}
+}
+
+// acquireRejection reserves one of the maxPendingRejections slots for sending
+// a rejection. If all are taken, the dropped rejection is counted and false is
+// returned.
+func (l *requestLimiter) acquireRejection() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rejecting >= maxPendingRejections {
+		l.stats.DroppedRejections++
+		return false
+	}
+	l.rejecting++
+	return true
+}
+
+func (l *requestLimiter) releaseRejection() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rejecting--
+}
+
+// peerBucket refills the rate limiting bucket of the peer and returns the
+// available tokens. If there is no per-peer rate limit, +Inf is returned.
+//
+// The caller is expected to have locked the limiter mutex.
+func (l *requestLimiter) peerBucket(key wallet.AddrKey, now time.Time) float64 {
+	lim := l.limits
+	if lim.RatePerPeer <= 0 {
+		return math.Inf(1)
+	}
+	if l.peerBuckets == nil {
+		l.peerBuckets = make(map[wallet.AddrKey]*tokenBucket)
+	}
+	if len(l.peerBuckets) > maxIdlePeerBuckets {
+		burst := burstOrRate(lim.BurstPerPeer, lim.RatePerPeer)
+		for k, b := range l.peerBuckets {
+			if b.refill(now, lim.RatePerPeer, lim.BurstPerPeer) >= burst {
+				delete(l.peerBuckets, k)
+			}
+		}
+	}
+
+	b, ok := l.peerBuckets[key]
+	if !ok {
+		b = new(tokenBucket)
+		l.peerBuckets[key] = b
+	}
+	return b.refill(now, lim.RatePerPeer, lim.BurstPerPeer)
+}
+
+// refill refills the bucket for the time passed since the last refill and
+// returns the available tokens. A new bucket starts full. If rate is not
+// positive, +Inf is returned.
+func (b *tokenBucket) refill(now time.Time, rate float64, burst int) float64 {
+	if rate <= 0 {
+		return math.Inf(1)
+	}
+	max := burstOrRate(burst, rate)
+	if b.last.IsZero() {
+		b.tokens = max
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(max, b.tokens+elapsed*rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	return b.tokens
+}
+
+// burstOrRate returns burst if it is positive and otherwise rate, rounded up.
+func burstOrRate(burst int, rate float64) float64 {
+	if burst > 0 {
+		return float64(burst)
+	}
+	return math.Ceil(rate)
+}
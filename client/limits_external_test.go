@@ -0,0 +1,55 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/test"
+)
+
+func TestClient_RequestLimits(t *testing.T) {
+	rng := test.Prng(t)
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	alice, bob := newTestClient(t, setups[0]), newTestClient(t, setups[1])
+	defer alice.Close()
+	defer bob.Close()
+
+	// Bob rejects all proposals but only handles one proposal per peer.
+	bob.SetRequestLimits(client.RequestLimits{RatePerPeer: 0.001, BurstPerPeer: 1})
+	noUpdates := client.UpdateHandlerFunc(func(client.ChannelUpdate, *client.UpdateResponder) {})
+	go bob.Handle(client.ProposalHandlerFunc(
+		func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+			defer cancel()
+			assert.NoError(t, res.Reject(ctx, "not today"))
+		}), noUpdates)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, err := alice.ProposeChannel(ctx, newProposal(rng, setups))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not today")
+
+	_, err = alice.ProposeChannel(ctx, newProposal(rng, setups))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit")
+	assert.Equal(t, client.RequestStats{Handled: 1, RejectedRate: 1}, bob.RequestStats())
+}
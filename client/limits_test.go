@@ -0,0 +1,112 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgtest "perun.network/go-perun/pkg/test"
+	wallettest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+func TestRequestLimiter_Concurrency(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	peerA, peerB := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+	var l requestLimiter
+	l.setLimits(RequestLimits{MaxConcurrent: 3, MaxConcurrentPerPeer: 2})
+	now := time.Now()
+
+	relA1, err := l.acquire(peerA, now)
+	require.NoError(t, err)
+	_, err = l.acquire(peerA, now)
+	require.NoError(t, err)
+	_, err = l.acquire(peerA, now)
+	assert.Equal(t, errConcurrencyLimit, err, "per-peer limit")
+
+	_, err = l.acquire(peerB, now)
+	require.NoError(t, err)
+	_, err = l.acquire(peerB, now)
+	assert.Equal(t, errConcurrencyLimit, err, "global limit")
+
+	relA1()
+	relA1() // releasing twice must not free another slot
+	_, err = l.acquire(peerB, now)
+	require.NoError(t, err)
+	_, err = l.acquire(peerA, now)
+	assert.Equal(t, errConcurrencyLimit, err)
+
+	assert.Equal(t, RequestStats{Handled: 4, RejectedConcurrency: 3}, l.Stats())
+}
+
+func TestRequestLimiter_Rate(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	peerA, peerB := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+	var l requestLimiter
+	l.setLimits(RequestLimits{Rate: 3, RatePerPeer: 1, BurstPerPeer: 2})
+	now := time.Now()
+
+	acquire := func(p wire.Address, now time.Time) error {
+		release, err := l.acquire(p, now)
+		if err == nil {
+			release()
+		}
+		return err
+	}
+
+	require.NoError(t, acquire(peerA, now))
+	require.NoError(t, acquire(peerA, now))
+	assert.Equal(t, errRateLimit, acquire(peerA, now), "per-peer burst")
+	require.NoError(t, acquire(peerB, now))
+	assert.Equal(t, errRateLimit, acquire(peerB, now), "global burst")
+
+	// After one second, the global bucket holds three and each peer bucket
+	// one new token.
+	now = now.Add(time.Second)
+	require.NoError(t, acquire(peerA, now))
+	assert.Equal(t, errRateLimit, acquire(peerA, now))
+	require.NoError(t, acquire(peerB, now))
+	require.NoError(t, acquire(peerB, now))
+	assert.Equal(t, errRateLimit, acquire(peerB, now))
+
+	assert.Equal(t, RequestStats{Handled: 6, RejectedRate: 4}, l.Stats())
+}
+
+func TestRequestLimiter_Unlimited(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	peer := wallettest.NewRandomAddress(rng)
+	var l requestLimiter
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		_, err := l.acquire(peer, now)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, RequestStats{Handled: 100}, l.Stats())
+}
+
+func TestRequestLimiter_Rejections(t *testing.T) {
+	var l requestLimiter
+	for i := 0; i < maxPendingRejections; i++ {
+		require.True(t, l.acquireRejection())
+	}
+	assert.False(t, l.acquireRejection(), "rejections over the limit should be dropped")
+	l.releaseRejection()
+	assert.True(t, l.acquireRejection())
+	assert.Equal(t, RequestStats{DroppedRejections: 1}, l.Stats())
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"perun.network/go-perun/wire"
+)
+
+// Address returns the wire address of the client.
+func (c *Client) Address() wire.Address {
+	return c.address
+}
+
+// SendMsg sends msg to peer p over the client's wire bus. It can be used by
+// protocols on top of the Client, e.g., payment routing, to exchange messages
+// that are not part of the channel protocol.
+func (c *Client) SendMsg(ctx context.Context, msg wire.Msg, p wire.Address) error {
+	return c.conn.pubMsg(ctx, msg, p)
+}
+
+// SubscribeMsgs subscribes consumer to all incoming messages that match
+// predicate. Messages of the channel protocol are handled by the Client and
+// should not be subscribed to. The subscription ends when consumer is closed.
+func (c *Client) SubscribeMsgs(consumer wire.Consumer, predicate wire.Predicate) error {
+	return c.conn.Subscribe(consumer, predicate)
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	simledger "perun.network/go-perun/backend/sim/ledger"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/multi"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/test"
+)
+
+// TestMultiLedger opens a channel with assets on two simulated ledgers,
+// transfers funds of both assets, verifies the channel's transcript and closes
+// the channel cooperatively.
+func TestMultiLedger(t *testing.T) {
+	rng := test.Prng(t)
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	ledgers := []*simledger.Ledger{simledger.NewLedger("chainA"), simledger.NewLedger("chainB")}
+
+	for i := range setups {
+		funder, adj := multi.NewFunder(), multi.NewAdjudicator()
+		for _, l := range ledgers {
+			funder.RegisterFunder(l.ID(), l)
+			adj.RegisterAdjudicator(l.ID(), l)
+		}
+		setups[i].Funder, setups[i].Adjudicator = funder, adj
+	}
+	acceptUpdates := client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		assert.NoError(t, res.Accept(ctx))
+	})
+	assets := []*simledger.Asset{ledgers[0].NewAsset(rng.Int63()), ledgers[1].NewAsset(rng.Int63())}
+	prop := newProposal(rng, setups)
+	prop.InitBals = &channel.Allocation{
+		Assets:   []channel.Asset{assets[0], assets[1]},
+		Balances: [][]channel.Bal{{big.NewInt(100), big.NewInt(50)}, {big.NewInt(10), big.NewInt(20)}},
+	}
+	alice, bob := openChannelPair(t, rng, setups, prop, acceptUpdates)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	// Alice sends 30 of the asset on chain A, Bob sends 5 of the asset on chain B.
+	require.NoError(t, alice.UpdateBy(ctx, func(s *channel.State) {
+		s.Balances[0][0].Sub(s.Balances[0][0], big.NewInt(30))
+		s.Balances[0][1].Add(s.Balances[0][1], big.NewInt(30))
+	}))
+	require.NoError(t, bob.UpdateBy(ctx, func(s *channel.State) {
+		s.Balances[1][1].Sub(s.Balances[1][1], big.NewInt(5))
+		s.Balances[1][0].Add(s.Balances[1][0], big.NewInt(5))
+	}))
+
+	var transcript bytes.Buffer
+	require.NoError(t, bob.ExportTranscript(&transcript))
+	// The ledger assets can only be decoded with the ledger backend.
+	tr, err := channel.Backends{Channel: simledger.Backend{}}.DecodeTranscript(&transcript)
+	require.NoError(t, err)
+	assert.Len(t, tr.Transactions, 3)
+	assert.NoError(t, channel.VerifyTranscript(tr))
+
+	require.NoError(t, alice.CloseCooperatively(ctx))
+	assert.Eventually(t, func() bool { return bob.Phase() == channel.Withdrawn },
+		defaultTimeout, 10*time.Millisecond)
+
+	parts := alice.Params().Parts
+	expected := [][]int64{{70, 80}, {15, 15}}
+	for a, asset := range assets {
+		for p, part := range parts {
+			assert.Zero(t, big.NewInt(expected[a][p]).Cmp(ledgers[a].Balance(part, asset)),
+				"balance of participant %d on ledger %s", p, ledgers[a].ID())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+)
+
+// ForceUpdate enforces an update of the channel on-chain, e.g., if the peer
+// refuses to sign it. The current state is registered if it is not registered
+// yet. After the challenge duration of the registration has passed, the state
+// modified by update is progressed on-chain. The update must be a valid
+// transition of the channel's app by the own participant. Its version is
+// increased automatically.
+//
+// Afterwards, the channel cannot be updated off-chain anymore. It can be
+// force-updated again during the challenge duration of the progression and
+// has to be settled with Settle eventually.
+//
+// Note that the peer may withdraw the registered state once its challenge
+// duration has passed, so the progression should happen promptly.
+func (c *Channel) ForceUpdate(ctx context.Context, update func(*channel.State)) error {
+	if !c.machMtx.TryLockCtx(ctx) {
+		return errors.Errorf("locking machine mutex in time: %v", ctx.Err())
+	}
+	defer c.machMtx.Unlock()
+	// Wrap the context to make sure that the call stops as soon as the
+	// channel controller is closed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.OnClose(cancel)
+
+	sub, err := channel.SubscribeRegistered(ctx, c.adjudicator, c.Params(), c.machine.State().Assets)
+	if err != nil {
+		return errors.WithMessage(err, "subscribing to RegisteredEvents")
+	}
+	// nolint:errcheck
+	defer sub.Close()
+
+	if c.machine.Phase() < channel.Registered {
+		if err := c.register(ctx); err != nil {
+			return errors.WithMessage(err, "registering")
+		}
+		c.Log().Info("Channel state registered.")
+	}
+
+	// A registered state can only be progressed after its challenge duration.
+	if reg := c.machine.Registered(); reg.State == nil {
+		c.Log().Infof("Waiting until %v for progression.", reg.Timeout)
+		if err := reg.Timeout.Wait(ctx); err != nil {
+			return errors.WithMessage(err, "waiting for timeout")
+		}
+	}
+
+	return c.progress(ctx, sub, update)
+}
+
+// progress progresses the current state by update on-chain and saves the
+// resulting RegisteredEvent, which is received from sub, to the machine.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) progress(ctx context.Context, sub channel.RegisteredSubscription, update func(*channel.State)) error {
+	state := c.machine.State().Clone()
+	state.Version++
+	update(state)
+
+	if err := c.machine.SetProgressing(ctx, state); err != nil {
+		return errors.WithMessage(err, "setting machine to Progressing phase")
+	}
+	req := channel.ProgressReq{
+		AdjudicatorReq: c.machine.AdjudicatorReq(),
+		NewState:       state,
+		Sig:            c.machine.StagingTX().Sigs[c.machine.Idx()],
+	}
+	if err := c.adjudicator.Progress(ctx, req); err != nil {
+		return errors.WithMessage(err, "calling Progress")
+	}
+
+	for {
+		reg := sub.Next()
+		if reg == nil {
+			return errors.WithMessage(sub.Err(), "subscription closed before progression")
+		}
+		if reg.State != nil && reg.Version >= state.Version {
+			return c.machine.SetProgressed(ctx, reg)
+		}
+	}
+}
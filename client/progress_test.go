@@ -0,0 +1,84 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	simledger "perun.network/go-perun/backend/sim/ledger"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/test"
+)
+
+// TestChannel_ForceUpdate opens a channel to a peer that rejects all updates,
+// enforces a payment on-chain and settles the progressed state.
+func TestChannel_ForceUpdate(t *testing.T) {
+	rng := test.Prng(t)
+	setups := NewSetups(rng, []string{"Alice", "Bob"})
+	ledger := simledger.NewLedger("chain")
+
+	for i := range setups {
+		setups[i].Funder, setups[i].Adjudicator = ledger, ledger
+	}
+	rejectUpdates := client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		assert.NoError(t, res.Reject(ctx, "no updates"))
+	})
+	asset := ledger.NewAsset(rng.Int63())
+	prop := newProposal(rng, setups)
+	prop.ChallengeDuration = 1
+	prop.InitBals.Assets = []channel.Asset{asset}
+	alice, bob := openChannelPair(t, rng, setups, prop, rejectUpdates)
+
+	// The challenge duration of one second is waited for twice.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pay := func(s *channel.State) {
+		s.Balances[0][0].Sub(s.Balances[0][0], big.NewInt(30))
+		s.Balances[0][1].Add(s.Balances[0][1], big.NewInt(30))
+	}
+	require.Error(t, alice.UpdateBy(ctx, pay), "Bob should reject the update")
+	var enabled []channel.StateEnabledEvent
+	alice.AddObserver(channel.MachineObserverFuncs{
+		StateEnabled: func(e channel.StateEnabledEvent) { enabled = append(enabled, e) },
+	})
+	require.NoError(t, alice.ForceUpdate(ctx, pay))
+	assert.Equal(t, channel.Progressed, alice.Phase())
+	require.Len(t, enabled, 1)
+	assert.Equal(t, channel.PhaseTransition{From: channel.Progressing, To: channel.Progressed}, enabled[0].Transition)
+	assert.Equal(t, uint64(1), enabled[0].NewVersion)
+	assert.Equal(t, uint64(1), alice.State().Version)
+
+	// Bob learns about the progressed state from his watcher and settles it.
+	go func() { assert.NoError(t, bob.Watch()) }()
+	require.NoError(t, alice.Settle(ctx))
+	assert.Eventually(t, func() bool { return bob.Phase() == channel.Withdrawn },
+		defaultTimeout, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), bob.State().Version)
+
+	parts := alice.Params().Parts
+	for p, expected := range []int64{70, 130} {
+		assert.Zero(t, big.NewInt(expected).Cmp(ledger.Balance(parts[p], asset)),
+			"balance of participant %d", p)
+	}
+}
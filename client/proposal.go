@@ -260,7 +260,7 @@ func (c *Client) validTwoPartyProposal(
 	ourIdx int,
 	peerAddr wallet.Address,
 ) error {
-	if err := proposal.Valid(); err != nil {
+	if err := proposal.valid(c.backends); err != nil {
 		return err
 	}
 
@@ -303,7 +303,7 @@ func (c *Client) setupChannel(
 	parts []wallet.Address, // result of the MPCPP on prop
 	idx channel.Index, // our index
 ) (*Channel, error) {
-	params := channel.NewParamsUnsafe(prop.ChallengeDuration, parts, prop.AppDef, prop.Nonce)
+	params := c.backends.NewParamsUnsafe(prop.ChallengeDuration, parts, prop.AppDef, prop.Nonce)
 	if c.channels.Has(params.ID()) {
 		return nil, errors.New("channel already exists")
 	}
@@ -29,15 +29,15 @@ import (
 )
 
 func init() {
-	wire.RegisterDecoder(wire.ChannelProposal,
-		func(r io.Reader) (wire.Msg, error) {
+	wire.RegisterBackendDecoder(wire.ChannelProposal,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
 			var m ChannelProposal
-			return &m, m.Decode(r)
+			return &m, m.decode(r, channel.BackendsOf(b))
 		})
-	wire.RegisterDecoder(wire.ChannelProposalAcc,
-		func(r io.Reader) (wire.Msg, error) {
+	wire.RegisterBackendDecoder(wire.ChannelProposalAcc,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
 			var m ChannelProposalAcc
-			return &m, m.Decode(r)
+			return &m, m.decode(r, channel.BackendsOf(b))
 		})
 	wire.RegisterDecoder(wire.ChannelProposalRej,
 		func(r io.Reader) (wire.Msg, error) {
@@ -103,8 +103,13 @@ func (c ChannelProposal) Encode(w io.Writer) error {
 	return nil
 }
 
-// Decode decodes a ChannelProposalRequest from an io.Reader.
-func (c *ChannelProposal) Decode(r io.Reader) (err error) {
+// Decode decodes a ChannelProposalRequest from an io.Reader using the global
+// backends.
+func (c *ChannelProposal) Decode(r io.Reader) error {
+	return c.decode(r, channel.Backends{})
+}
+
+func (c *ChannelProposal) decode(r io.Reader, b channel.Backends) (err error) {
 	if r == nil {
 		return errors.New("reader must not be nil")
 	}
@@ -113,14 +118,14 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 		return err
 	}
 
-	if c.ParticipantAddr, err = wallet.DecodeAddress(r); err != nil {
+	if c.ParticipantAddr, err = b.DecodeAddress(r); err != nil {
 		return err
 	}
-	if c.AppDef, err = wallet.DecodeAddress(r); err != nil {
+	if c.AppDef, err = b.DecodeAddress(r); err != nil {
 		return err
 	}
 	var app channel.App
-	if app, err = channel.AppFromDefinition(c.AppDef); err != nil {
+	if app, err = b.AppFromDefinition(c.AppDef); err != nil {
 		return err
 	}
 
@@ -128,10 +133,7 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 		return err
 	}
 
-	if c.InitBals == nil {
-		c.InitBals = new(channel.Allocation)
-	}
-	if err := perunio.Decode(r, c.InitBals); err != nil {
+	if c.InitBals, err = b.DecodeAllocation(r); err != nil {
 		return err
 	}
 
@@ -151,7 +153,7 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 
 	c.PeerAddrs = make([]wallet.Address, numParts)
 	for i := range c.PeerAddrs {
-		if c.PeerAddrs[i], err = wallet.DecodeAddress(r); err != nil {
+		if c.PeerAddrs[i], err = b.DecodeAddress(r); err != nil {
 			return err
 		}
 	}
@@ -194,10 +196,16 @@ func (c ChannelProposal) SessID() (sid SessionID) {
 // * InitBals match the dimension of Parts
 // * non-zero ChallengeDuration.
 func (c ChannelProposal) Valid() error {
+	return c.valid(channel.Backends{})
+}
+
+// valid checks that the channel proposal is valid, like Valid, resolving the
+// app with the given backends.
+func (c ChannelProposal) valid(b channel.Backends) error {
 	// nolint: gocritic
 	if c.InitBals == nil || c.ParticipantAddr == nil {
 		return errors.New("invalid nil fields")
-	} else if err := channel.ValidateParameters(
+	} else if err := b.ValidateParameters(
 		c.ChallengeDuration, len(c.PeerAddrs), c.AppDef, c.Nonce); err != nil {
 		return errors.WithMessage(err, "invalid channel parameters")
 	} else if err := c.InitBals.Valid(); err != nil {
@@ -240,13 +248,18 @@ func (acc ChannelProposalAcc) Encode(w io.Writer) error {
 	return nil
 }
 
-// Decode decodes a ChannelProposalAcc from an io.Reader.
-func (acc *ChannelProposalAcc) Decode(r io.Reader) (err error) {
+// Decode decodes a ChannelProposalAcc from an io.Reader using the global
+// backends.
+func (acc *ChannelProposalAcc) Decode(r io.Reader) error {
+	return acc.decode(r, channel.Backends{})
+}
+
+func (acc *ChannelProposalAcc) decode(r io.Reader, b channel.Backends) (err error) {
 	if err = perunio.Decode(r, &acc.SessID); err != nil {
 		return errors.WithMessage(err, "SID decoding")
 	}
 
-	acc.ParticipantAddr, err = wallet.DecodeAddress(r)
+	acc.ParticipantAddr, err = b.DecodeAddress(r)
 	return errors.WithMessage(err, "participant address decoding")
 }
 
@@ -0,0 +1,167 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+type (
+	// A ChannelFilter is a predicate on channels. It is used to select the
+	// channels returned by Client.Channels and aggregated by Client.Balances.
+	ChannelFilter func(*Channel) bool
+
+	// AssetBalances are the balances of a single asset, aggregated over
+	// multiple channels per channel network peer.
+	AssetBalances struct {
+		// Asset is the asset of the balances.
+		Asset channel.Asset
+		// Peers maps the channel network peers, including the own client, to
+		// their aggregated balances.
+		Peers map[wallet.AddrKey]channel.Bal
+	}
+)
+
+// WithPeer returns a ChannelFilter that selects all channels with the given
+// channel network peer.
+func WithPeer(peer wire.Address) ChannelFilter {
+	return func(ch *Channel) bool {
+		return wallet.IndexOfAddr(ch.Peers(), peer) >= 0
+	}
+}
+
+// WithPhase returns a ChannelFilter that selects all channels that are in any
+// of the given phases.
+func WithPhase(phases ...channel.Phase) ChannelFilter {
+	return func(ch *Channel) bool {
+		phase := ch.Phase()
+		for _, p := range phases {
+			if p == phase {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithAppDef returns a ChannelFilter that selects all channels running the app
+// with the given app definition.
+func WithAppDef(def wallet.Address) ChannelFilter {
+	return func(ch *Channel) bool {
+		return ch.Params().App.Def().Equals(def)
+	}
+}
+
+// WithAsset returns a ChannelFilter that selects all channels holding the given
+// asset.
+func WithAsset(asset channel.Asset) ChannelFilter {
+	return func(ch *Channel) bool {
+		for _, a := range ch.State().Assets {
+			if eq, err := perunio.EqualEncoding(a, asset); err == nil && eq {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Channels returns all channels of the client that match all passed filters,
+// ordered by their channel ID. If no filter is passed, all channels are
+// returned.
+func (c *Client) Channels(filters ...ChannelFilter) []*Channel {
+	chs := c.channels.Channels()
+	sort.Slice(chs, func(i, j int) bool {
+		idi, idj := chs[i].ID(), chs[j].ID()
+		return bytes.Compare(idi[:], idj[:]) < 0
+	})
+
+	filtered := chs[:0]
+outer:
+	for _, ch := range chs {
+		for _, f := range filters {
+			if !f(ch) {
+				continue outer
+			}
+		}
+		filtered = append(filtered, ch)
+	}
+	return filtered
+}
+
+// Balances aggregates the current balances of all channels that match all
+// passed filters. The balances are summed up per asset and channel network peer.
+// The returned slice contains one entry per asset, in order of first
+// occurrence. Locked sub-allocations are not taken into account.
+func (c *Client) Balances(filters ...ChannelFilter) ([]AssetBalances, error) {
+	var (
+		bals     []AssetBalances
+		assetIdx = make(map[string]int) // maps asset encodings to their index in bals
+	)
+	for _, ch := range c.Channels(filters...) {
+		peers, state := ch.Peers(), ch.State()
+		for a, asset := range state.Assets {
+			var buf bytes.Buffer
+			if err := asset.Encode(&buf); err != nil {
+				return nil, errors.WithMessagef(err, "encoding asset %d of channel %x", a, ch.ID())
+			}
+			i, ok := assetIdx[buf.String()]
+			if !ok {
+				i = len(bals)
+				assetIdx[buf.String()] = i
+				bals = append(bals, AssetBalances{
+					Asset: asset,
+					Peers: make(map[wallet.AddrKey]channel.Bal),
+				})
+			}
+
+			for p, bal := range state.Balances[a] {
+				key := wallet.Key(peers[p])
+				if sum, ok := bals[i].Peers[key]; ok {
+					sum.Add(sum, bal)
+				} else {
+					bals[i].Peers[key] = new(big.Int).Set(bal)
+				}
+			}
+		}
+	}
+	return bals, nil
+}
+
+// Of returns the aggregated balance of the given channel network peer. If the
+// peer has no balance of the asset, zero is returned.
+func (b AssetBalances) Of(peer wire.Address) channel.Bal {
+	if bal, ok := b.Peers[wallet.Key(peer)]; ok {
+		return new(big.Int).Set(bal)
+	}
+	return new(big.Int)
+}
+
+// Total returns the sum of all peers' balances of the asset.
+func (b AssetBalances) Total() channel.Bal {
+	total := new(big.Int)
+	for _, bal := range b.Peers {
+		total.Add(total, bal)
+	}
+	return total
+}
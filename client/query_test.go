@@ -0,0 +1,107 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/payment"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wallettest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+// testChWithState creates a channel controller in the given phase with a
+// random two-party state, using the passed options. The own peer has index 0.
+func testChWithState(t *testing.T, rng *rand.Rand, peers []wire.Address, phase channel.Phase, opts ...test.RandomOpt) *Channel {
+	acc := wallettest.NewRandomAccount(rng)
+	opts = append(opts, test.WithFirstPart(acc.Address()), test.WithNumParts(2), test.WithNumLocked(0))
+	params, state := test.NewRandomParamsAndState(rng, opts...)
+	machine, err := channel.RestoreStateMachine(acc, &persistence.Channel{
+		ParamsV:    params,
+		CurrentTXV: channel.Transaction{State: state},
+		PhaseV:     phase,
+	})
+	require.NoError(t, err)
+
+	ch := testCh()
+	ch.conn.peers = peers
+	ch.machine = persistence.FromStateMachine(machine, persistence.NonPersistRestorer)
+	return ch
+}
+
+func TestClient_Channels(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	own := wallettest.NewRandomAddress(rng)
+	peerA, peerB := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+	asset1, asset2 := test.NewRandomAsset(rng), test.NewRandomAsset(rng)
+	bals := func(a, b int64) test.RandomOpt { return test.WithBalances([]channel.Bal{big.NewInt(a), big.NewInt(b)}) }
+
+	chs := []*Channel{
+		testChWithState(t, rng, []wire.Address{own, peerA}, channel.Acting,
+			test.WithAssets(asset1), bals(10, 20)),
+		testChWithState(t, rng, []wire.Address{own, peerA}, channel.Final,
+			test.WithAssets(asset2), bals(1, 2)),
+		testChWithState(t, rng, []wire.Address{own, peerB}, channel.Acting,
+			test.WithAssets(asset1), bals(5, 7)),
+	}
+	c := &Client{address: own, channels: makeChanRegistry()}
+	for _, ch := range chs {
+		require.True(t, c.channels.Put(ch.ID(), ch))
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		assert.Len(t, c.Channels(), 3)
+		assert.ElementsMatch(t, chs[:2], c.Channels(WithPeer(peerA)))
+		assert.ElementsMatch(t, chs[2:], c.Channels(WithPeer(peerB)))
+		assert.ElementsMatch(t, []*Channel{chs[0], chs[2]}, c.Channels(WithPhase(channel.Acting)))
+		assert.ElementsMatch(t, chs, c.Channels(WithAppDef(payment.AppDef())))
+		assert.Empty(t, c.Channels(WithAppDef(wallettest.NewRandomAddress(rng))))
+		assert.ElementsMatch(t, chs[1:2], c.Channels(WithAsset(asset2)))
+		assert.ElementsMatch(t, chs[2:], c.Channels(WithAsset(asset1), WithPeer(peerB)))
+		assert.Empty(t, c.Channels(WithPeer(wallettest.NewRandomAddress(rng))))
+	})
+
+	t.Run("balances", func(t *testing.T) {
+		bals, err := c.Balances()
+		require.NoError(t, err)
+		require.Len(t, bals, 2)
+		for _, b := range bals {
+			if b.Asset == asset1 {
+				assert.Zero(t, b.Of(own).Cmp(big.NewInt(15)))
+				assert.Zero(t, b.Of(peerA).Cmp(big.NewInt(20)))
+				assert.Zero(t, b.Of(peerB).Cmp(big.NewInt(7)))
+				assert.Zero(t, b.Total().Cmp(big.NewInt(42)))
+			} else {
+				assert.Zero(t, b.Of(own).Cmp(big.NewInt(1)))
+				assert.Zero(t, b.Of(peerA).Cmp(big.NewInt(2)))
+				assert.Zero(t, b.Of(peerB).Sign())
+			}
+		}
+
+		bals, err = c.Balances(WithPeer(peerB))
+		require.NoError(t, err)
+		require.Len(t, bals, 1)
+		assert.Zero(t, bals[0].Of(own).Cmp(big.NewInt(5)))
+	})
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package routing implements multi-hop payments over a network of HTLC
+// channels (see package apps/htlc) on top of a client.Client.
+//
+// A Router finds a path to the payee in a local channel graph, which is built
+// from its own channels and from announcements gossiped by the other routers.
+// Payments are forwarded hop by hop as hash time-locked payments. Each
+// forwarding router charges its fee. The payment either settles atomically
+// along the path when the payee reveals the preimage, or it is rolled back by
+// cancelling the locks from the failing hop back to the payer.
+package routing // import "perun.network/go-perun/client/routing"
@@ -0,0 +1,187 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+type (
+	// Edge is a directed edge of the channel graph. It represents the
+	// possibility of From to forward payments of Asset to To over a channel.
+	Edge struct {
+		From, To wire.Address
+		Asset    channel.Asset
+		// Capacity is the amount that From can send over the channel.
+		Capacity channel.Bal
+		// Fee is the fee that From charges for forwarding a payment over the
+		// channel.
+		Fee channel.Bal
+		// Seq is the sequence number of the edge's announcement. Newer
+		// announcements have higher sequence numbers.
+		Seq uint64
+	}
+
+	// Hop is a hop of a payment route. Amount of the asset is locked for
+	// recipient To until Expiry.
+	Hop struct {
+		To     wire.Address
+		Amount channel.Bal
+		Expiry uint64 // Unix time in seconds
+	}
+
+	// Graph is a directed channel graph. It is safe for concurrent use.
+	Graph struct {
+		mu    sync.RWMutex
+		edges map[edgeKey]Edge
+	}
+
+	edgeKey struct {
+		from, to wallet.AddrKey
+		asset    string
+	}
+)
+
+// NewGraph creates an empty channel graph.
+func NewGraph() *Graph {
+	return &Graph{edges: make(map[edgeKey]Edge)}
+}
+
+// Update adds the edge to the graph or replaces the edge between the same
+// nodes for the same asset, if the new edge has a higher sequence number. It
+// returns whether the graph changed.
+func (g *Graph) Update(e Edge) bool {
+	key := e.key()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if old, ok := g.edges[key]; ok && old.Seq >= e.Seq {
+		return false
+	}
+	g.edges[key] = e
+	return true
+}
+
+// Edges returns all edges of the graph.
+func (g *Graph) Edges() []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	edges := make([]Edge, 0, len(g.edges))
+	for _, e := range g.edges {
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+// Route finds the cheapest route to send amount of asset from node from to
+// node to. The returned edges form the path from from to to. Each edge has
+// enough capacity for the amount plus the fees of the subsequent hops.
+func (g *Graph) Route(from, to wire.Address, asset channel.Asset, amount channel.Bal) ([]Edge, error) {
+	ak := assetKey(asset)
+	g.mu.RLock()
+	// Dijkstra backwards from the payee. need is the amount that a node has to
+	// send to deliver amount to the payee.
+	incoming := make(map[wallet.AddrKey][]Edge)
+	for k, e := range g.edges {
+		if k.asset == ak {
+			incoming[k.to] = append(incoming[k.to], e)
+		}
+	}
+	g.mu.RUnlock()
+
+	src, dst := wallet.Key(from), wallet.Key(to)
+	need := map[wallet.AddrKey]*big.Int{dst: amount}
+	next := make(map[wallet.AddrKey]Edge)
+	done := make(map[wallet.AddrKey]bool)
+	for {
+		node, ok := cheapest(need, done)
+		if !ok {
+			return nil, errors.Errorf("no route with enough capacity to %v", to)
+		}
+		if node == src {
+			break
+		}
+		done[node] = true
+		for _, e := range incoming[node] {
+			k := wallet.Key(e.From)
+			if done[k] || e.Capacity.Cmp(need[node]) < 0 {
+				continue
+			}
+			n := new(big.Int).Set(need[node])
+			if k != src {
+				n.Add(n, e.Fee)
+			}
+			if old, ok := need[k]; !ok || n.Cmp(old) < 0 {
+				need[k], next[k] = n, e
+			}
+		}
+	}
+
+	var route []Edge
+	for node := src; node != dst; node = wallet.Key(next[node].To) {
+		route = append(route, next[node])
+	}
+	return route, nil
+}
+
+// cheapest returns the node with the lowest need that is not done.
+func cheapest(need map[wallet.AddrKey]*big.Int, done map[wallet.AddrKey]bool) (wallet.AddrKey, bool) {
+	var (
+		min   *big.Int
+		found wallet.AddrKey
+	)
+	for k, n := range need {
+		if !done[k] && (min == nil || n.Cmp(min) < 0) {
+			min, found = n, k
+		}
+	}
+	return found, min != nil
+}
+
+// Hops calculates the hops of a route for a payment of amount. The last hop
+// expires at finalExpiry and each preceding hop expiryDelta seconds later.
+// Each hop's amount includes the fees of the subsequent hops.
+func Hops(route []Edge, amount channel.Bal, finalExpiry, expiryDelta uint64) []Hop {
+	hops := make([]Hop, len(route))
+	amt, exp := new(big.Int).Set(amount), finalExpiry
+	for i := len(route) - 1; i >= 0; i-- {
+		hops[i] = Hop{To: route[i].To, Amount: new(big.Int).Set(amt), Expiry: exp}
+		if i > 0 {
+			amt.Add(amt, route[i].Fee)
+			exp += expiryDelta
+		}
+	}
+	return hops
+}
+
+func (e Edge) key() edgeKey {
+	return edgeKey{from: wallet.Key(e.From), to: wallet.Key(e.To), asset: assetKey(e.Asset)}
+}
+
+// assetKey returns a comparable representation of asset a.
+func assetKey(a channel.Asset) string {
+	var buf bytes.Buffer
+	if err := a.Encode(&buf); err != nil {
+		panic("encoding asset: " + err.Error())
+	}
+	return buf.String()
+}
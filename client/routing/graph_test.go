@@ -0,0 +1,67 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client/routing"
+	"perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+func TestGraph_Route(t *testing.T) {
+	rng := test.Prng(t)
+	a, b, c, d := wtest.NewRandomAddress(rng), wtest.NewRandomAddress(rng),
+		wtest.NewRandomAddress(rng), wtest.NewRandomAddress(rng)
+	asset := chtest.NewRandomAsset(rng)
+	g := routing.NewGraph()
+	for _, e := range []routing.Edge{
+		{From: a, To: b, Capacity: big.NewInt(100), Fee: big.NewInt(1)},
+		{From: b, To: d, Capacity: big.NewInt(100), Fee: big.NewInt(5)},
+		{From: a, To: c, Capacity: big.NewInt(100), Fee: big.NewInt(1)},
+		{From: c, To: d, Capacity: big.NewInt(100), Fee: big.NewInt(2)},
+	} {
+		e.Asset, e.Seq = asset, 1
+		require.True(t, g.Update(e))
+	}
+	assert.False(t, g.Update(routing.Edge{From: c, To: d, Asset: asset, Capacity: big.NewInt(0), Fee: big.NewInt(0), Seq: 1}),
+		"edge with old sequence number should be ignored")
+	assert.Len(t, g.Edges(), 4)
+
+	route, err := g.Route(a, d, asset, big.NewInt(10))
+	require.NoError(t, err)
+	require.Len(t, route, 2)
+	assert.True(t, route[0].To.Equals(c), "cheapest route should be taken")
+	assert.True(t, route[1].To.Equals(d))
+
+	hops := routing.Hops(route, big.NewInt(10), 1000, 100)
+	assert.Equal(t, []routing.Hop{
+		{To: c, Amount: big.NewInt(12), Expiry: 1100},
+		{To: d, Amount: big.NewInt(10), Expiry: 1000},
+	}, hops)
+
+	_, err = g.Route(a, d, asset, big.NewInt(100))
+	assert.Error(t, err, "fees should exceed capacity")
+	_, err = g.Route(d, a, asset, big.NewInt(1))
+	assert.Error(t, err, "edges should be directed")
+	_, err = g.Route(a, d, chtest.NewRandomAsset(rng), big.NewInt(1))
+	assert.Error(t, err, "edges of other assets should be ignored")
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"math/rand"
+
+	"github.com/sirupsen/logrus"
+
+	"perun.network/go-perun/apps/htlc"
+	_ "perun.network/go-perun/backend/sim" // backend init
+	plogrus "perun.network/go-perun/log/logrus"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// This file initializes the blockchain and logging backend and the HTLC app
+// for the tests of package routing.
+func init() {
+	plogrus.Set(logrus.WarnLevel, &logrus.TextFormatter{ForceColors: true})
+
+	rng := rand.New(rand.NewSource(pkgtest.Seed("routing app def")))
+	htlc.SetAppDef(wallettest.NewRandomAddress(rng))
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wire"
+)
+
+func init() {
+	wire.RegisterBackendDecoder(wire.RoutingAnnouncement,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
+			var m msgAnnouncement
+			return &m, m.decode(r, channel.BackendsOf(b))
+		})
+	wire.RegisterBackendDecoder(wire.RoutingForward,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
+			var m msgForward
+			return &m, m.decode(r, channel.BackendsOf(b))
+		})
+}
+
+// maxNumHops is the maximum number of hops of a forwarded payment.
+const maxNumHops = 32
+
+type (
+	// msgAnnouncement announces an edge of the channel graph.
+	msgAnnouncement struct {
+		Edge
+	}
+
+	// msgForward instructs the recipient to forward the payment locked with
+	// Hash along Hops. The first hop is to be executed by the recipient. An
+	// empty Hops means that the recipient is the payee.
+	msgForward struct {
+		Hash htlc.Hash
+		Hops []Hop
+	}
+)
+
+// Type returns wire.RoutingAnnouncement.
+func (*msgAnnouncement) Type() wire.Type {
+	return wire.RoutingAnnouncement
+}
+
+// Type returns wire.RoutingForward.
+func (*msgForward) Type() wire.Type {
+	return wire.RoutingForward
+}
+
+func (m msgAnnouncement) Encode(w io.Writer) error {
+	return perunio.Encode(w, m.From, m.To, m.Asset, m.Capacity, m.Fee, m.Seq)
+}
+
+func (m *msgAnnouncement) Decode(r io.Reader) error {
+	return m.decode(r, channel.Backends{})
+}
+
+func (m *msgAnnouncement) decode(r io.Reader, b channel.Backends) (err error) {
+	if m.From, err = b.DecodeAddress(r); err != nil {
+		return errors.WithMessage(err, "decoding from address")
+	}
+	if m.To, err = b.DecodeAddress(r); err != nil {
+		return errors.WithMessage(err, "decoding to address")
+	}
+	if m.Asset, err = b.DecodeAsset(r); err != nil {
+		return errors.WithMessage(err, "decoding asset")
+	}
+	return perunio.Decode(r, &m.Capacity, &m.Fee, &m.Seq)
+}
+
+func (m msgForward) Encode(w io.Writer) error {
+	if len(m.Hops) > maxNumHops {
+		return errors.Errorf("too many hops: %d", len(m.Hops))
+	}
+	if err := perunio.Encode(w, m.Hash, uint16(len(m.Hops))); err != nil {
+		return err
+	}
+	for _, h := range m.Hops {
+		if err := perunio.Encode(w, h.To, h.Amount, h.Expiry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *msgForward) Decode(r io.Reader) error {
+	return m.decode(r, channel.Backends{})
+}
+
+func (m *msgForward) decode(r io.Reader, b channel.Backends) (err error) {
+	var n uint16
+	if err := perunio.Decode(r, &m.Hash, &n); err != nil {
+		return err
+	}
+	if n > maxNumHops {
+		return errors.Errorf("too many hops: %d", n)
+	}
+	m.Hops = nil
+	if n > 0 {
+		m.Hops = make([]Hop, n)
+	}
+	for i := range m.Hops {
+		if m.Hops[i].To, err = b.DecodeAddress(r); err != nil {
+			return errors.WithMessage(err, "decoding hop address")
+		}
+		if err := perunio.Decode(r, &m.Hops[i].Amount, &m.Hops[i].Expiry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
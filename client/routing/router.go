@@ -0,0 +1,565 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+const (
+	// maxPendingInstructions is the maximum number of forwarding instructions
+	// per peer that are kept before their lock is received.
+	maxPendingInstructions = 64
+)
+
+type (
+	// Config configures a Router.
+	Config struct {
+		// Fee is the fee that the router charges for forwarding a payment over
+		// one of its channels.
+		Fee channel.Bal
+		// ExpiryDelta is the minimum time in seconds between the expiries of
+		// an incoming lock and the outgoing lock that forwards it. It must be
+		// the same for all routers of a network.
+		ExpiryDelta uint64
+		// FinalExpiry is the time in seconds after which the lock of the last
+		// hop of an own payment expires.
+		FinalExpiry uint64
+		// ForwardTimeout is the time to wait for the forwarding instructions of
+		// an incoming lock. Instructions that arrive before their lock are
+		// dropped after the same time.
+		ForwardTimeout time.Duration
+		// UpdateTimeout is the timeout of the channel updates and messages sent
+		// by the router.
+		UpdateTimeout time.Duration
+	}
+
+	// Router routes payments over HTLC channels of a client.Client. Its
+	// UpdateHandler must be used as the client's update handler.
+	Router struct {
+		client *client.Client
+		cfg    Config
+		graph  *Graph
+		recv   *wire.Receiver
+		seq    uint64 // accessed atomically
+		log    log.Logger
+
+		mu       sync.Mutex
+		invoices map[htlc.Hash]invoice
+		instrs   map[htlc.Hash]*instruction
+		pending  map[wallet.AddrKey]int        // unmatched instructions per sender
+		forwards map[htlc.Hash]*client.Channel // incoming channels of forwarded locks
+		payments map[htlc.Hash]chan error
+	}
+
+	// invoice is a payment that the router expects to receive.
+	invoice struct {
+		preimage htlc.Preimage
+		amount   channel.Bal
+	}
+
+	// instruction holds the forwarding instructions of a lock once they are
+	// received. ready is closed when they are set. expiry is only set for
+	// instructions that were received before their lock.
+	instruction struct {
+		ready  chan struct{}
+		sender wire.Address
+		hops   []Hop
+		expiry *time.Timer
+	}
+
+	// lockEvent is the addition or removal of a lock in a channel update. For
+	// removed locks, preimage is set if the lock was unlocked.
+	lockEvent struct {
+		lock     htlc.Lock
+		added    bool
+		preimage *htlc.Preimage
+	}
+)
+
+// DefaultConfig returns the default router configuration. It does not charge
+// fees.
+func DefaultConfig() Config {
+	return Config{
+		Fee:            big.NewInt(0),
+		ExpiryDelta:    10 * 60,
+		FinalExpiry:    60 * 60,
+		ForwardTimeout: 5 * time.Second,
+		UpdateTimeout:  10 * time.Second,
+	}
+}
+
+// New creates a router for the client. It subscribes to routing messages on
+// the client's wire bus until it is closed.
+func New(c *client.Client, cfg Config) (*Router, error) {
+	r := &Router{
+		client:   c,
+		cfg:      cfg,
+		graph:    NewGraph(),
+		recv:     wire.NewReceiver(),
+		seq:      uint64(time.Now().UnixNano()),
+		log:      c.Log().WithField("module", "routing"),
+		invoices: make(map[htlc.Hash]invoice),
+		instrs:   make(map[htlc.Hash]*instruction),
+		pending:  make(map[wallet.AddrKey]int),
+		forwards: make(map[htlc.Hash]*client.Channel),
+		payments: make(map[htlc.Hash]chan error),
+	}
+	if err := c.SubscribeMsgs(r.recv, func(e *wire.Envelope) bool {
+		return e.Msg.Type() == wire.RoutingAnnouncement || e.Msg.Type() == wire.RoutingForward
+	}); err != nil {
+		return nil, errors.WithMessage(err, "subscribing to routing messages")
+	}
+	go r.receive()
+	return r, nil
+}
+
+// Close stops receiving routing messages.
+func (r *Router) Close() error {
+	return r.recv.Close()
+}
+
+// Graph returns the router's channel graph.
+func (r *Router) Graph() *Graph {
+	return r.graph
+}
+
+// UpdateHandler returns the update handler that must be passed to the client's
+// Handle method. Updates of HTLC channels that add or remove locks are accepted
+// and processed by the router. All other updates are passed to uh. HTLC
+// updates whose time is far off the own clock are already rejected by the
+// client.
+func (r *Router) UpdateHandler(uh client.UpdateHandler) client.UpdateHandler {
+	return client.UpdateHandlerFunc(func(up client.ChannelUpdate, res *client.UpdateResponder) {
+		ch, err := r.client.Channel(up.State.ID)
+		if err != nil {
+			uh.HandleUpdate(up, res)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.UpdateTimeout)
+		defer cancel()
+		events := lockEvents(res.CurrentState(), up.State)
+		if len(events) == 0 {
+			uh.HandleUpdate(up, res)
+			return
+		}
+
+		if err := res.Accept(ctx); err != nil {
+			r.log.Errorf("Accepting HTLC update: %v", err)
+			return
+		}
+		go r.handleEvents(ch, events)
+	})
+}
+
+// NewInvoice creates a new invoice for receiving amount. The returned hash
+// has to be passed to the payer.
+func (r *Router) NewInvoice(amount channel.Bal) (htlc.Hash, error) {
+	var p htlc.Preimage
+	if _, err := rand.Read(p[:]); err != nil {
+		return htlc.Hash{}, errors.Wrap(err, "generating preimage")
+	}
+	h := htlc.HashPreimage(p)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invoices[h] = invoice{preimage: p, amount: new(big.Int).Set(amount)}
+	return h, nil
+}
+
+// Announce announces the router's channels to its channel peers, who gossip
+// them to their peers.
+func (r *Router) Announce(ctx context.Context) error {
+	edges := r.updateOwnEdges()
+	for _, e := range edges {
+		if err := r.gossip(ctx, e, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pay pays amount of asset to payee with the payment locked by hash, which the
+// payee got from Router.NewInvoice. It finds the cheapest route and blocks
+// until the payment is settled or rolled back or the context is done.
+//
+// If the context is done before, the pending lock of the first hop remains and
+// can be refunded after it expired.
+func (r *Router) Pay(ctx context.Context, payee wire.Address, asset channel.Asset, amount channel.Bal, hash htlc.Hash) error {
+	r.updateOwnEdges()
+	route, err := r.graph.Route(r.client.Address(), payee, asset, amount)
+	if err != nil {
+		return err
+	} else if len(route) == 0 {
+		return errors.New("cannot pay to self")
+	}
+	now := uint64(time.Now().Unix())
+	hops := Hops(route, amount, now+r.cfg.FinalExpiry, r.cfg.ExpiryDelta)
+
+	out, idx, err := r.channelTo(hops[0].To, asset)
+	if err != nil {
+		return err
+	}
+	res := make(chan error, 1)
+	r.mu.Lock()
+	if _, ok := r.payments[hash]; ok {
+		r.mu.Unlock()
+		return errors.Errorf("payment %x already pending", hash)
+	}
+	r.payments[hash] = res
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.payments, hash)
+		r.mu.Unlock()
+	}()
+
+	if err := r.client.SendMsg(ctx, &msgForward{Hash: hash, Hops: hops[1:]}, hops[0].To); err != nil {
+		return errors.WithMessage(err, "sending forwarding instructions")
+	}
+	if err := out.LockHTLC(ctx, idx, hops[0].Amount, hash, time.Unix(int64(hops[0].Expiry), 0)); err != nil {
+		return errors.WithMessage(err, "locking payment")
+	}
+
+	select {
+	case err := <-res:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for payment")
+	}
+}
+
+// receive handles incoming routing messages until the router is closed.
+func (r *Router) receive() {
+	for {
+		env, err := r.recv.Next(context.Background())
+		if err != nil {
+			return
+		}
+		switch msg := env.Msg.(type) {
+		case *msgAnnouncement:
+			if msg.From.Equals(r.client.Address()) || !r.graph.Update(msg.Edge) {
+				continue
+			}
+			go func(sender wire.Address) {
+				ctx, cancel := context.WithTimeout(context.Background(), r.cfg.UpdateTimeout)
+				defer cancel()
+				if err := r.gossip(ctx, msg.Edge, sender); err != nil {
+					r.log.Warnf("Gossiping announcement: %v", err)
+				}
+			}(env.Sender)
+		case *msgForward:
+			r.addInstruction(env.Sender, msg)
+		}
+	}
+}
+
+// gossip sends the announcement of edge e to all channel peers, except peer
+// exclude and the edge's nodes.
+func (r *Router) gossip(ctx context.Context, e Edge, exclude wire.Address) error {
+	sent := make(map[wallet.AddrKey]bool)
+	for _, ch := range r.channels() {
+		peer := ch.Peers()[ch.Idx()^1]
+		k := wallet.Key(peer)
+		if sent[k] || peer.Equals(e.From) || peer.Equals(e.To) || (exclude != nil && peer.Equals(exclude)) {
+			continue
+		}
+		sent[k] = true
+		if err := r.client.SendMsg(ctx, &msgAnnouncement{Edge: e}, peer); err != nil {
+			return errors.WithMessagef(err, "sending announcement to %v", peer)
+		}
+	}
+	return nil
+}
+
+// handleEvents processes the lock events of an accepted update of channel ch.
+func (r *Router) handleEvents(ch *client.Channel, events []lockEvent) {
+	for _, ev := range events {
+		switch {
+		case ev.added && ev.lock.Receiver == ch.Idx():
+			r.handleIncoming(ch, ev.lock)
+		case !ev.added && ev.lock.Sender == ch.Idx():
+			r.handleResolved(ev.lock, ev.preimage)
+		}
+	}
+}
+
+// handleIncoming settles the incoming lock if it pays an invoice and forwards
+// it otherwise. If this is not possible, the lock is cancelled.
+func (r *Router) handleIncoming(in *client.Channel, lock htlc.Lock) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.UpdateTimeout)
+	defer cancel()
+	if err := r.settleOrForward(ctx, in, lock); err != nil {
+		r.log.Warnf("Cancelling incoming lock %x: %v", lock.Hash, err)
+		if err := in.CancelHTLC(ctx, lock.Hash); err != nil {
+			r.log.Errorf("Cancelling incoming lock %x: %v", lock.Hash, err)
+		}
+	}
+}
+
+func (r *Router) settleOrForward(ctx context.Context, in *client.Channel, lock htlc.Lock) error {
+	r.mu.Lock()
+	inv, ok := r.invoices[lock.Hash]
+	if ok {
+		delete(r.invoices, lock.Hash)
+	}
+	r.mu.Unlock()
+	if ok {
+		if lock.Amount.Cmp(inv.amount) < 0 {
+			return errors.Errorf("amount %v less than invoiced %v", lock.Amount, inv.amount)
+		}
+		return in.UnlockHTLC(ctx, inv.preimage)
+	}
+
+	instr, err := r.waitInstruction(lock.Hash)
+	if err != nil {
+		return err
+	}
+	if peer := in.Peers()[in.Idx()^1]; !instr.sender.Equals(peer) {
+		return errors.Errorf("instructions sent by %v instead of %v", instr.sender, peer)
+	}
+	if len(instr.hops) == 0 {
+		return errors.New("no invoice for payment")
+	}
+
+	hop := instr.hops[0]
+	if fee := new(big.Int).Sub(lock.Amount, hop.Amount); fee.Cmp(r.cfg.Fee) < 0 {
+		return errors.Errorf("fee %v less than %v", fee, r.cfg.Fee)
+	}
+	if lock.Expiry < hop.Expiry+r.cfg.ExpiryDelta {
+		return errors.Errorf("expiry delta less than %d", r.cfg.ExpiryDelta)
+	}
+	out, idx, err := r.channelTo(hop.To, in.State().Assets[lock.Asset])
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.forwards[lock.Hash] = in
+	r.mu.Unlock()
+	err = r.client.SendMsg(ctx, &msgForward{Hash: lock.Hash, Hops: instr.hops[1:]}, hop.To)
+	if err == nil {
+		err = out.LockHTLC(ctx, idx, hop.Amount, lock.Hash, time.Unix(int64(hop.Expiry), 0))
+	}
+	if err != nil {
+		r.mu.Lock()
+		delete(r.forwards, lock.Hash)
+		r.mu.Unlock()
+		return errors.WithMessage(err, "forwarding lock")
+	}
+	return nil
+}
+
+// handleResolved handles the resolution of an outgoing lock. If it was
+// forwarded, the incoming lock is unlocked with the preimage or cancelled. If
+// it was an own payment, its result is reported.
+func (r *Router) handleResolved(lock htlc.Lock, preimage *htlc.Preimage) {
+	r.mu.Lock()
+	in, forwarded := r.forwards[lock.Hash]
+	delete(r.forwards, lock.Hash)
+	res, paid := r.payments[lock.Hash]
+	r.mu.Unlock()
+
+	switch {
+	case forwarded:
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.UpdateTimeout)
+		defer cancel()
+		var err error
+		if preimage != nil {
+			err = in.UnlockHTLC(ctx, *preimage)
+		} else {
+			err = in.CancelHTLC(ctx, lock.Hash)
+		}
+		if err != nil {
+			r.log.Errorf("Resolving incoming lock %x: %v", lock.Hash, err)
+		}
+	case paid && preimage != nil:
+		res <- nil
+	case paid:
+		res <- errors.New("payment cancelled")
+	}
+}
+
+// instruction returns the instruction entry for hash, creating it if needed.
+func (r *Router) instruction(hash htlc.Hash) *instruction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instr, ok := r.instrs[hash]
+	if !ok {
+		instr = &instruction{ready: make(chan struct{})}
+		r.instrs[hash] = instr
+	}
+	return instr
+}
+
+// addInstruction sets the forwarding instructions msg received from sender.
+// If no lock is waiting for them yet, they are kept until the forward timeout.
+// At most maxPendingInstructions of such instructions are kept per sender.
+func (r *Router) addInstruction(sender wire.Address, msg *msgForward) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instr, ok := r.instrs[msg.Hash]
+	if !ok {
+		key := wallet.Key(sender)
+		if r.pending[key] >= maxPendingInstructions {
+			r.log.Warnf("Dropping forwarding instructions from %v: too many pending", sender)
+			return
+		}
+		r.pending[key]++
+		instr = &instruction{ready: make(chan struct{})}
+		instr.expiry = time.AfterFunc(r.cfg.ForwardTimeout, func() {
+			r.removeInstruction(msg.Hash, instr)
+		})
+		r.instrs[msg.Hash] = instr
+	}
+	instr.set(sender, msg.Hops)
+}
+
+// removeInstruction removes the instruction entry instr for hash if it was not
+// replaced.
+func (r *Router) removeInstruction(hash htlc.Hash, instr *instruction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.instrs[hash] != instr {
+		return
+	}
+	delete(r.instrs, hash)
+	if instr.expiry == nil {
+		return
+	}
+	instr.expiry.Stop()
+	key := wallet.Key(instr.sender)
+	if r.pending[key]--; r.pending[key] == 0 {
+		delete(r.pending, key)
+	}
+}
+
+// waitInstruction waits for the forwarding instructions of the lock with hash
+// until the forward timeout and removes them.
+func (r *Router) waitInstruction(hash htlc.Hash) (*instruction, error) {
+	instr := r.instruction(hash)
+	defer r.removeInstruction(hash, instr)
+	select {
+	case <-instr.ready:
+		return instr, nil
+	case <-time.After(r.cfg.ForwardTimeout):
+		return nil, errors.New("no forwarding instructions received")
+	}
+}
+
+func (i *instruction) set(sender wire.Address, hops []Hop) {
+	select {
+	case <-i.ready: // duplicate instructions are ignored
+	default:
+		i.sender, i.hops = sender, hops
+		close(i.ready)
+	}
+}
+
+// channels returns the router's acting HTLC channels.
+func (r *Router) channels() []*client.Channel {
+	return r.client.Channels(client.WithAppDef(htlc.AppDef()), client.WithPhase(channel.Acting))
+}
+
+// updateOwnEdges updates the edges of the own channels in the graph and
+// returns them.
+func (r *Router) updateOwnEdges() []Edge {
+	var edges []Edge
+	for _, ch := range r.channels() {
+		idx, state := ch.Idx(), ch.State()
+		for i, asset := range state.Assets {
+			e := Edge{
+				From:     r.client.Address(),
+				To:       ch.Peers()[idx^1],
+				Asset:    asset,
+				Capacity: new(big.Int).Set(state.Balances[i][idx]),
+				Fee:      r.cfg.Fee,
+				Seq:      atomic.AddUint64(&r.seq, 1),
+			}
+			r.graph.Update(e)
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// channelTo returns the own channel to peer with the most free funds of
+// asset and the asset's index in it.
+func (r *Router) channelTo(peer wire.Address, asset channel.Asset) (*client.Channel, int, error) {
+	var (
+		best     *client.Channel
+		bestIdx  int
+		bestFund channel.Bal
+	)
+	ak := assetKey(asset)
+	for _, ch := range r.client.Channels(client.WithPeer(peer), client.WithAppDef(htlc.AppDef()),
+		client.WithPhase(channel.Acting)) {
+		state := ch.State()
+		for i, a := range state.Assets {
+			if assetKey(a) != ak {
+				continue
+			}
+			if fund := state.Balances[i][ch.Idx()]; best == nil || fund.Cmp(bestFund) > 0 {
+				best, bestIdx, bestFund = ch, i, fund
+			}
+		}
+	}
+	if best == nil {
+		return nil, 0, errors.Errorf("no channel to %v", peer)
+	}
+	return best, bestIdx, nil
+}
+
+// lockEvents returns the locks that are added and removed by the update from
+// state from to state to.
+func lockEvents(from, to *channel.State) []lockEvent {
+	fromData, ok1 := from.Data.(*htlc.Data)
+	toData, ok2 := to.Data.(*htlc.Data)
+	if !ok1 || !ok2 {
+		return nil
+	}
+	var events []lockEvent
+	for _, l := range toData.Locks {
+		if _, ok := fromData.Lock(l.Hash); !ok {
+			events = append(events, lockEvent{lock: l, added: true})
+		}
+	}
+	for _, l := range fromData.Locks {
+		if _, ok := toData.Lock(l.Hash); ok {
+			continue
+		}
+		ev := lockEvent{lock: l}
+		for i, p := range toData.Preimages {
+			if htlc.HashPreimage(p) == l.Hash {
+				ev.preimage = &toData.Preimages[i]
+			}
+		}
+		events = append(events, ev)
+	}
+	return events
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/log"
+	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+func TestRouter_addInstruction(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	r := &Router{
+		cfg:     Config{ForwardTimeout: 20 * time.Millisecond},
+		log:     log.Get(),
+		instrs:  make(map[htlc.Hash]*instruction),
+		pending: make(map[wallet.AddrKey]int),
+	}
+	peer, other := wtest.NewRandomAddress(rng), wtest.NewRandomAddress(rng)
+
+	// Instructions are capped per peer.
+	for i := 0; i < maxPendingInstructions+1; i++ {
+		r.addInstruction(peer, &msgForward{Hash: randomHash(rng)})
+	}
+	r.addInstruction(other, &msgForward{Hash: randomHash(rng)})
+	r.mu.Lock()
+	assert.Len(t, r.instrs, maxPendingInstructions+1)
+	assert.Equal(t, maxPendingInstructions, r.pending[wallet.Key(peer)])
+	r.mu.Unlock()
+
+	// A waiting lock takes its instructions.
+	hash := randomHash(rng)
+	r.addInstruction(other, &msgForward{Hash: hash})
+	instr, err := r.waitInstruction(hash)
+	assert.NoError(t, err)
+	assert.True(t, instr.sender.Equals(other))
+
+	// Unmatched instructions expire.
+	assert.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.instrs) == 0 && len(r.pending) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func randomHash(rng *rand.Rand) (h htlc.Hash) {
+	rng.Read(h[:])
+	return
+}
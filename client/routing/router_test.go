@@ -0,0 +1,183 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/client/routing"
+	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+const timeout = 5 * time.Second
+
+type (
+	node struct {
+		client *client.Client
+		router *routing.Router
+		wallet wtest.Wallet
+		chans  chan *client.Channel // channels accepted by the node
+	}
+
+	noopFunder      struct{}
+	noopAdjudicator struct{}
+)
+
+func (noopFunder) Fund(context.Context, channel.FundingReq) error { return nil }
+
+func (noopAdjudicator) Register(_ context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	return &channel.RegisteredEvent{ID: req.Params.ID(), Version: req.Tx.Version, Timeout: &channel.ElapsedTimeout{}}, nil
+}
+
+func (noopAdjudicator) Withdraw(context.Context, channel.AdjudicatorReq) error { return nil }
+
+func (noopAdjudicator) Progress(context.Context, channel.ProgressReq) error { return nil }
+
+func (noopAdjudicator) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
+	return nil, nil
+}
+
+func TestRouter_Pay(t *testing.T) {
+	rng := test.Prng(t)
+	asset := chtest.NewRandomAsset(rng)
+	nodes := newNodes(t, rng, 4)
+	// Line topology A - B - C - D.
+	chans := make([][2]*client.Channel, len(nodes)-1)
+	for i := range chans {
+		chans[i] = openChannel(t, rng, nodes[i], nodes[i+1], asset)
+	}
+	for _, n := range nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		require.NoError(t, n.router.Announce(ctx))
+		cancel()
+	}
+	a, d := nodes[0], nodes[3]
+	require.Eventually(t, func() bool {
+		_, err := a.router.Graph().Route(a.client.Address(), d.client.Address(), asset, big.NewInt(10))
+		return err == nil
+	}, timeout, 10*time.Millisecond, "A should learn a route to D")
+
+	t.Run("unknown hash", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		hash := htlc.HashPreimage(htlc.NewRandomPreimage(rng))
+		assert.Error(t, a.router.Pay(ctx, d.client.Address(), asset, big.NewInt(10), hash))
+		assertBalances(t, chans, [][2]int64{{100, 100}, {100, 100}, {100, 100}})
+	})
+
+	t.Run("invoice", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		hash, err := d.router.NewInvoice(big.NewInt(10))
+		require.NoError(t, err)
+		require.NoError(t, a.router.Pay(ctx, d.client.Address(), asset, big.NewInt(10), hash))
+		// Each of B and C earns a fee of 1.
+		assertBalances(t, chans, [][2]int64{{88, 112}, {89, 111}, {90, 110}})
+	})
+}
+
+// assertBalances asserts that the channels eventually have the expected
+// balances and no locks on both sides.
+func assertBalances(t *testing.T, chans [][2]*client.Channel, expected [][2]int64) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		for i, pair := range chans {
+			for _, ch := range pair {
+				s := ch.State()
+				bals := s.Balances[0]
+				if bals[0].Int64() != expected[i][0] || bals[1].Int64() != expected[i][1] ||
+					len(s.Data.(*htlc.Data).Locks) != 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}, timeout, 10*time.Millisecond)
+}
+
+func newNodes(t *testing.T, rng *rand.Rand, n int) []*node {
+	bus := wire.NewLocalBus()
+	cfg := routing.DefaultConfig()
+	cfg.Fee = big.NewInt(1)
+	nodes := make([]*node, n)
+	for i := range nodes {
+		w := wtest.NewWallet()
+		c, err := client.New(wtest.NewRandomAccount(rng).Address(), bus, noopFunder{}, noopAdjudicator{}, w)
+		require.NoError(t, err)
+		r, err := routing.New(c, cfg)
+		require.NoError(t, err)
+		nd := &node{client: c, router: r, wallet: w, chans: make(chan *client.Channel, 1)}
+		nodes[i] = nd
+		t.Cleanup(func() {
+			r.Close()
+			c.Close()
+		})
+
+		acc := w.NewRandomAccount(rng).Address()
+		go c.Handle(client.ProposalHandlerFunc(func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			ch, err := res.Accept(ctx, client.ProposalAcc{Participant: acc})
+			assert.NoError(t, err)
+			nd.chans <- ch
+		}), r.UpdateHandler(client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			assert.NoError(t, res.Reject(ctx, "unexpected update"))
+		})))
+	}
+	return nodes
+}
+
+// openChannel opens an HTLC channel between from and to with 100 of asset for
+// each.
+func openChannel(t *testing.T, rng *rand.Rand, from, to *node, asset channel.Asset) [2]*client.Channel {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ch, err := from.client.ProposeChannel(ctx, &client.ChannelProposal{
+		ChallengeDuration: 60,
+		Nonce:             big.NewInt(rng.Int63()),
+		ParticipantAddr:   from.wallet.NewRandomAccount(rng).Address(),
+		AppDef:            htlc.AppDef(),
+		InitData:          new(htlc.Data),
+		InitBals: &channel.Allocation{
+			Assets:   []channel.Asset{asset},
+			Balances: [][]channel.Bal{{big.NewInt(100), big.NewInt(100)}},
+		},
+		PeerAddrs: []wallet.Address{from.client.Address(), to.client.Address()},
+	})
+	require.NoError(t, err)
+	select {
+	case peerCh := <-to.chans:
+		return [2]*client.Channel{ch, peerCh}
+	case <-ctx.Done():
+		t.Fatal("expected accepted channel")
+	}
+	return [2]*client.Channel{}
+}
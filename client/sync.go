@@ -112,15 +112,55 @@ func (c *Client) handleSyncMsg(peer wire.Address, msg *msgChannelSync) {
 	cancel() // can already release context resourcers
 
 	// Revert ongoing update since this is how synchronization is currently
-	// implemented... the peer will do the same.
+	// implemented... the peer will do the same. If the peer already enabled
+	// the staged update request, it is completed instead.
 	if ch.machine.Phase() == channel.Signing {
 		// The passed context is used for persistence, so use client life-time context
+		if err := ch.completeStaged(c.Ctx(), msg.CurrentTX); err == nil {
+			log.Infof("Completed staged update request %x.", msg.CurrentTX.RequestID)
+			return
+		} else if !ch.machine.StagingTX().RequestID.IsZero() {
+			log.Debugf("Could not complete staged update request: %v", err)
+		}
 		if err := ch.machine.DiscardUpdate(c.Ctx()); err != nil {
 			log.Error("Error discarding update: ", err)
 		}
 	}
 }
 
+// completeStaged completes the staged update using the peer's signature from
+// the peer's current transaction tx. This is only done if the staged update
+// carries a request ID and tx is the peer's completed transaction of the same
+// request.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) completeStaged(ctx context.Context, tx channel.Transaction) error {
+	staging := c.machine.StagingTX()
+	if staging.RequestID.IsZero() || staging.RequestID != tx.RequestID {
+		return errors.New("no matching request ID")
+	}
+	if tx.State == nil {
+		return errors.New("no state")
+	}
+	if err := tx.State.Equal(staging.State); err != nil {
+		return errors.WithMessage(err, "state mismatch")
+	}
+
+	pidx := c.machine.Idx() ^ 1
+	if len(tx.Sigs) != len(staging.Sigs) || tx.Sigs[pidx] == nil {
+		return errors.New("missing peer signature")
+	}
+	if _, err := c.machine.Sig(ctx); err != nil {
+		return errors.WithMessage(err, "signing staged state")
+	}
+	if staging.Sigs[pidx] == nil {
+		if err := c.machine.AddSig(ctx, pidx, tx.Sigs[pidx]); err != nil {
+			return errors.WithMessage(err, "adding peer signature")
+		}
+	}
+	return c.enableNotifyUpdate(ctx)
+}
+
 // syncChannel synchronizes the channel state with the given peer and modifies
 // the current state if required.
 // nolint:unused
@@ -188,7 +228,7 @@ func validateMessage(ch *persistence.Channel, msg *msgChannelSync) error {
 			return errors.New("sigs length mismatch")
 		}
 		for i, sig := range msg.CurrentTX.Sigs {
-			ok, err := channel.Verify(ch.Params().Parts[i], ch.Params(), msg.CurrentTX.State, sig)
+			ok, err := ch.Params().Backends().Verify(ch.Params().Parts[i], ch.Params(), msg.CurrentTX.State, sig)
 			if err != nil {
 				return errors.WithMessagef(err, "validating sig %d", i)
 			}
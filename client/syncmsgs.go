@@ -23,10 +23,10 @@ import (
 )
 
 func init() {
-	wire.RegisterDecoder(wire.ChannelSync,
-		func(r io.Reader) (wire.Msg, error) {
+	wire.RegisterBackendDecoder(wire.ChannelSync,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
 			var m msgChannelSync
-			return &m, m.Decode(r)
+			return &m, m.decode(r, channel.BackendsOf(b))
 		})
 }
 
@@ -53,9 +53,15 @@ func (m *msgChannelSync) Encode(w io.Writer) error {
 
 // Decode implements perunio.Decode.
 func (m *msgChannelSync) Decode(r io.Reader) error {
-	return perunio.Decode(r,
-		&m.Phase,
-		&m.CurrentTX)
+	return m.decode(r, channel.Backends{})
+}
+
+func (m *msgChannelSync) decode(r io.Reader, b channel.Backends) (err error) {
+	if err := perunio.Decode(r, &m.Phase); err != nil {
+		return err
+	}
+	m.CurrentTX, err = b.DecodeTransaction(r)
+	return err
 }
 
 // ID returns the channel's ID.
@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"perun.network/go-perun/channel"
 	"perun.network/go-perun/client"
 )
 
@@ -69,7 +70,7 @@ func (r *Mallory) exec(cfg ExecConfig, ch *paymentChannel) {
 	// within the challenge duration, Carol should refute.
 	subCtx, subCancel := context.WithTimeout(context.Background(), r.timeout+challengeDuration)
 	defer subCancel()
-	sub, err := r.setup.Adjudicator.SubscribeRegistered(subCtx, ch.Params())
+	sub, err := channel.SubscribeRegistered(subCtx, r.setup.Adjudicator, ch.Params(), ch.State().Assets)
 	assert.NoError(err)
 
 	// 3rd stage - wait until Carol has refuted
@@ -15,6 +15,7 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	"math/big"
 	"testing"
@@ -23,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/client"
 	"perun.network/go-perun/pkg/test"
 )
@@ -30,6 +32,7 @@ import (
 type (
 	multiClientRole struct {
 		role
+		migrateTo persistence.PersistRestorer
 	}
 
 	// Petra is the Proposer in a Persistence test.
@@ -39,9 +42,18 @@ type (
 	Robert struct{ multiClientRole }
 )
 
+// MigrateOnRestart makes ReplaceClient move all persisted channels into pr
+// through an archive, like when moving a node to new hardware.
+func (r *multiClientRole) MigrateOnRestart(pr persistence.PersistRestorer) {
+	r.migrateTo = pr
+}
+
 // ReplaceClient replaces the client instance of the Role. Useful for
 // persistence testing.
 func (r *multiClientRole) ReplaceClient() {
+	if r.migrateTo != nil {
+		r.migrate()
+	}
 	cl, err := client.New(r.setup.Identity.Address(), r.setup.Bus, r.setup.Funder, r.setup.Adjudicator, r.setup.Wallet)
 	if err != nil {
 		r.t.Fatal("Error recreating Client: ", err)
@@ -49,6 +61,18 @@ func (r *multiClientRole) ReplaceClient() {
 	r.setClient(cl)
 }
 
+func (r *multiClientRole) migrate() {
+	var archive bytes.Buffer
+	ctx := context.Background()
+	if _, err := persistence.ExportArchive(ctx, &archive, r.setup.PR); err != nil {
+		r.t.Fatal("Error exporting channels: ", err)
+	}
+	if _, err := persistence.ImportArchive(ctx, &archive, r.migrateTo, channel.Backends{}); err != nil {
+		r.t.Fatal("Error importing channels: ", err)
+	}
+	r.setup.PR, r.migrateTo = r.migrateTo, nil
+}
+
 func makeMultiClientRole(setup RoleSetup, t *testing.T, stages int) multiClientRole {
 	return multiClientRole{role: makeRole(setup, t, stages)}
 }
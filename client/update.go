@@ -16,9 +16,11 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"perun.network/go-perun/apps/htlc"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/log"
 	"perun.network/go-perun/pkg/sync/atomic"
@@ -26,6 +28,14 @@ import (
 	"perun.network/go-perun/wire"
 )
 
+// retryReplyTimeout is the time to wait for resending the acceptance of a
+// retried update request.
+var retryReplyTimeout = 10 * time.Second
+
+// maxClockSkew is the maximum difference between the changed time of an update
+// of a channel.TimedApp and the own clock.
+const maxClockSkew = time.Minute
+
 // handleChannelUpdate forwards incoming channel update requests to the
 // respective channel's update handler (Channel.handleUpdateReq). If the channel
 // is unknown, an error is logged.
@@ -49,6 +59,10 @@ type (
 		// ActorIdx is the actor causing the new state.  It does not need to
 		// coincide with the sender of the request.
 		ActorIdx uint16
+		// RequestID optionally identifies the update request. If set, retries
+		// of the request are recognized and completed instead of being applied
+		// twice. It is persisted together with the staged state.
+		RequestID channel.RequestID
 	}
 
 	// An UpdateHandler decides how to handle incoming channel update requests
@@ -110,11 +124,31 @@ func (r *UpdateResponder) Reject(ctx context.Context, reason string) error {
 	return r.channel.handleUpdateRej(ctx, r.pidx, r.req, reason)
 }
 
+// CurrentState returns the current state of the channel, which is replaced by
+// the update if it is accepted. It must only be called from within the update
+// handler, while the channel is locked for the update. Use Channel.State
+// otherwise.
+func (r *UpdateResponder) CurrentState() *channel.State {
+	return r.channel.machine.State()
+}
+
 // Update proposes the given channel update to all channel participants.
 //
 // It returns nil if all peers accept the update. If any runtime error occurs or
-// any peer rejects the update, an error is returned.
-// nolint: funlen
+// any peer rejects the update, an error is returned. If the update could not be
+// sent or remained unanswered, this is accounted for by the channel's
+// EscalationPolicy.
+//
+// If the update has a RequestID, it is safe to retry the update with the same
+// RequestID and state until it succeeds. If the request with this ID already
+// led to the channel's current state, Update returns nil without proposing the
+// state again. If the request is still staged, e.g., after restoring the
+// channel from persistence, the staged update is completed. In both cases, the
+// proposed state must equal the current or staged state, respectively.
+// Restoring a channel does not resume its staged update by itself; it is only
+// resumed by such a retry. Only the request of the current or staged
+// transaction is recognized, so retries of earlier requests fail like any
+// outdated update.
 func (c *Channel) Update(ctx context.Context, up ChannelUpdate) (err error) {
 	if ctx == nil {
 		return errors.New("context must not be nil")
@@ -128,7 +162,24 @@ func (c *Channel) Update(ctx context.Context, up ChannelUpdate) (err error) {
 	}
 	defer c.machMtx.Unlock()
 
-	if err = c.machine.Update(ctx, up.State, up.ActorIdx); err != nil {
+	return c.update(ctx, up, false)
+}
+
+// update proposes the given channel update to all channel participants. If
+// closing is true, the update is sent as cooperative close request.
+//
+// The caller is expected to have locked the channel mutex.
+// nolint: funlen
+func (c *Channel) update(ctx context.Context, up ChannelUpdate, closing bool) (err error) {
+	completed, pending, err := c.checkRetry(up)
+	if err != nil {
+		return err
+	} else if completed {
+		c.Log().Debugf("Update request %x already completed.", up.RequestID)
+		return nil
+	} else if pending {
+		c.Log().Debugf("Resuming staged update request %x.", up.RequestID)
+	} else if err = c.machine.UpdateWithRequestID(ctx, up.State, up.ActorIdx, up.RequestID); err != nil {
 		return errors.WithMessage(err, "updating machine")
 	}
 	// if anything goes wrong from now on, we discard the update.
@@ -159,14 +210,21 @@ func (c *Channel) Update(ctx context.Context, up ChannelUpdate) (err error) {
 		ChannelUpdate: up,
 		Sig:           sig,
 	}
-	if err = c.conn.Send(ctx, msgUpdate); err != nil {
+	var msg wire.Msg = msgUpdate
+	if closing {
+		msg = &msgChannelClose{*msgUpdate}
+	}
+	if err = c.conn.Send(ctx, msg); err != nil {
+		c.updateUnanswered(err)
 		return errors.WithMessage(err, "sending update")
 	}
 
 	pidx, res, err := resRecv.Next(ctx)
 	if err != nil {
+		c.updateUnanswered(err)
 		return errors.WithMessage(err, "receiving update response")
 	}
+	c.updateAnswered()
 	c.Log().Tracef("Received update response (%T): %v", res, res)
 
 	if rej, ok := res.(*msgChannelUpdateRej); ok {
@@ -181,6 +239,34 @@ func (c *Channel) Update(ctx context.Context, up ChannelUpdate) (err error) {
 	return c.enableNotifyUpdate(ctx)
 }
 
+// checkRetry checks whether the update is a retry of the request of the
+// current or staged transaction. It returns whether that request already
+// completed or is still pending, i.e., staged. A retry with a different state
+// than the request's state is an error. Updates without request ID are never
+// retries.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) checkRetry(up ChannelUpdate) (completed, pending bool, err error) {
+	if up.RequestID.IsZero() {
+		return false, false, nil
+	}
+	if tx := c.machine.CurrentTX(); tx.RequestID == up.RequestID {
+		if err := up.State.Equal(tx.State); err != nil {
+			return false, false, errors.WithMessagef(err,
+				"update conflicts with completed update of request %x", up.RequestID)
+		}
+		return true, false, nil
+	}
+	if c.machine.Phase() != channel.Signing || c.machine.StagingTX().RequestID != up.RequestID {
+		return false, false, nil
+	}
+	if err := up.State.Equal(c.machine.StagingState()); err != nil {
+		return false, false, errors.WithMessagef(err,
+			"update conflicts with staged update of request %x", up.RequestID)
+	}
+	return false, true, nil
+}
+
 // UpdateBy updates the channel state using the update function and proposes the new state
 // to all other channel participants.
 //
@@ -212,6 +298,10 @@ func (c *Channel) handleUpdateReq(
 	c.machMtx.Lock() // Lock machine while update is in progress.
 	defer c.machMtx.Unlock()
 
+	if c.handleRetriedUpdateReq(pidx, req) {
+		return
+	}
+
 	if err := c.machine.CheckUpdate(req.State, req.ActorIdx, req.Sig, pidx); err != nil {
 		// TODO: how to handle invalid updates? Just drop and ignore them?
 		c.logPeer(pidx).Warnf("invalid update received: %v", err)
@@ -222,6 +312,51 @@ func (c *Channel) handleUpdateReq(
 	uh.HandleUpdate(req.ChannelUpdate, responder)
 }
 
+// handleRetriedUpdateReq handles update requests whose request ID matches the
+// request of the current or staged transaction. If the request already led to
+// the current state, the acceptance is sent again and true is returned. If the
+// request is still staged, e.g., because the channel was restored while
+// signing, the stale staged update is discarded so that the request can be
+// handled anew.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) handleRetriedUpdateReq(pidx channel.Index, req *msgChannelUpdate) bool {
+	if req.RequestID.IsZero() {
+		return false
+	}
+	log := c.logPeer(pidx)
+	ctx, cancel := context.WithTimeout(c.Ctx(), retryReplyTimeout)
+	defer cancel()
+
+	if c.machine.Phase() == channel.Signing && c.machine.StagingTX().RequestID == req.RequestID {
+		log.Debugf("Discarding stale staged update of retried request %x.", req.RequestID)
+		if err := c.machine.DiscardUpdate(ctx); err != nil {
+			log.Errorf("Error discarding stale staged update: %v", err)
+		}
+		return false
+	}
+
+	tx := c.machine.CurrentTX()
+	if tx.RequestID != req.RequestID {
+		return false
+	}
+	if err := req.State.Equal(tx.State); err != nil {
+		log.Warnf("retried update request %x does not match completed update: %v", req.RequestID, err)
+		return true
+	}
+
+	log.Debugf("Resending acceptance of completed update request %x.", req.RequestID)
+	msgUpAcc := &msgChannelUpdateAcc{
+		ChannelID: c.ID(),
+		Version:   tx.Version,
+		Sig:       tx.Sigs[c.machine.Idx()],
+	}
+	if err := c.conn.Send(ctx, msgUpAcc); err != nil {
+		log.Errorf("Error resending accept message: %v", err)
+	}
+	return true
+}
+
 func (c *Channel) handleUpdateAcc(
 	ctx context.Context,
 	pidx channel.Index,
@@ -234,7 +369,7 @@ func (c *Channel) handleUpdateAcc(
 	}()
 
 	// machine.Update and AddSig should never fail after CheckUpdate...
-	if err = c.machine.Update(ctx, req.State, req.ActorIdx); err != nil {
+	if err = c.machine.UpdateWithRequestID(ctx, req.State, req.ActorIdx, req.RequestID); err != nil {
 		return errors.WithMessage(err, "updating machine")
 	}
 	// if anything goes wrong from now on, we discard the update.
@@ -326,14 +461,34 @@ func (c *Channel) SubUpdates(updateSub chan<- *channel.State) {
 // validTwoPartyUpdate performs additional protocol-dependent checks on the
 // proposed update that go beyond the machine's checks:
 // * actor and signer must be the same
-// * no locked sub-allocations.
+// * no locked sub-allocations, except for the HTLC app's locked funds.
+// * the time of a TimedApp's state is unchanged or near the own clock.
 func (c *Channel) validTwoPartyUpdate(up ChannelUpdate, sigIdx channel.Index) error {
 	if up.ActorIdx != sigIdx {
 		return errors.Errorf(
 			"Currently, only update proposals with the proposing peer as actor are allowed.")
 	}
-	if len(up.State.Locked) > 0 {
+	if _, ok := c.Params().App.(*htlc.App); !ok && len(up.State.Locked) > 0 {
 		return errors.New("no locked sub-allocations allowed")
 	}
+	if app, ok := c.Params().App.(channel.TimedApp); ok {
+		return validStateTime(app, c.machine.State(), up.State, time.Now())
+	}
+	return nil
+}
+
+// validStateTime checks that the time of state to of a TimedApp either equals
+// the time of the current state cur or is at most maxClockSkew off now.
+func validStateTime(app channel.TimedApp, cur, to *channel.State, now time.Time) error {
+	t, err := app.StateTime(to)
+	if err != nil {
+		return errors.WithMessage(err, "reading state time")
+	}
+	if prev, err := app.StateTime(cur); err == nil && t.Equal(prev) {
+		return nil
+	}
+	if d := t.Sub(now); d < -maxClockSkew || d > maxClockSkew {
+		return errors.Errorf("state time %v too far off own time %v", t, now)
+	}
 	return nil
 }
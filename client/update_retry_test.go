@@ -0,0 +1,80 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/test"
+)
+
+func TestChannel_UpdateRetry(t *testing.T) {
+	rng := test.Prng(t)
+	const answerDelay = 100 * time.Millisecond
+
+	// Bob answers updates too late for Alice's first attempt.
+	var numHandled int32
+	uh := client.UpdateHandlerFunc(func(_ client.ChannelUpdate, res *client.UpdateResponder) {
+		atomic.AddInt32(&numHandled, 1)
+		time.Sleep(answerDelay)
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		assert.NoError(t, res.Accept(ctx))
+	})
+	alice, bob, _ := testChannelPair(t, uh)
+
+	transfer := func(amount int64) client.ChannelUpdate {
+		state := alice.State().Clone()
+		state.Balances[0][0].Sub(state.Balances[0][0], big.NewInt(amount))
+		state.Balances[0][1].Add(state.Balances[0][1], big.NewInt(amount))
+		state.Version++
+		return client.ChannelUpdate{State: state, ActorIdx: alice.Idx()}
+	}
+	up := transfer(1)
+	up.RequestID = chtest.NewRandomRequestID(rng)
+
+	ctx, cancel := context.WithTimeout(context.Background(), answerDelay/2)
+	defer cancel()
+	require.Error(t, alice.Update(ctx, up), "first update attempt should time out")
+	assert.EqualValues(t, 0, alice.State().Version)
+	// Wait for Bob to accept the first attempt.
+	time.Sleep(2 * answerDelay)
+	assert.EqualValues(t, 1, bob.State().Version)
+
+	ctx, cancel = context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	require.NoError(t, alice.Update(ctx, up), "retried update should complete")
+	assert.EqualValues(t, 1, alice.State().Version)
+	assert.NoError(t, alice.State().Equal(bob.State()))
+
+	// Retrying a completed request is a no-op, but fails with a different state.
+	require.NoError(t, alice.Update(ctx, up))
+	require.Error(t, alice.Update(ctx, client.ChannelUpdate{
+		State:     transfer(2).State,
+		ActorIdx:  alice.Idx(),
+		RequestID: up.RequestID,
+	}))
+	assert.EqualValues(t, 1, alice.State().Version)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&numHandled), "update handler called more than once")
+}
@@ -15,10 +15,20 @@
 package client
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/htlc"
+	"perun.network/go-perun/apps/payment"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wallettest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
 )
 
 func TestUpdateResponder_Accept_NilArgs(t *testing.T) {
@@ -40,3 +50,56 @@ func TestChannel_Update_NilArgs(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "context")
 }
+
+func TestChannel_checkRetry(t *testing.T) {
+	rng := pkgtest.Prng(t)
+	peers := []wire.Address{wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)}
+	app, err := payment.AppFromDefinition(payment.AppDef())
+	require.NoError(t, err)
+	ch := testChWithState(t, rng, peers, channel.Acting,
+		test.WithIsFinal(false), test.WithApp(app), test.WithAppData(new(payment.NoData)))
+	id := test.NewRandomRequestID(rng)
+
+	state := ch.State().Clone()
+	state.Version++
+	up := ChannelUpdate{State: state, ActorIdx: ch.Idx(), RequestID: id}
+
+	completed, pending, err := ch.checkRetry(up)
+	require.NoError(t, err)
+	assert.False(t, completed)
+	assert.False(t, pending, "request should not be pending before staging")
+
+	require.NoError(t, ch.machine.UpdateWithRequestID(context.Background(), state, ch.Idx(), id))
+	completed, pending, err = ch.checkRetry(up)
+	require.NoError(t, err)
+	assert.False(t, completed)
+	assert.True(t, pending, "staged request should be pending")
+
+	conflicting := up
+	conflicting.State = state.Clone()
+	conflicting.State.Version++
+	_, _, err = ch.checkRetry(conflicting)
+	assert.Error(t, err, "retry with different state should conflict")
+
+	other := up
+	other.RequestID = test.NewRandomRequestID(rng)
+	completed, pending, err = ch.checkRetry(other)
+	require.NoError(t, err)
+	assert.False(t, completed || pending, "request with other ID is no retry")
+}
+
+func TestValidStateTime(t *testing.T) {
+	app := new(htlc.App)
+	now := time.Unix(1000, 0)
+	state := func(t uint64) *channel.State {
+		return &channel.State{Data: &htlc.Data{Time: t}}
+	}
+	cur := state(100)
+
+	assert.NoError(t, validStateTime(app, cur, state(100), now), "unchanged time should be valid")
+	assert.NoError(t, validStateTime(app, cur, state(1000), now))
+	assert.NoError(t, validStateTime(app, cur, state(1060), now))
+	assert.NoError(t, validStateTime(app, cur, state(940), now))
+	assert.Error(t, validStateTime(app, cur, state(1061), now))
+	assert.Error(t, validStateTime(app, cur, state(939), now))
+}
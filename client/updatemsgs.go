@@ -24,21 +24,26 @@ import (
 )
 
 func init() {
-	wire.RegisterDecoder(wire.ChannelUpdate,
-		func(r io.Reader) (wire.Msg, error) {
+	wire.RegisterBackendDecoder(wire.ChannelUpdate,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
 			var m msgChannelUpdate
-			return &m, m.Decode(r)
+			return &m, m.decode(r, channel.BackendsOf(b))
 		})
-	wire.RegisterDecoder(wire.ChannelUpdateAcc,
-		func(r io.Reader) (wire.Msg, error) {
+	wire.RegisterBackendDecoder(wire.ChannelUpdateAcc,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
 			var m msgChannelUpdateAcc
-			return &m, m.Decode(r)
+			return &m, m.decode(r, channel.BackendsOf(b))
 		})
 	wire.RegisterDecoder(wire.ChannelUpdateRej,
 		func(r io.Reader) (wire.Msg, error) {
 			var m msgChannelUpdateRej
 			return &m, m.Decode(r)
 		})
+	wire.RegisterBackendDecoder(wire.ChannelClose,
+		func(r io.Reader, b wire.Backends) (wire.Msg, error) {
+			var m msgChannelClose
+			return &m, m.decode(r, channel.BackendsOf(b))
+		})
 }
 
 type (
@@ -86,10 +91,19 @@ type (
 		// Reason states why the sender rejectes the proposed new state.
 		Reason string
 	}
+
+	// msgChannelClose is the wire message of a cooperative channel close
+	// request. It is a channel update that proposes the current state as final
+	// state, with the version increased by one. It is answered like a
+	// ChannelUpdate.
+	msgChannelClose struct {
+		msgChannelUpdate
+	}
 )
 
 var (
 	_ ChannelMsg          = (*msgChannelUpdate)(nil)
+	_ ChannelMsg          = (*msgChannelClose)(nil)
 	_ channelUpdateResMsg = (*msgChannelUpdateAcc)(nil)
 	_ channelUpdateResMsg = (*msgChannelUpdateRej)(nil)
 )
@@ -99,6 +113,11 @@ func (*msgChannelUpdate) Type() wire.Type {
 	return wire.ChannelUpdate
 }
 
+// Type returns this message's type: ChannelClose.
+func (*msgChannelClose) Type() wire.Type {
+	return wire.ChannelClose
+}
+
 // Type returns this message's type: ChannelUpdateAcc.
 func (*msgChannelUpdateAcc) Type() wire.Type {
 	return wire.ChannelUpdateAcc
@@ -110,17 +129,21 @@ func (*msgChannelUpdateRej) Type() wire.Type {
 }
 
 func (c msgChannelUpdate) Encode(w io.Writer) error {
-	return perunio.Encode(w, c.State, c.ActorIdx, c.Sig)
+	return perunio.Encode(w, c.State, c.ActorIdx, c.RequestID, c.Sig)
+}
+
+func (c *msgChannelUpdate) Decode(r io.Reader) error {
+	return c.decode(r, channel.Backends{})
 }
 
-func (c *msgChannelUpdate) Decode(r io.Reader) (err error) {
-	if c.State == nil {
-		c.State = new(channel.State)
+func (c *msgChannelUpdate) decode(r io.Reader, b channel.Backends) (err error) {
+	if c.State, err = b.DecodeState(r); err != nil {
+		return err
 	}
-	if err := perunio.Decode(r, c.State, &c.ActorIdx); err != nil {
+	if err := perunio.Decode(r, &c.ActorIdx, &c.RequestID); err != nil {
 		return err
 	}
-	c.Sig, err = wallet.DecodeSig(r)
+	c.Sig, err = b.DecodeSig(r)
 	return err
 }
 
@@ -128,11 +151,15 @@ func (c msgChannelUpdateAcc) Encode(w io.Writer) error {
 	return perunio.Encode(w, c.ChannelID, c.Version, c.Sig)
 }
 
-func (c *msgChannelUpdateAcc) Decode(r io.Reader) (err error) {
+func (c *msgChannelUpdateAcc) Decode(r io.Reader) error {
+	return c.decode(r, channel.Backends{})
+}
+
+func (c *msgChannelUpdateAcc) decode(r io.Reader, b channel.Backends) (err error) {
 	if err := perunio.Decode(r, &c.ChannelID, &c.Version); err != nil {
 		return err
 	}
-	c.Sig, err = wallet.DecodeSig(r)
+	c.Sig, err = b.DecodeSig(r)
 	return err
 }
 
@@ -37,6 +37,9 @@ func TestChannelUpdateSerialization(t *testing.T) {
 			},
 			Sig: sig,
 		}
+		if i%2 == 0 {
+			m.RequestID = test.NewRandomRequestID(rng)
+		}
 		wire.TestMsg(t, m)
 	}
 }
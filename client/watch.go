@@ -34,7 +34,7 @@ func (c *Channel) Watch() error {
 	defer log.Info("Watcher returned.")
 
 	ctx := c.Ctx()
-	sub, err := c.adjudicator.SubscribeRegistered(ctx, c.Params())
+	sub, err := channel.SubscribeRegistered(ctx, c.adjudicator, c.Params(), c.State().Assets)
 	if err != nil {
 		return errors.WithMessage(err, "subscribing to RegisteredEvents")
 	}
@@ -58,7 +58,8 @@ func (c *Channel) Watch() error {
 }
 
 // handleRegisteredEvent stores the passed RegisteredEvent to the machine and
-// settles the channel.
+// settles the channel. Events of progressed states also update the machine's
+// current state.
 func (c *Channel) handleRegisteredEvent(ctx context.Context, reg *channel.RegisteredEvent) error {
 	log := c.Log().WithField("proc", "watcher")
 	// Lock machine while registering is in progress.
@@ -74,13 +75,36 @@ func (c *Channel) handleRegisteredEvent(ctx context.Context, reg *channel.Regist
 		return nil
 	}
 
-	if err := c.machine.SetRegistered(ctx, reg); err != nil {
-		return errors.WithMessage(err, "setting machine to Registered phase")
+	if err := c.setRegistered(ctx, reg); err != nil {
+		return err
 	}
 
 	return c.settle(ctx)
 }
 
+// setRegistered saves the passed RegisteredEvent to the machine. If the event
+// carries a progressed state, the machine is set to phase Progressed.
+//
+// The caller is expected to have locked the channel mutex.
+func (c *Channel) setRegistered(ctx context.Context, reg *channel.RegisteredEvent) error {
+	if cur := c.machine.Registered(); cur != nil && c.machine.Phase() >= channel.Registered && reg.Version < cur.Version {
+		c.Log().Debugf("Ignoring outdated RegisteredEvent: %v", reg)
+		return nil
+	}
+
+	if reg.State == nil {
+		return errors.WithMessage(c.machine.SetRegistered(ctx, reg),
+			"setting machine to Registered phase")
+	}
+	if c.machine.Phase() < channel.Registered {
+		if err := c.machine.SetRegistered(ctx, reg); err != nil {
+			return errors.WithMessage(err, "setting machine to Registered phase")
+		}
+	}
+	return errors.WithMessage(c.machine.SetProgressed(ctx, reg),
+		"setting machine to Progressed phase")
+}
+
 // Settle settles the channel: it is made sure that the current state is
 // registered and the final balance withdrawn. This call blocks until the
 // channel has been successfully withdrawn.
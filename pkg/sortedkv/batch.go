@@ -19,6 +19,11 @@ package sortedkv
 type Batch interface {
 	Writer // Put and Delete
 
+	// RangeDeleter deletes the keys in a range that are in the database when
+	// the batch is applied, or that were put into the batch before the range
+	// deletion. Keys that are put into the batch afterwards are kept.
+	RangeDeleter
+
 	// Apply performs all batched actions on the database.
 	Apply() error
 
@@ -0,0 +1,95 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"perun.network/go-perun/pkg/sortedkv/key"
+)
+
+// Batch represents a batch and implements the batch interface.
+type Batch struct {
+	db  *bolt.DB
+	ops []batchOp
+}
+
+// batchOp is a single put, delete or range deletion of a Batch.
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+	// end is the end of a range deletion that starts at key, if isRange is
+	// set.
+	end     string
+	isRange bool
+}
+
+// Put puts a new value in the batch.
+func (b *Batch) Put(key string, value string) error {
+	return b.PutBytes(key, []byte(value))
+}
+
+// PutBytes puts a new byte slice into the batch.
+func (b *Batch) PutBytes(key string, value []byte) error {
+	b.ops = append(b.ops, batchOp{key: []byte(key), value: append([]byte{}, value...)})
+	return nil
+}
+
+// Delete deletes a value from the batch.
+func (b *Batch) Delete(key string) error {
+	b.ops = append(b.ops, batchOp{key: []byte(key), delete: true})
+	return nil
+}
+
+// DeleteRange deletes a range of keys.
+func (b *Batch) DeleteRange(start string, end string) error {
+	b.ops = append(b.ops, batchOp{key: []byte(start), end: end, isRange: true})
+	return nil
+}
+
+// DeletePrefix deletes all keys with a prefix.
+func (b *Batch) DeletePrefix(prefix string) error {
+	return b.DeleteRange(prefix, key.IncPrefix(prefix))
+}
+
+// Apply applies the batch to the database in a single transaction. All
+// changes become visible to readers at once.
+func (b *Batch) Apply() error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		for _, op := range b.ops {
+			var err error
+			if op.isRange {
+				err = deleteRange(bkt, string(op.key), op.end)
+			} else if op.delete {
+				err = bkt.Delete(op.key)
+			} else {
+				err = bkt.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "bolt batch apply error")
+}
+
+// Reset resets the batch.
+func (b *Batch) Reset() {
+	b.ops = nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/test"
+)
+
+func TestBatch(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericBatchTest(t, db)
+	})
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericBatchTest(t, sortedkv.NewTable(db, "table"))
+	})
+}
+
+func TestDatabase(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericDatabaseTest(t, db)
+	})
+}
+
+func TestIterator(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericIteratorTest(t, db)
+	})
+}
+
+func TestIterator_Pages(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		const n = 3*pageSize + 1
+		batch := db.NewBatch()
+		for i := 0; i < n; i++ {
+			require.NoError(t, batch.Put(string([]byte{'k', byte(i)}), "v"))
+		}
+		require.NoError(t, batch.Put("l", "v"))
+		require.NoError(t, batch.Apply())
+
+		it := db.NewIteratorWithPrefix("k")
+		for i := 0; i < n; i++ {
+			require.True(t, it.Next())
+			assert.Equal(t, string([]byte{'k', byte(i)}), it.Key())
+			// Writes during the iteration must not block.
+			require.NoError(t, db.Put("m", "v"))
+		}
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Close())
+
+		it = db.NewReverseIteratorWithPrefix("k")
+		require.True(t, it.Seek(string([]byte{'k', byte(2 * pageSize)})))
+		for i := 2 * pageSize; i >= 0; i-- {
+			assert.Equal(t, string([]byte{'k', byte(i)}), it.Key())
+			require.Equal(t, i > 0, it.Next())
+		}
+		assert.NoError(t, it.Close())
+	})
+}
+
+func BenchmarkDatabase(b *testing.B) {
+	path, err := ioutil.TempDir("", "perun_benchdb_")
+	require.NoError(b, err)
+	defer os.RemoveAll(path)
+
+	db, err := LoadDatabase(filepath.Join(path, "bolt.db"))
+	require.NoError(b, err)
+	defer db.Close()
+
+	test.GenericDatabaseBenchmark(b, db)
+}
+
+func runTestOnTempDatabase(t *testing.T, tester func(*Database)) {
+	// Create a temporary directory and delete it when done
+	path, err := ioutil.TempDir("", "perun_testdb_")
+	require.Nil(t, err, "Could not create temporary directory for database")
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	// Create a database in the directory and close it when done
+	db, err := LoadDatabase(filepath.Join(path, "bolt.db"))
+	require.Nil(t, err, "Could not load database")
+	defer func() { assert.Nil(t, db.DB.Close(), "Could not close database") }()
+
+	tester(db)
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/key"
+)
+
+// bucket is the name of the bucket that holds all entries.
+var bucket = []byte("sortedkv")
+
+// Database implements the Database interface and stores the values in a bbolt
+// database file.
+type Database struct {
+	*bolt.DB
+}
+
+// LoadDatabase creates or opens a database file at the given path.
+// It blocks as long as another process holds the file open.
+func LoadDatabase(path string) (*Database, error) {
+	db, err := bolt.Open(path, os.FileMode(0600), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Database.LoadDatabase(path) could not open/create file")
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		db.Close() // nolint: errcheck
+		return nil, errors.Wrap(err, "Database.LoadDatabase(path) could not create bucket")
+	}
+
+	return &Database{db}, nil
+}
+
+// Reader interface.
+
+// Has returns true if the database contains a key.
+func (d *Database) Has(key string) (has bool, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		has = tx.Bucket(bucket).Get([]byte(key)) != nil
+		return nil
+	})
+	return has, errors.Wrap(err, "Database.Has(key) error")
+}
+
+// Get returns a value to a key.
+func (d *Database) Get(key string) (string, error) {
+	val, err := d.GetBytes(key)
+	return string(val), err
+}
+
+// GetBytes returns a value to a key in bytes.
+func (d *Database) GetBytes(key string) (val []byte, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucket).Get([]byte(key))
+		if v == nil {
+			return &sortedkv.ErrNotFound{Key: key}
+		}
+		// v is only valid during the transaction.
+		val = append([]byte{}, v...)
+		return nil
+	})
+	return val, err
+}
+
+// Writer interface.
+
+// Put saves a value under a key.
+func (d *Database) Put(key string, value string) error {
+	return d.PutBytes(key, []byte(value))
+}
+
+// PutBytes saves a bytes value under a key.
+func (d *Database) PutBytes(key string, value []byte) error {
+	err := d.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), value)
+	})
+	return errors.Wrap(err, "Database.Put(key, value) error")
+}
+
+// Delete deletes a key from the database.
+func (d *Database) Delete(key string) error {
+	return d.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b.Get([]byte(key)) == nil {
+			return &sortedkv.ErrNotFound{Key: key}
+		}
+		return errors.Wrap(b.Delete([]byte(key)), "Database.Delete(key) error")
+	})
+}
+
+// RangeDeleter interface.
+
+// DeleteRange deletes all keys in a range in a single transaction.
+func (d *Database) DeleteRange(start string, end string) error {
+	err := d.DB.Update(func(tx *bolt.Tx) error {
+		return deleteRange(tx.Bucket(bucket), start, end)
+	})
+	return errors.Wrap(err, "Database.DeleteRange(start, end) error")
+}
+
+// DeletePrefix deletes all keys with a prefix in a single transaction.
+func (d *Database) DeletePrefix(prefix string) error {
+	return d.DeleteRange(prefix, key.IncPrefix(prefix))
+}
+
+// Sizer interface.
+
+// ApproximateSize returns the exact length of all keys and values in a range.
+// The space used by the database file is not included.
+func (d *Database) ApproximateSize(start string, end string) (size int64, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		forRange(tx.Bucket(bucket).Cursor(), start, end, func(k, v []byte) {
+			size += int64(len(k) + len(v))
+		})
+		return nil
+	})
+	return size, errors.Wrap(err, "Database.ApproximateSize(start, end) error")
+}
+
+// Count returns the number of keys in a range.
+func (d *Database) Count(start string, end string) (n int, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		forRange(tx.Bucket(bucket).Cursor(), start, end, func(_, _ []byte) {
+			n++
+		})
+		return nil
+	})
+	return n, errors.Wrap(err, "Database.Count(start, end) error")
+}
+
+// deleteRange deletes all keys in a range from a bucket.
+func deleteRange(b *bolt.Bucket, start string, end string) error {
+	// Deleting while moving the cursor skips entries, so the keys are
+	// collected first.
+	var keys [][]byte
+	forRange(b.Cursor(), start, end, func(k, _ []byte) {
+		keys = append(keys, append([]byte{}, k...))
+	})
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forRange calls f for all entries in a range. The arguments of f are only
+// valid during the transaction.
+func forRange(c *bolt.Cursor, start string, end string, f func(k, v []byte)) {
+	Start, End := rangeBounds(start, end)
+	for k, v := c.Seek(Start); k != nil; k, v = c.Next() {
+		if End != nil && bytes.Compare(k, End) >= 0 {
+			break
+		}
+		f(k, v)
+	}
+}
+
+// Batcher interface.
+
+// NewBatch creates a new batch.
+func (d *Database) NewBatch() sortedkv.Batch {
+	return &Batch{db: d.DB}
+}
+
+// Iterateable interface.
+
+// NewIterator creates a new iterator.
+func (d *Database) NewIterator() sortedkv.Iterator {
+	return newIterator(d.DB, nil, nil, false)
+}
+
+// NewIteratorWithRange creates a new iterator based on a given range.
+func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+	Start, End := rangeBounds(start, end)
+	return newIterator(d.DB, Start, End, false)
+}
+
+// NewIteratorWithPrefix creates a new iterator for a given prefix.
+func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.NewIteratorWithRange(prefix, key.IncPrefix(prefix))
+}
+
+// NewReverseIterator creates a new reverse iterator.
+func (d *Database) NewReverseIterator() sortedkv.Iterator {
+	return newIterator(d.DB, nil, nil, true)
+}
+
+// NewReverseIteratorWithRange creates a new reverse iterator based on a given
+// range.
+func (d *Database) NewReverseIteratorWithRange(start string, end string) sortedkv.Iterator {
+	Start, End := rangeBounds(start, end)
+	return newIterator(d.DB, Start, End, true)
+}
+
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a given
+// prefix.
+func (d *Database) NewReverseIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.NewReverseIteratorWithRange(prefix, key.IncPrefix(prefix))
+}
+
+// rangeBounds converts a range to bounds of cursor keys. Empty bounds become
+// nil.
+func rangeBounds(start string, end string) (Start []byte, End []byte) {
+	if len(start) != 0 {
+		Start = []byte(start)
+	}
+
+	if len(end) != 0 {
+		End = []byte(end)
+	}
+
+	return Start, End
+}
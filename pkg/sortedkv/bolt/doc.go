@@ -0,0 +1,21 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bolt implements the key-value database interface using bbolt.
+//
+// All entries are stored in a single bucket of a single database file. Every
+// write is its own transaction, and batches are applied in one transaction.
+// This makes the backend suited for embedded and mobile deployments, where the
+// background compaction and multi-file layout of LevelDB are a burden.
+package bolt // import "perun.network/go-perun/pkg/sortedkv/bolt"
@@ -0,0 +1,210 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+
+	"perun.network/go-perun/log"
+)
+
+// pageSize is the number of entries that an Iterator reads per transaction.
+const pageSize = 64
+
+// Iterator provides an iterator over a key range.
+//
+// bbolt cursors are only valid during their transaction, and long-running read
+// transactions block the database file from growing. The Iterator therefore
+// reads pages of entries in short transactions and continues after the last
+// read key.
+type Iterator struct {
+	mu sync.Mutex
+
+	db         *bolt.DB
+	start, end []byte // end is exclusive, nil means unbounded.
+	reverse    bool
+
+	page []entry
+	pos  int
+	// last is the key at which the next page starts. It is included in the
+	// page if lastIncl is set.
+	last     []byte
+	lastIncl bool
+	done     bool
+	closed   bool
+	err      error
+}
+
+type entry struct {
+	key, value string
+}
+
+func newIterator(db *bolt.DB, start, end []byte, reverse bool) *Iterator {
+	return &Iterator{db: db, start: start, end: end, reverse: reverse, pos: -1}
+}
+
+// Next returns true if the iterator has a next element.
+func (i *Iterator) Next() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closed {
+		return false
+	}
+
+	if i.pos++; i.pos < len(i.page) {
+		return true
+	}
+
+	if i.done {
+		i.page = nil
+		return false
+	}
+
+	i.readPage()
+	return len(i.page) > 0
+}
+
+// Seek moves the iterator to the first element whose key is not before key in
+// iteration order.
+func (i *Iterator) Seek(key string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closed {
+		return false
+	}
+
+	i.last, i.lastIncl, i.done = []byte(key), true, false
+	if !i.reverse && bytes.Compare(i.last, i.start) < 0 {
+		i.last = i.start
+	} else if i.reverse && i.end != nil && bytes.Compare(i.last, i.end) >= 0 {
+		i.last = nil
+	}
+	i.readPage()
+	return len(i.page) > 0
+}
+
+// readPage reads the next page of entries.
+func (i *Iterator) readPage() {
+	i.page, i.pos = i.page[:0], 0
+	err := i.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		advance := c.Next
+		if i.reverse {
+			advance = c.Prev
+		}
+
+		for k, v := i.position(c); k != nil; k, v = advance() {
+			if i.outOfRange(k) {
+				i.done = true
+				return nil
+			}
+			if len(i.page) == pageSize {
+				return nil
+			}
+			i.page = append(i.page, entry{key: string(k), value: string(v)})
+		}
+		i.done = true
+		return nil
+	})
+	if err != nil {
+		i.err, i.done, i.page = err, true, nil
+		return
+	}
+
+	if len(i.page) > 0 {
+		i.last, i.lastIncl = []byte(i.page[len(i.page)-1].key), false
+	}
+}
+
+// position moves the cursor to the first entry of the next page.
+func (i *Iterator) position(c *bolt.Cursor) (k, v []byte) {
+	if !i.reverse {
+		switch {
+		case i.last != nil:
+			if k, v = c.Seek(i.last); !i.lastIncl && bytes.Equal(k, i.last) {
+				k, v = c.Next()
+			}
+			return k, v
+		case len(i.start) != 0:
+			return c.Seek(i.start)
+		default:
+			return c.First()
+		}
+	}
+
+	from, incl := i.end, false
+	if i.last != nil {
+		from, incl = i.last, i.lastIncl
+	}
+	if from == nil {
+		return c.Last()
+	}
+	if k, v = c.Seek(from); k == nil {
+		return c.Last()
+	} else if incl && bytes.Equal(k, from) {
+		return k, v
+	}
+	return c.Prev()
+}
+
+// outOfRange returns whether the iteration has left the range at key k.
+func (i *Iterator) outOfRange(k []byte) bool {
+	if i.reverse {
+		return bytes.Compare(k, i.start) < 0
+	}
+	return i.end != nil && bytes.Compare(k, i.end) >= 0
+}
+
+// current returns the current entry or panics if there is none.
+func (i *Iterator) current(method string) entry {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closed || i.pos < 0 || i.pos >= len(i.page) {
+		log.Panic("Iterator." + method + "() called on invalid iterator")
+	}
+	return i.page[i.pos]
+}
+
+// Key returns the key of the current element.
+func (i *Iterator) Key() string {
+	return i.current("Key").key
+}
+
+// Value returns the value of the current element.
+func (i *Iterator) Value() string {
+	return i.current("Value").value
+}
+
+// ValueBytes returns the value converted to bytes of the current element.
+func (i *Iterator) ValueBytes() []byte {
+	return []byte(i.Value())
+}
+
+// Close closes the iterator. The accumulated error is only returned on the
+// first call.
+func (i *Iterator) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	err := i.err
+	i.closed, i.page, i.err = true, nil, nil
+	return err
+}
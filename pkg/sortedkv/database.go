@@ -63,7 +63,40 @@ type Writer interface {
 type Database interface {
 	Reader
 	Writer
+	RangeDeleter
+	Sizer
 	Batcher
 	Iterable
 	io.Closer
 }
+
+// RangeDeleter deletes whole key ranges. In a Batch, ranges are resolved when
+// the batch is applied, so they also delete keys that are written to the
+// database between the range deletion and Apply.
+type RangeDeleter interface {
+	// DeleteRange removes all keys in the range [start, end) at once. If start
+	// is empty, the range starts with the first key. If end is empty, it ends
+	// with the last key. It is not an error if the range is empty.
+	DeleteRange(start string, end string) error
+
+	// DeletePrefix removes all keys with the given prefix at once.
+	DeletePrefix(prefix string) error
+}
+
+// Syncer is implemented by databases that do not write to stable storage
+// immediately.
+type Syncer interface {
+	// Sync flushes all previous writes to stable storage.
+	Sync() error
+}
+
+// Sizer estimates the size of key ranges. Ranges are given as for
+// RangeDeleter.DeleteRange.
+type Sizer interface {
+	// ApproximateSize returns the approximate number of bytes that the entries
+	// in the range [start, end) take up in the database.
+	ApproximateSize(start string, end string) (int64, error)
+
+	// Count returns the number of entries in the range [start, end).
+	Count(start string, end string) (int, error)
+}
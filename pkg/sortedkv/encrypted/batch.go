@@ -0,0 +1,138 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import "perun.network/go-perun/pkg/sortedkv"
+
+// Batch is an encrypting batch. It encrypts all values before they are added
+// to a batch of the underlying database.
+type Batch struct {
+	sortedkv.Batch
+	db *Database
+	// seq counts the operations of the batch. last is the sequence number of
+	// the last put or delete of each key.
+	seq  int
+	last map[string]int
+	// ranges are the range deletions of the batch. Since the underlying keys
+	// are not ordered, they are resolved when the batch is applied.
+	ranges []batchRange
+}
+
+// batchRange is a range deletion of a Batch.
+type batchRange struct {
+	prefix string // prefix of all keys in the range, for the iterator
+	match  func(string) bool
+	seq    int
+}
+
+// Put puts a new value in the batch.
+func (b *Batch) Put(key string, value string) error {
+	return b.PutBytes(key, []byte(value))
+}
+
+// PutBytes encrypts a value with the current key and puts it in the batch.
+// Entries of the key that were written with old keys are deleted.
+func (b *Batch) PutBytes(key string, value []byte) error {
+	if err := b.db.putCurrent(b.Batch, key, value); err != nil {
+		return err
+	}
+	b.track(key)
+	return b.deleteOld(key)
+}
+
+// Delete deletes the entries of a key for all keys.
+func (b *Batch) Delete(key string) error {
+	b.track(key)
+	return b.deleteAll(key)
+}
+
+func (b *Batch) track(key string) {
+	if b.last == nil {
+		b.last = make(map[string]int)
+	}
+	b.seq++
+	b.last[key] = b.seq
+}
+
+func (b *Batch) deleteAll(key string) error {
+	if err := b.Batch.Delete(b.db.keys[0].encryptKey(key)); err != nil {
+		return err
+	}
+	return b.deleteOld(key)
+}
+
+func (b *Batch) deleteOld(key string) error {
+	for _, c := range b.db.keys[1:] {
+		if err := b.Batch.Delete(c.encryptKey(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRange deletes a range of keys.
+func (b *Batch) DeleteRange(start string, end string) error {
+	b.seq++
+	b.ranges = append(b.ranges, batchRange{match: inRange(start, end), seq: b.seq})
+	return nil
+}
+
+// DeletePrefix deletes all keys with a prefix.
+func (b *Batch) DeletePrefix(prefix string) error {
+	b.seq++
+	b.ranges = append(b.ranges, batchRange{prefix: prefix, match: hasPrefix(prefix), seq: b.seq})
+	return nil
+}
+
+// Apply applies the batch to the underlying database. The range deletions
+// delete the keys that are in the database at this time and the keys that
+// were put into the batch before them, one by one.
+func (b *Batch) Apply() error {
+	for _, r := range b.ranges {
+		if err := b.deleteMatching(r); err != nil {
+			return err
+		}
+	}
+	return b.Batch.Apply()
+}
+
+func (b *Batch) deleteMatching(r batchRange) error {
+	it := b.db.newIterator(r.prefix, r.match, false)
+	keys := it.keys
+	if err := it.Close(); err != nil {
+		return err
+	}
+	for key := range b.last {
+		if r.match(key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		// Keys that are written after the range deletion are kept.
+		if b.last[key] > r.seq {
+			continue
+		}
+		if err := b.deleteAll(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset resets the batch.
+func (b *Batch) Reset() {
+	b.seq, b.last, b.ranges = 0, nil, nil
+	b.Batch.Reset()
+}
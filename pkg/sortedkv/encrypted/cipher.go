@@ -0,0 +1,155 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MinSecretLen is the minimal length of a Key's Secret.
+	MinSecretLen = 16
+
+	idLen    = 4  // length of the key ID prefix of underlying keys.
+	tokenLen = 16 // length of an encrypted key segment.
+)
+
+// Key is an encryption key. The ID identifies the key among the keys of a
+// Database and must not be reused for a different secret.
+type Key struct {
+	ID     uint32
+	Secret []byte
+}
+
+// cipherKey holds the keys derived from a Key.
+type cipherKey struct {
+	id    [idLen]byte
+	aead  cipher.AEAD
+	index []byte // HMAC key for key segments.
+}
+
+func newCipherKey(k Key) (*cipherKey, error) {
+	if len(k.Secret) < MinSecretLen {
+		return nil, errors.Errorf("secret of key %d too short (%d < %d)", k.ID, len(k.Secret), MinSecretLen)
+	}
+	block, err := aes.NewCipher(derive(k.Secret, "aead"))
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating AEAD")
+	}
+
+	c := &cipherKey{aead: aead, index: derive(k.Secret, "index")}
+	binary.BigEndian.PutUint32(c.id[:], k.ID)
+	return c, nil
+}
+
+// derive derives a 32 byte subkey for the given purpose from secret.
+func derive(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("perun-sortedkv-encrypted-" + purpose)) // nolint: errcheck
+	return mac.Sum(nil)
+}
+
+// encryptKey returns the underlying key of the plaintext key.
+func (c *cipherKey) encryptKey(key string) string {
+	return c.encryptSegments(splitKey(key))
+}
+
+// encryptPrefix returns the underlying prefix of all keys that have the
+// given plaintext prefix. The returned prefix only covers the complete
+// segments of prefix, so the results still need to be filtered.
+func (c *cipherKey) encryptPrefix(prefix string) string {
+	segs := splitKey(prefix)
+	if len(segs) > 0 && !strings.HasSuffix(segs[len(segs)-1], ":") {
+		segs = segs[:len(segs)-1]
+	}
+	return c.encryptSegments(segs)
+}
+
+func (c *cipherKey) encryptSegments(segs []string) string {
+	var key strings.Builder
+	key.Write(c.id[:])
+	mac := hmac.New(sha256.New, c.index)
+	var token []byte
+	for _, seg := range segs {
+		token = c.segmentToken(mac, token, seg)
+		key.Write(token)
+	}
+	return key.String()
+}
+
+// segmentToken chains the token of the previous segments with seg.
+func (c *cipherKey) segmentToken(mac hash.Hash, prev []byte, seg string) []byte {
+	mac.Reset()
+	mac.Write(prev)        // nolint: errcheck
+	mac.Write([]byte(seg)) // nolint: errcheck
+	return mac.Sum(nil)[:tokenLen]
+}
+
+// splitKey splits key after every colon. The empty key has no segments.
+func splitKey(key string) []string {
+	segs := strings.SplitAfter(key, ":")
+	if segs[len(segs)-1] == "" {
+		segs = segs[:len(segs)-1]
+	}
+	return segs
+}
+
+// seal encrypts the plaintext key and value. The underlying key is
+// authenticated as additional data, so entries cannot be swapped.
+func (c *cipherKey) seal(ekey, key string, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+
+	plain := make([]byte, 4, 4+len(key)+len(value))
+	binary.BigEndian.PutUint32(plain, uint32(len(key)))
+	plain = append(append(plain, key...), value...)
+	return c.aead.Seal(nonce, nonce, plain, []byte(ekey)), nil
+}
+
+// open decrypts an entry that was sealed with seal.
+func (c *cipherKey) open(ekey string, sealed []byte) (key string, value []byte, err error) {
+	n := c.aead.NonceSize()
+	if len(sealed) < n {
+		return "", nil, errors.New("sealed value too short")
+	}
+	plain, err := c.aead.Open(nil, sealed[:n], sealed[n:], []byte(ekey))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "decrypting value")
+	}
+	if len(plain) < 4 {
+		return "", nil, errors.New("decrypted value too short")
+	}
+	keyLen := binary.BigEndian.Uint32(plain)
+	if uint64(len(plain)-4) < uint64(keyLen) {
+		return "", nil, errors.New("invalid key length")
+	}
+	return string(plain[4 : 4+keyLen]), plain[4+keyLen:], nil
+}
@@ -0,0 +1,362 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// Database is an encrypting sortedkv.Database. It is safe for concurrent use
+// if the underlying database is.
+type Database struct {
+	db   sortedkv.Database
+	keys []*cipherKey // keys[0] is the current key.
+}
+
+var _ sortedkv.Database = (*Database)(nil)
+
+// NewDatabase creates an encrypting Database on top of db. All data is
+// written with the current key. The old keys are only used for reading data
+// that was written before they were replaced. All key IDs must be distinct.
+func NewDatabase(db sortedkv.Database, current Key, old ...Key) (*Database, error) {
+	d := &Database{db: db}
+	ids := make(map[uint32]bool)
+	for _, k := range append([]Key{current}, old...) {
+		if ids[k.ID] {
+			return nil, errors.Errorf("duplicate key ID %d", k.ID)
+		}
+		ids[k.ID] = true
+
+		c, err := newCipherKey(k)
+		if err != nil {
+			return nil, err
+		}
+		d.keys = append(d.keys, c)
+	}
+	return d, nil
+}
+
+// Has returns true if the database contains a key.
+func (d *Database) Has(key string) (bool, error) {
+	_, err := d.GetBytes(key)
+	if _, ok := err.(*sortedkv.ErrNotFound); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get returns the value of a key.
+func (d *Database) Get(key string) (string, error) {
+	value, err := d.GetBytes(key)
+	return string(value), err
+}
+
+// GetBytes returns the value of a key. The current key is tried first, then
+// the old keys.
+func (d *Database) GetBytes(key string) ([]byte, error) {
+	for _, c := range d.keys {
+		ekey := c.encryptKey(key)
+		has, err := d.db.Has(ekey)
+		if err != nil {
+			return nil, err
+		} else if !has {
+			continue
+		}
+
+		sealed, err := d.db.GetBytes(ekey)
+		if err != nil {
+			return nil, err
+		}
+		pkey, value, err := c.open(ekey, sealed)
+		if err != nil {
+			return nil, err
+		}
+		if pkey != key {
+			return nil, errors.Errorf("entry of key %q has key %q", key, pkey)
+		}
+		return value, nil
+	}
+	return nil, &sortedkv.ErrNotFound{Key: key}
+}
+
+// Put saves a value under a key.
+func (d *Database) Put(key string, value string) error {
+	return d.PutBytes(key, []byte(value))
+}
+
+// PutBytes encrypts a value with the current key and saves it under a key.
+// Entries of the key that were written with old keys are deleted.
+func (d *Database) PutBytes(key string, value []byte) error {
+	b := d.NewBatch()
+	if err := b.PutBytes(key, value); err != nil {
+		return err
+	}
+	return b.Apply()
+}
+
+// Delete deletes a key from the database.
+func (d *Database) Delete(key string) error {
+	if has, err := d.Has(key); err != nil {
+		return err
+	} else if !has {
+		return &sortedkv.ErrNotFound{Key: key}
+	}
+
+	b := d.NewBatch()
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	return b.Apply()
+}
+
+// NewBatch creates a new batch. The underlying database must support
+// deleting absent keys in batches.
+func (d *Database) NewBatch() sortedkv.Batch {
+	return &Batch{Batch: d.db.NewBatch(), db: d}
+}
+
+// DeleteRange deletes all keys in [start, end) in a single batch.
+func (d *Database) DeleteRange(start string, end string) error {
+	b := d.NewBatch()
+	if err := b.DeleteRange(start, end); err != nil {
+		return err
+	}
+	return b.Apply()
+}
+
+// DeletePrefix deletes all keys with a prefix in a single batch.
+func (d *Database) DeletePrefix(prefix string) error {
+	b := d.NewBatch()
+	if err := b.DeletePrefix(prefix); err != nil {
+		return err
+	}
+	return b.Apply()
+}
+
+// ApproximateSize returns the length of all decrypted keys and values in
+// [start, end). The encrypted entries are slightly larger. All entries under
+// the common prefix of start and end are decrypted, see the package
+// documentation.
+func (d *Database) ApproximateSize(start string, end string) (int64, error) {
+	var size int64
+	err := d.scan(rangePrefix(start, end), inRange(start, end), func(key string, value []byte) {
+		size += int64(len(key) + len(value))
+	})
+	return size, err
+}
+
+// Count returns the number of keys in [start, end). All entries under the
+// common prefix of start and end are decrypted, see the package documentation.
+func (d *Database) Count(start string, end string) (int, error) {
+	var n int
+	err := d.scan(rangePrefix(start, end), inRange(start, end), func(string, []byte) { n++ })
+	return n, err
+}
+
+// NewIterator creates an iterator over the whole database.
+func (d *Database) NewIterator() sortedkv.Iterator {
+	return d.NewIteratorWithPrefix("")
+}
+
+// NewIteratorWithRange creates an iterator over the keys in [start, end).
+// Since the underlying keys are not ordered, all entries under the common
+// prefix of start and end are read.
+func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return d.newIterator(rangePrefix(start, end), inRange(start, end), false)
+}
+
+// NewIteratorWithPrefix creates an iterator over the keys with a prefix.
+func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.newIterator(prefix, hasPrefix(prefix), false)
+}
+
+// NewReverseIterator creates a reverse iterator over the whole database.
+func (d *Database) NewReverseIterator() sortedkv.Iterator {
+	return d.NewReverseIteratorWithPrefix("")
+}
+
+// NewReverseIteratorWithRange creates a reverse iterator over the keys in
+// [start, end).
+func (d *Database) NewReverseIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return d.newIterator(rangePrefix(start, end), inRange(start, end), true)
+}
+
+// NewReverseIteratorWithPrefix creates a reverse iterator over the keys with a
+// prefix.
+func (d *Database) NewReverseIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.newIterator(prefix, hasPrefix(prefix), true)
+}
+
+// rangePrefix returns the common prefix of start and end, which all keys in
+// [start, end) have.
+func rangePrefix(start string, end string) string {
+	if end == "" {
+		return ""
+	}
+	i := 0
+	for i < len(start) && i < len(end) && start[i] == end[i] {
+		i++
+	}
+	return start[:i]
+}
+
+func inRange(start string, end string) func(string) bool {
+	return func(key string) bool {
+		return key >= start && (end == "" || key < end)
+	}
+}
+
+func hasPrefix(prefix string) func(string) bool {
+	return func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+}
+
+// scan decrypts all entries whose keys have the given prefix and calls f for
+// those that pass the filter, in no particular order. The underlying entries
+// that are read are those under the longest prefix of prefix that ends with a
+// colon. If a key was written with several keys, only the entry of the newest
+// key is passed to f.
+func (d *Database) scan(prefix string, filter func(string) bool, f func(key string, value []byte)) error {
+	var seen map[string]bool // only needed if there are old keys
+	if len(d.keys) > 1 {
+		seen = make(map[string]bool)
+	}
+	for _, c := range d.keys {
+		it := d.db.NewIteratorWithPrefix(c.encryptPrefix(prefix))
+		for it.Next() {
+			key, value, err := c.open(it.Key(), it.ValueBytes())
+			if err != nil {
+				it.Close() // nolint: errcheck
+				return err
+			}
+			if seen[key] || !filter(key) {
+				continue
+			}
+			if seen != nil {
+				seen[key] = true
+			}
+			f(key, value)
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newIterator decrypts all entries whose keys have the given prefix and pass
+// the filter and returns them in ascending, or descending if reverse is set,
+// key order. All entries are held in memory.
+func (d *Database) newIterator(prefix string, filter func(string) bool, reverse bool) *Iterator {
+	entries := make(map[string][]byte)
+	if err := d.scan(prefix, filter, func(key string, value []byte) {
+		entries[key] = value
+	}); err != nil {
+		return &Iterator{err: err}
+	}
+
+	it := &Iterator{keys: make([]string, 0, len(entries)), reverse: reverse}
+	for key := range entries {
+		it.keys = append(it.keys, key)
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(it.keys)))
+	} else {
+		sort.Strings(it.keys)
+	}
+	it.values = make([][]byte, len(it.keys))
+	for i, key := range it.keys {
+		it.values[i] = entries[key]
+	}
+	return it
+}
+
+// Reencrypt rewrites all entries that were written with old keys with the
+// current key. Afterwards, the old keys are no longer needed to read the
+// database.
+func (d *Database) Reencrypt() error {
+	for _, c := range d.keys[1:] {
+		b := d.db.NewBatch()
+		it := d.db.NewIteratorWithPrefix(string(c.id[:]))
+		for it.Next() {
+			key, value, err := c.open(it.Key(), it.ValueBytes())
+			if err != nil {
+				it.Close() // nolint: errcheck
+				return errors.WithMessage(err, "decrypting entry")
+			}
+			// Old entries are shadowed by entries of newer keys.
+			if !d.shadowed(c, key) {
+				if err := d.putCurrent(b, key, value); err != nil {
+					it.Close() // nolint: errcheck
+					return err
+				}
+			}
+			if err := b.Delete(it.Key()); err != nil {
+				it.Close() // nolint: errcheck
+				return err
+			}
+		}
+		if err := it.Close(); err != nil {
+			return errors.WithMessage(err, "iterating entries")
+		}
+		if err := b.Apply(); err != nil {
+			return errors.WithMessage(err, "applying batch")
+		}
+	}
+	return nil
+}
+
+// shadowed returns whether key has an entry with a key newer than c.
+func (d *Database) shadowed(c *cipherKey, key string) bool {
+	for _, newer := range d.keys {
+		if newer == c {
+			return false
+		}
+		if has, err := d.db.Has(newer.encryptKey(key)); err == nil && has {
+			return true
+		}
+	}
+	return false
+}
+
+// putCurrent writes an encrypted entry with the current key to b.
+func (d *Database) putCurrent(b sortedkv.Writer, key string, value []byte) error {
+	c := d.keys[0]
+	ekey := c.encryptKey(key)
+	sealed, err := c.seal(ekey, key, value)
+	if err != nil {
+		return err
+	}
+	return b.PutBytes(ekey, sealed)
+}
+
+// Sync syncs the underlying database if it is a sortedkv.Syncer.
+func (d *Database) Sync() error {
+	if s, ok := d.db.(sortedkv.Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying database.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	"perun.network/go-perun/pkg/sortedkv/test"
+)
+
+var (
+	key1 = Key{ID: 1, Secret: []byte("0123456789abcdef")}
+	key2 = Key{ID: 2, Secret: []byte("fedcba9876543210")}
+)
+
+func TestDatabase(t *testing.T) {
+	t.Run("Generic Database test", func(t *testing.T) {
+		test.GenericDatabaseTest(t, newDatabase(t, memorydb.NewDatabase(), key1))
+	})
+	t.Run("Generic Batch test", func(t *testing.T) {
+		test.GenericBatchTest(t, newDatabase(t, memorydb.NewDatabase(), key1))
+	})
+	t.Run("Generic Iterator test", func(t *testing.T) {
+		test.GenericIteratorTest(t, newDatabase(t, memorydb.NewDatabase(), key1))
+	})
+	t.Run("Generic Table test", func(t *testing.T) {
+		test.GenericTableTest(t, newDatabase(t, memorydb.NewDatabase(), key1))
+	})
+	t.Run("Generic Database test with old key", func(t *testing.T) {
+		test.GenericDatabaseTest(t, newDatabase(t, memorydb.NewDatabase(), key2, key1))
+	})
+}
+
+func TestNewDatabase(t *testing.T) {
+	_, err := NewDatabase(memorydb.NewDatabase(), key1, key1)
+	assert.Error(t, err, "duplicate key ID")
+	_, err = NewDatabase(memorydb.NewDatabase(), Key{ID: 1, Secret: []byte("short")})
+	assert.Error(t, err, "short secret")
+}
+
+func TestDatabase_Plaintext(t *testing.T) {
+	data := make(map[string]string)
+	db := newDatabase(t, memorydb.FromData(data), key1)
+	require.NoError(t, db.Put("Chan:secret-channel:params", "secret-value"))
+	require.NoError(t, db.Put("Chan:secret-channel:phase", "secret-phase"))
+
+	require.Len(t, data, 2)
+	for k, v := range data {
+		assert.NotContains(t, k, "secret")
+		assert.NotContains(t, k, "Chan")
+		assert.NotContains(t, v, "secret")
+	}
+
+	// Entries of a table share the underlying prefix.
+	c := db.keys[0]
+	prefix := c.encryptPrefix("Chan:secret-channel:")
+	for k := range data {
+		assert.True(t, strings.HasPrefix(k, prefix))
+	}
+
+	// A database with another key cannot read the entries.
+	other := newDatabase(t, memorydb.FromData(data), key2)
+	_, err := other.Get("Chan:secret-channel:params")
+	assert.Error(t, err)
+}
+
+func TestDatabase_Prefix(t *testing.T) {
+	db := newDatabase(t, memorydb.NewDatabase(), key1)
+	for _, k := range []string{"a:b:2", "a:b:1", "a:bc", "a:c:1", "a", "b:a:1"} {
+		require.NoError(t, db.Put(k, k))
+	}
+
+	requireKeys(t, db.NewIteratorWithPrefix("a:b"), "a:b:1", "a:b:2", "a:bc")
+	requireKeys(t, db.NewIteratorWithPrefix("a:b:"), "a:b:1", "a:b:2")
+	requireKeys(t, db.NewIteratorWithPrefix(""), "a", "a:b:1", "a:b:2", "a:bc", "a:c:1", "b:a:1")
+	requireKeys(t, db.NewIteratorWithRange("a:b:2", "b"), "a:b:2", "a:bc", "a:c:1")
+	requireKeys(t, sortedkv.NewTable(db, "a:").NewIteratorWithPrefix("b:"), "b:1", "b:2")
+}
+
+func TestDatabase_Reencrypt(t *testing.T) {
+	data := make(map[string]string)
+	db := newDatabase(t, memorydb.FromData(data), key1)
+	require.NoError(t, db.Put("k:1", "v1"))
+	require.NoError(t, db.Put("k:2", "v2"))
+	require.NoError(t, db.Put("k:3", "v3"))
+
+	// Rotate to key2. Old entries stay readable, writes use key2.
+	db = newDatabase(t, memorydb.FromData(data), key2, key1)
+	(&test.DatabaseTest{T: t, Database: db}).MustGetEqual("k:1", "v1")
+	require.NoError(t, db.Put("k:2", "v2'"))
+	require.NoError(t, db.Delete("k:3"))
+	requireKeys(t, db.NewIterator(), "k:1", "k:2")
+	assert.Len(t, data, 2, "old entries of written keys removed")
+
+	require.NoError(t, db.Reencrypt())
+	assert.Len(t, data, 2)
+
+	// Only key2 is needed afterwards.
+	db = newDatabase(t, memorydb.FromData(data), key2)
+	dbtest := test.DatabaseTest{T: t, Database: db}
+	dbtest.MustGetEqual("k:1", "v1")
+	dbtest.MustGetEqual("k:2", "v2'")
+	dbtest.MustNotHave("k:3")
+}
+
+func newDatabase(t *testing.T, db sortedkv.Database, current Key, old ...Key) *Database {
+	d, err := NewDatabase(db, current, old...)
+	require.NoError(t, err)
+	return d
+}
+
+func requireKeys(t *testing.T, it sortedkv.Iterator, keys ...string) {
+	var actual []string
+	for it.Next() {
+		actual = append(actual, it.Key())
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, keys, actual)
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encrypted implements a sortedkv.Database that encrypts all data
+// before writing it to an underlying sortedkv.Database.
+//
+// Values are sealed with AES-256-GCM together with their plaintext key. Keys
+// are split after every colon (':') into segments, and every segment is
+// replaced by a truncated HMAC-SHA256 of the segment and its predecessors.
+// Hence, iterating over a prefix that ends with a colon, like the prefix of a
+// sortedkv table, only reads the entries with that prefix. Iterators decrypt
+// the plaintext keys and yield them in ascending order.
+//
+// Limitations
+//
+// Since the underlying keys are not ordered like the plaintext keys, every
+// iterator decrypts all entries under its prefix, truncated after the last
+// colon, and holds them in memory to sort them. Range iterators, Count and
+// ApproximateSize read all entries under the common prefix of the range's
+// start and end, which is the whole database if the range is not within a
+// single table. Hence, iterating a table or a range costs time and memory in
+// the size of the whole table or database, not in the number of entries that
+// are returned. For example, restoring all channels of a PersistRestorer or
+// querying its archive reads the respective tables completely. Count and
+// ApproximateSize do not hold the values in memory.
+//
+// Key rotation
+//
+// Every Key has an ID, which prefixes all underlying keys that are written
+// with it. A Database writes with its current key and reads with all its
+// keys, so a new current key can be introduced while the old keys stay
+// readable. Reencrypt rewrites all entries of old keys with the current key,
+// after which the old keys are no longer needed.
+package encrypted // import "perun.network/go-perun/pkg/sortedkv/encrypted"
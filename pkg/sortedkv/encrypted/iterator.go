@@ -0,0 +1,80 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import "sort"
+
+// Iterator iterates over decrypted entries. All entries are decrypted and held
+// in memory when the iterator is created, see the package documentation.
+type Iterator struct {
+	next    int
+	keys    []string
+	values  [][]byte
+	reverse bool
+	err     error
+}
+
+// Next returns true if the iterator has a next element.
+func (i *Iterator) Next() bool {
+	if i.err != nil || i.next >= len(i.keys) {
+		return false
+	}
+	i.next++
+	return true
+}
+
+// Key returns the key of the current element.
+func (i *Iterator) Key() string {
+	if i.next == 0 || i.next > len(i.keys) {
+		return ""
+	}
+	return i.keys[i.next-1]
+}
+
+// Value returns the value of the current element.
+func (i *Iterator) Value() string {
+	return string(i.ValueBytes())
+}
+
+// ValueBytes returns the value of the current element.
+func (i *Iterator) ValueBytes() []byte {
+	if i.next == 0 || i.next > len(i.keys) {
+		return nil
+	}
+	return i.values[i.next-1]
+}
+
+// Seek moves the iterator to the first element whose key is not before key in
+// iteration order.
+func (i *Iterator) Seek(key string) bool {
+	if i.err != nil {
+		return false
+	}
+	if i.reverse {
+		i.next = sort.Search(len(i.keys), func(j int) bool { return i.keys[j] <= key })
+	} else {
+		i.next = sort.SearchStrings(i.keys, key)
+	}
+	i.next++
+	return i.next <= len(i.keys)
+}
+
+// Close closes the iterator and returns the error that occurred while
+// decrypting the entries.
+func (i *Iterator) Close() error {
+	i.keys = nil
+	i.values = nil
+	return i.err
+}
@@ -39,6 +39,14 @@ type Iterator interface {
 	// may change on the next call to Next.
 	ValueBytes() []byte
 
+	// Seek moves the iterator to the first key/value pair in iteration order
+	// whose key is not before the given key. That is, its key is greater than
+	// or equal to the given key for forward iterators and less than or equal
+	// for reverse iterators. It returns whether such a pair exists in the
+	// iterator's range. The pair becomes the current key/value pair and the
+	// following call to Next moves past it.
+	Seek(key string) bool
+
 	// Close releases associated resources. It returns any accumulated error.
 	// Exhausting all the key/value pairs is not considered to be an error.
 	// Close can be called multiple times.
@@ -60,4 +68,17 @@ type Iterable interface {
 	// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
 	// of database content with a particular key prefix.
 	NewIteratorWithPrefix(prefix string) Iterator
+
+	// NewReverseIterator creates an iterator over the entire keyspace in
+	// reverse binary-alphabetical order.
+	NewReverseIterator() Iterator
+
+	// NewReverseIteratorWithRange creates an iterator over the key range
+	// [start, end) in reverse binary-alphabetical order. Empty bounds are
+	// treated as for NewIteratorWithRange.
+	NewReverseIteratorWithRange(start string, end string) Iterator
+
+	// NewReverseIteratorWithPrefix creates an iterator over the keys with a
+	// particular prefix in reverse binary-alphabetical order.
+	NewReverseIteratorWithPrefix(prefix string) Iterator
 }
@@ -17,12 +17,23 @@ package leveldb
 import (
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Batch represents a batch and implements the batch interface.
 type Batch struct {
 	*leveldb.Batch
 	db *leveldb.DB
+	// ranges are the range deletions of the batch. LevelDB batches have no
+	// range deletion, so they are resolved when the batch is applied.
+	ranges []batchRange
+}
+
+// batchRange is a range deletion that comes before the pos-th record of the
+// batch.
+type batchRange struct {
+	slice *util.Range
+	pos   int
 }
 
 // Put puts a new value in the batch.
@@ -42,13 +53,101 @@ func (b *Batch) Delete(key string) error {
 	return nil
 }
 
-// Apply applies the batch to the database.
+// DeleteRange deletes a range of keys.
+func (b *Batch) DeleteRange(start string, end string) error {
+	b.ranges = append(b.ranges, batchRange{rangeSlice(start, end), b.Batch.Len()})
+	return nil
+}
+
+// DeletePrefix deletes all keys with a prefix.
+func (b *Batch) DeletePrefix(prefix string) error {
+	b.ranges = append(b.ranges, batchRange{prefixSlice(prefix), b.Batch.Len()})
+	return nil
+}
+
+// Apply applies the batch to the database. Batches with range deletions are
+// applied in a transaction, in which the ranges are deleted key by key.
 func (b *Batch) Apply() error {
-	err := b.db.Write(b.Batch, nil)
-	return errors.Wrap(err, "leveldb batch apply error")
+	if len(b.ranges) == 0 {
+		err := b.db.Write(b.Batch, nil)
+		return errors.Wrap(err, "leveldb batch apply error")
+	}
+
+	tx, err := b.db.OpenTransaction()
+	if err != nil {
+		return errors.Wrap(err, "leveldb batch apply error")
+	}
+	r := &txReplay{tx: tx, ranges: b.ranges}
+	if err := b.Batch.Replay(r); err != nil {
+		r.setErr(err)
+	}
+	r.deleteRanges(b.Batch.Len())
+	if r.err != nil {
+		tx.Discard()
+		return errors.Wrap(r.err, "leveldb batch apply error")
+	}
+	return errors.Wrap(tx.Commit(), "leveldb batch apply error")
 }
 
 // Reset resets the batch.
 func (b *Batch) Reset() {
 	b.Batch.Reset()
+	b.ranges = nil
+}
+
+// txReplay replays the records of a batch into a transaction and deletes the
+// ranges of the batch before the records that follow them.
+type txReplay struct {
+	tx     *leveldb.Transaction
+	ranges []batchRange
+	pos    int
+	err    error
+}
+
+func (r *txReplay) Put(key, value []byte) {
+	r.deleteRanges(r.pos)
+	r.setErr(r.tx.Put(key, value, nil))
+	r.pos++
+}
+
+func (r *txReplay) Delete(key []byte) {
+	r.deleteRanges(r.pos)
+	r.setErr(r.tx.Delete(key, nil))
+	r.pos++
+}
+
+// deleteRanges deletes the keys of all ranges that come before the pos-th
+// record.
+func (r *txReplay) deleteRanges(pos int) {
+	for len(r.ranges) > 0 && r.ranges[0].pos <= pos {
+		r.setErr(deleteInTx(r.tx, r.ranges[0].slice))
+		r.ranges = r.ranges[1:]
+	}
+}
+
+func (r *txReplay) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// deleteInTx deletes all keys in a slice of a transaction, including the keys
+// written by the transaction.
+func deleteInTx(tx *leveldb.Transaction, slice *util.Range) error {
+	var keys [][]byte
+	it := tx.NewIterator(slice, nil)
+	for it.Next() {
+		keys = append(keys, append([]byte{}, it.Key()...))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := tx.Delete(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
 }
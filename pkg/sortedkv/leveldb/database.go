@@ -15,10 +15,11 @@
 package leveldb
 
 import (
-	"sync"
+	"bytes"
 
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"perun.network/go-perun/pkg/sortedkv"
@@ -96,43 +97,103 @@ func (d *Database) Delete(key string) error {
 	return errors.Wrap(err, "Database.Delete(key) error")
 }
 
+// RangeDeleter interface.
+
+// DeleteRange deletes all keys in a range in a single batch.
+func (d *Database) DeleteRange(start string, end string) error {
+	return errors.Wrap(d.deleteSlice(rangeSlice(start, end)), "Database.DeleteRange(start, end) error")
+}
+
+// DeletePrefix deletes all keys with a prefix in a single batch.
+func (d *Database) DeletePrefix(prefix string) error {
+	return errors.Wrap(d.deleteSlice(prefixSlice(prefix)), "Database.DeletePrefix(prefix) error")
+}
+
+// deleteSlice deletes all keys in a slice in a single batch.
+func (d *Database) deleteSlice(slice *util.Range) error {
+	b := &Batch{Batch: &leveldb.Batch{}, db: d.DB}
+	b.ranges = []batchRange{{slice: slice}}
+	return b.Apply()
+}
+
+// Syncer interface.
+
+// syncKey is deleted by Sync. It must not be used otherwise.
+var syncKey = []byte("\x00sortedkv:sync")
+
+// Sync flushes all previous writes to stable storage. LevelDB appends all
+// writes to a journal, so a synced write also syncs all earlier writes. Sync
+// makes a synced deletion of syncKey, which is never set.
+func (d *Database) Sync() error {
+	err := d.DB.Delete(syncKey, &opt.WriteOptions{Sync: true})
+	return errors.Wrap(err, "Database.Sync() error")
+}
+
+// Sizer interface.
+
+// ApproximateSize returns the approximate size of a range in the database
+// files. Recent writes that are not yet flushed to a file are not included.
+func (d *Database) ApproximateSize(start string, end string) (int64, error) {
+	slice := rangeSlice(start, end)
+	if slice.Limit == nil {
+		slice.Limit = maxKey
+	}
+	sizes, err := d.DB.SizeOf([]util.Range{*slice})
+	return sizes.Sum(), errors.Wrap(err, "Database.ApproximateSize(start, end) error")
+}
+
+// maxKey is used as the limit of ranges without end. It is greater than all
+// keys that are used in practice.
+var maxKey = bytes.Repeat([]byte{0xff}, 256)
+
+// Count counts the keys in a range.
+func (d *Database) Count(start string, end string) (int, error) {
+	var n int
+	it := d.DB.NewIterator(rangeSlice(start, end), nil)
+	for it.Next() {
+		n++
+	}
+	it.Release()
+	return n, errors.Wrap(it.Error(), "Database.Count(start, end) error")
+}
+
 // Batcher interface.
 
 // NewBatch creates a new batch.
 func (d *Database) NewBatch() sortedkv.Batch {
-	return &Batch{&leveldb.Batch{}, d.DB}
+	return &Batch{Batch: &leveldb.Batch{}, db: d.DB}
 }
 
 // Iterateable interface.
 
 // NewIterator creates a new iterator.
 func (d *Database) NewIterator() sortedkv.Iterator {
-	return &Iterator{d.DB.NewIterator(&util.Range{Start: nil, Limit: nil}, nil), sync.Mutex{}}
+	return newIterator(d.DB, nil, false)
 }
 
 // NewIteratorWithRange creates a new iterator based on a given range.
 func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
-	var Start []byte
-	var End []byte
-
-	if len(start) != 0 {
-		Start = []byte(start)
-	}
-
-	if len(end) != 0 {
-		End = []byte(end)
-	}
-
-	return &Iterator{d.DB.NewIterator(&util.Range{Start: Start, Limit: End}, nil), sync.Mutex{}}
+	return newIterator(d.DB, rangeSlice(start, end), false)
 }
 
 // NewIteratorWithPrefix creates a new iterator for a given prefix.
 func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
-	var slice *util.Range
+	return newIterator(d.DB, prefixSlice(prefix), false)
+}
 
-	if len(prefix) != 0 {
-		slice = util.BytesPrefix([]byte(prefix))
-	}
+// NewReverseIterator creates a new reverse iterator.
+func (d *Database) NewReverseIterator() sortedkv.Iterator {
+	return newIterator(d.DB, nil, true)
+}
+
+// NewReverseIteratorWithRange creates a new reverse iterator based on a given
+// range.
+func (d *Database) NewReverseIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return newIterator(d.DB, rangeSlice(start, end), true)
+}
 
-	return &Iterator{d.DB.NewIterator(slice, nil), sync.Mutex{}}
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a given
+// prefix.
+func (d *Database) NewReverseIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return newIterator(d.DB, prefixSlice(prefix), true)
 }
@@ -18,13 +18,59 @@ import (
 	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
 	"perun.network/go-perun/log"
 )
 
+// iterable is implemented by leveldb.DB and leveldb.Snapshot.
+type iterable interface {
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// newIterator creates a new iterator over the given slice of src. A nil slice
+// iterates over all entries.
+func newIterator(src iterable, slice *util.Range, reverse bool) *Iterator {
+	return &Iterator{Iterator: src.NewIterator(slice, nil), reverse: reverse}
+}
+
+// rangeSlice returns the slice of keys in [start, end). Empty bounds are
+// unbounded.
+func rangeSlice(start string, end string) *util.Range {
+	var Start []byte
+	var End []byte
+
+	if len(start) != 0 {
+		Start = []byte(start)
+	}
+
+	if len(end) != 0 {
+		End = []byte(end)
+	}
+
+	return &util.Range{Start: Start, Limit: End}
+}
+
+// prefixSlice returns the slice of keys with the given prefix.
+func prefixSlice(prefix string) *util.Range {
+	var slice *util.Range
+
+	if len(prefix) != 0 {
+		slice = util.BytesPrefix([]byte(prefix))
+	}
+
+	return slice
+}
+
 // Iterator provides an iterator over a key range.
 type Iterator struct {
 	iterator.Iterator
 	mu sync.Mutex
+
+	reverse bool
+	// positioned is set once a reverse iterator was moved to its last entry.
+	positioned bool
 }
 
 // Next returns true if the iterator has a next element.
@@ -37,7 +83,37 @@ func (i *Iterator) Next() bool {
 		return false
 	}
 
-	return i.Iterator.Next()
+	if !i.reverse {
+		return i.Iterator.Next()
+	}
+	if !i.positioned {
+		i.positioned = true
+		return i.Iterator.Last()
+	}
+	return i.Iterator.Prev()
+}
+
+// Seek moves the iterator to the first entry whose key is not before key in
+// iteration order.
+func (i *Iterator) Seek(key string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.Iterator == nil {
+		return false
+	}
+
+	if !i.reverse {
+		return i.Iterator.Seek([]byte(key))
+	}
+	i.positioned = true
+	if !i.Iterator.Seek([]byte(key)) {
+		return i.Iterator.Last()
+	}
+	if string(i.Iterator.Key()) == key {
+		return true
+	}
+	return i.Iterator.Prev()
 }
 
 // Key returns the key of the current element.
@@ -46,6 +46,28 @@ func TestIterator(t *testing.T) {
 	})
 }
 
+func TestSnapshot(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericSnapshotTest(t, db)
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		test.GenericTransactionTest(t, db)
+	})
+}
+
+func TestDatabase_Sync(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		require.NoError(t, db.Put("key", "value"))
+		require.NoError(t, db.Sync())
+		n, err := db.Count("", "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, n, "Sync should not add keys")
+	})
+}
+
 func runTestOnTempDatabase(t *testing.T, tester func(*Database)) {
 	// Create a temporary directory and delete it when done
 	path, err := ioutil.TempDir("", "perun_testdb_")
@@ -59,3 +81,15 @@ func runTestOnTempDatabase(t *testing.T, tester func(*Database)) {
 
 	tester(db)
 }
+
+func BenchmarkDatabase(b *testing.B) {
+	path, err := ioutil.TempDir("", "perun_benchdb_")
+	require.NoError(b, err)
+	defer os.RemoveAll(path)
+
+	db, err := LoadDatabase(path)
+	require.NoError(b, err)
+	defer db.DB.Close()
+
+	test.GenericDatabaseBenchmark(b, db)
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leveldb
+
+import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// Snapshot implements the sortedkv.Snapshot interface using a LevelDB
+// snapshot.
+type Snapshot struct {
+	*leveldb.Snapshot
+}
+
+// Snapshot takes a snapshot of the current database content.
+func (d *Database) Snapshot() (sortedkv.Snapshot, error) {
+	s, err := d.DB.GetSnapshot()
+	if err != nil {
+		return nil, errors.Wrap(err, "Database.Snapshot() error")
+	}
+	return &Snapshot{s}, nil
+}
+
+// Has returns true if the snapshot contains a key.
+func (s *Snapshot) Has(key string) (bool, error) {
+	has, err := s.Snapshot.Has([]byte(key), nil)
+	return has, errors.Wrap(err, "Snapshot.Has(key) error")
+}
+
+// Get returns a value to a key.
+func (s *Snapshot) Get(key string) (string, error) {
+	val, err := s.GetBytes(key)
+	return string(val), err
+}
+
+// GetBytes returns a value to a key in bytes.
+func (s *Snapshot) GetBytes(key string) ([]byte, error) {
+	val, err := s.Snapshot.Get([]byte(key), nil)
+	return val, errors.Wrap(err, "Snapshot.Get(key) error")
+}
+
+// NewIterator creates a new iterator.
+func (s *Snapshot) NewIterator() sortedkv.Iterator {
+	return newIterator(s.Snapshot, nil, false)
+}
+
+// NewIteratorWithRange creates a new iterator based on a given range.
+func (s *Snapshot) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return newIterator(s.Snapshot, rangeSlice(start, end), false)
+}
+
+// NewIteratorWithPrefix creates a new iterator for a given prefix.
+func (s *Snapshot) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return newIterator(s.Snapshot, prefixSlice(prefix), false)
+}
+
+// NewReverseIterator creates a new reverse iterator.
+func (s *Snapshot) NewReverseIterator() sortedkv.Iterator {
+	return newIterator(s.Snapshot, nil, true)
+}
+
+// NewReverseIteratorWithRange creates a new reverse iterator based on a given
+// range.
+func (s *Snapshot) NewReverseIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return newIterator(s.Snapshot, rangeSlice(start, end), true)
+}
+
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a given
+// prefix.
+func (s *Snapshot) NewReverseIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return newIterator(s.Snapshot, prefixSlice(prefix), true)
+}
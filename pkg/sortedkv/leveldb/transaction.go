@@ -0,0 +1,98 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leveldb
+
+import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// Transaction implements the sortedkv.Transaction interface using a LevelDB
+// transaction.
+type Transaction struct {
+	*leveldb.Transaction
+	closed bool
+}
+
+// NewTransaction opens a new transaction. Writes to the database block until
+// it is committed or discarded.
+func (d *Database) NewTransaction() (sortedkv.Transaction, error) {
+	tx, err := d.DB.OpenTransaction()
+	if err != nil {
+		return nil, errors.Wrap(err, "Database.NewTransaction() error")
+	}
+	return &Transaction{Transaction: tx}, nil
+}
+
+// Has returns true if the key is present in the database or was written by the
+// transaction.
+func (tx *Transaction) Has(key string) (bool, error) {
+	has, err := tx.Transaction.Has([]byte(key), nil)
+	return has, errors.Wrap(err, "Transaction.Has(key) error")
+}
+
+// Get returns the value of a key as seen by the transaction.
+func (tx *Transaction) Get(key string) (string, error) {
+	val, err := tx.GetBytes(key)
+	return string(val), err
+}
+
+// GetBytes returns the value of a key in bytes as seen by the transaction.
+func (tx *Transaction) GetBytes(key string) ([]byte, error) {
+	val, err := tx.Transaction.Get([]byte(key), nil)
+	return val, errors.Wrap(err, "Transaction.Get(key) error")
+}
+
+// Put saves a value under a key.
+func (tx *Transaction) Put(key string, value string) error {
+	return tx.PutBytes(key, []byte(value))
+}
+
+// PutBytes saves a bytes value under a key.
+func (tx *Transaction) PutBytes(key string, value []byte) error {
+	err := tx.Transaction.Put([]byte(key), value, nil)
+	return errors.Wrap(err, "Transaction.Put(key, value) error")
+}
+
+// Delete deletes a key. It returns an error if the key is not present.
+func (tx *Transaction) Delete(key string) error {
+	has, err := tx.Has(key)
+	if err != nil {
+		return errors.Wrap(err, "Transaction.Delete(key) error")
+	}
+
+	if !has {
+		return &sortedkv.ErrNotFound{Key: key}
+	}
+
+	err = tx.Transaction.Delete([]byte(key), nil)
+	return errors.Wrap(err, "Transaction.Delete(key) error")
+}
+
+// Commit applies the writes of the transaction to the database at once.
+func (tx *Transaction) Commit() error {
+	tx.closed = true
+	return errors.Wrap(tx.Transaction.Commit(), "Transaction.Commit() error")
+}
+
+// Discard closes the transaction without applying its writes.
+func (tx *Transaction) Discard() {
+	if !tx.closed {
+		tx.closed = true
+		tx.Transaction.Discard()
+	}
+}
@@ -14,15 +14,14 @@
 
 package memorydb
 
-import (
-	"github.com/pkg/errors"
-)
-
 // Batch represents a batch and implements the batch interface.
 type Batch struct {
 	db      *Database
 	writes  map[string]string
 	deletes map[string]struct{}
+	// ranges are applied before deletes and writes. Earlier writes that match
+	// are removed from the batch.
+	ranges []func(string) bool
 }
 
 // Put puts a new value in the batch.
@@ -44,26 +43,61 @@ func (b *Batch) Delete(key string) error {
 	return nil
 }
 
-// Apply applies the batch to the database.
-func (b *Batch) Apply() error {
-	for key, value := range b.writes {
-		err := b.db.Put(key, value)
-		if err != nil {
-			return errors.Wrap(err, "failed to put entry")
+// DeleteRange deletes a range of keys.
+func (b *Batch) DeleteRange(start string, end string) error {
+	b.deleteMatching(inRange(start, end))
+	return nil
+}
+
+// DeletePrefix deletes all keys with a prefix.
+func (b *Batch) DeletePrefix(prefix string) error {
+	b.deleteMatching(hasPrefix(prefix))
+	return nil
+}
+
+func (b *Batch) deleteMatching(match func(string) bool) {
+	for key := range b.writes {
+		if match(key) {
+			delete(b.writes, key)
 		}
 	}
+	b.ranges = append(b.ranges, match)
+}
 
-	for key := range b.deletes {
-		err := b.db.Delete(key)
-		if err != nil {
-			return errors.Wrap(err, "failed to delete entry")
+// Apply applies the batch to the database. All changes become visible to
+// readers at once.
+func (b *Batch) Apply() error {
+	b.db.txMutex.Lock()
+	defer b.db.txMutex.Unlock()
+	b.apply()
+	return nil
+}
+
+// apply applies the batch to the database. The txMutex must be held already.
+func (b *Batch) apply() {
+	b.db.mutex.Lock()
+	defer b.db.mutex.Unlock()
+
+	b.db.own()
+	for key := range b.db.data {
+		for _, match := range b.ranges {
+			if match(key) {
+				delete(b.db.data, key)
+				break
+			}
 		}
 	}
-	return nil
+	for key, value := range b.writes {
+		b.db.data[key] = value
+	}
+	for key := range b.deletes {
+		delete(b.db.data, key)
+	}
 }
 
 // Reset resets the batch.
 func (b *Batch) Reset() {
 	b.writes = make(map[string]string)
 	b.deletes = make(map[string]struct{})
+	b.ranges = nil
 }
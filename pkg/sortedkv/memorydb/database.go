@@ -34,8 +34,13 @@ import (
 
 // Database implements the Database interface and stores the values in memory.
 type Database struct {
-	mutex sync.RWMutex
-	data  map[string]string
+	// txMutex is held by writers and for the whole lifetime of transactions.
+	txMutex sync.Mutex
+	mutex   sync.RWMutex
+	data    map[string]string
+	// snap is the database of the snapshots that were taken since the last
+	// write. It shares data until the next write.
+	snap *Database
 }
 
 // NewDatabase creates a new, empty Database.
@@ -91,9 +96,12 @@ func (d *Database) GetBytes(key string) ([]byte, error) {
 
 // Put saves a value under a key.
 func (d *Database) Put(key string, value string) error {
+	d.txMutex.Lock()
+	defer d.txMutex.Unlock()
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
+	d.own()
 	d.data[key] = value
 	return nil
 }
@@ -105,16 +113,35 @@ func (d *Database) PutBytes(key string, value []byte) error {
 
 // Delete deletes a key from the database.
 func (d *Database) Delete(key string) error {
+	d.txMutex.Lock()
+	defer d.txMutex.Unlock()
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
 	if _, has := d.data[key]; !has {
 		return &sortedkv.ErrNotFound{Key: key}
 	}
+	d.own()
 	delete(d.data, key)
 	return nil
 }
 
+// own gives the snapshots that share the data a copy of it, so that the data
+// can be modified. The mutex must be held.
+func (d *Database) own() {
+	if d.snap == nil {
+		return
+	}
+	data := make(map[string]string, len(d.data))
+	for key, value := range d.data {
+		data[key] = value
+	}
+	d.snap.mutex.Lock()
+	d.snap.data = data
+	d.snap.mutex.Unlock()
+	d.snap = nil
+}
+
 // Batcher interface.
 
 // NewBatch creates a new batch.
@@ -124,70 +151,134 @@ func (d *Database) NewBatch() sortedkv.Batch {
 	return &batch
 }
 
-// Iterateable interface.
+// RangeDeleter interface.
 
-// NewIterator creates a new iterator.
-func (d *Database) NewIterator() sortedkv.Iterator {
-	d.mutex.RLock()
-	defer d.mutex.RUnlock()
+// DeleteRange deletes all keys in a range from the database.
+func (d *Database) DeleteRange(start string, end string) error {
+	return d.deleteMatching(inRange(start, end))
+}
 
-	keys := make([]string, 0, len(d.data))
-	for key := range d.data {
-		keys = append(keys, key)
-	}
+// DeletePrefix deletes all keys with a prefix from the database.
+func (d *Database) DeletePrefix(prefix string) error {
+	return d.deleteMatching(hasPrefix(prefix))
+}
 
-	sort.Strings(keys)
+func (d *Database) deleteMatching(match func(string) bool) error {
+	d.txMutex.Lock()
+	defer d.txMutex.Unlock()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 
-	return &Iterator{
-		keys:   keys,
-		values: d.readValues(keys),
+	d.own()
+	for key := range d.data {
+		if match(key) {
+			delete(d.data, key)
+		}
 	}
+	return nil
 }
 
-// NewIteratorWithRange creates a new iterator based on a given range.
-func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+// Sizer interface.
+
+// ApproximateSize returns the exact length of all keys and values in a range.
+func (d *Database) ApproximateSize(start string, end string) (int64, error) {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 
-	var keys []string
-	// No need to check for start == "", as all strings >= "".
-	if end == "" {
-		for key := range d.data {
-			if key >= start {
-				keys = append(keys, key)
-			}
-		}
-	} else {
-		for key := range d.data {
-			if key >= start && key < end {
-				keys = append(keys, key)
-			}
+	var size int64
+	match := inRange(start, end)
+	for key, value := range d.data {
+		if match(key) {
+			size += int64(len(key) + len(value))
 		}
 	}
+	return size, nil
+}
 
-	sort.Strings(keys)
-	return &Iterator{
-		keys:   keys,
-		values: d.readValues(keys),
-	}
+// Count returns the number of keys in a range.
+func (d *Database) Count(start string, end string) (int, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return len(d.matchingKeys(inRange(start, end))), nil
+}
+
+// Iterateable interface.
+
+// NewIterator creates a new iterator.
+func (d *Database) NewIterator() sortedkv.Iterator {
+	return d.newIterator(inRange("", ""), false)
+}
+
+// NewIteratorWithRange creates a new iterator based on a given range.
+func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return d.newIterator(inRange(start, end), false)
 }
 
 // NewIteratorWithPrefix creates a new iterator for a given prefix.
 func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.newIterator(hasPrefix(prefix), false)
+}
+
+// NewReverseIterator creates a new reverse iterator.
+func (d *Database) NewReverseIterator() sortedkv.Iterator {
+	return d.newIterator(inRange("", ""), true)
+}
+
+// NewReverseIteratorWithRange creates a new reverse iterator based on a given
+// range.
+func (d *Database) NewReverseIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return d.newIterator(inRange(start, end), true)
+}
+
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a given
+// prefix.
+func (d *Database) NewReverseIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return d.newIterator(hasPrefix(prefix), true)
+}
+
+// newIterator creates an iterator over a copy of the matching entries.
+func (d *Database) newIterator(match func(string) bool, reverse bool) sortedkv.Iterator {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 
+	keys := d.matchingKeys(match)
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	return &Iterator{
+		keys:    keys,
+		values:  d.readValues(keys),
+		reverse: reverse,
+	}
+}
+
+// matchingKeys returns the sorted keys that match. The database must be
+// readlocked already.
+func (d *Database) matchingKeys(match func(string) bool) []string {
 	var keys []string
 	for key := range d.data {
-		if strings.HasPrefix(key, prefix) {
+		if match(key) {
 			keys = append(keys, key)
 		}
 	}
 
 	sort.Strings(keys)
-	return &Iterator{
-		keys:   keys,
-		values: d.readValues(keys),
+	return keys
+}
+
+// inRange returns a matcher for the keys in [start, end). No need to check for
+// start == "", as all strings >= "".
+func inRange(start string, end string) func(string) bool {
+	return func(key string) bool {
+		return key >= start && (end == "" || key < end)
+	}
+}
+
+// hasPrefix returns a matcher for the keys with a prefix.
+func hasPrefix(prefix string) func(string) bool {
+	return func(key string) bool {
+		return strings.HasPrefix(key, prefix)
 	}
 }
 
@@ -14,13 +14,18 @@
 
 package memorydb
 
-import "perun.network/go-perun/log"
+import (
+	"sort"
+
+	"perun.network/go-perun/log"
+)
 
 // Iterator provides an iterator over a key range.
 type Iterator struct {
-	next   int
-	keys   []string
-	values []string
+	next    int
+	keys    []string
+	values  []string
+	reverse bool
 }
 
 // Next returns true if the iterator has a next element.
@@ -58,6 +63,18 @@ func (i *Iterator) ValueBytes() []byte {
 	return []byte(i.Value())
 }
 
+// Seek moves the iterator to the first element whose key is not before key in
+// iteration order.
+func (i *Iterator) Seek(key string) bool {
+	if i.reverse {
+		i.next = sort.Search(len(i.keys), func(j int) bool { return i.keys[j] <= key })
+	} else {
+		i.next = sort.SearchStrings(i.keys, key)
+	}
+	i.next++
+	return i.next <= len(i.keys)
+}
+
 // Close closes this iterator.
 func (i *Iterator) Close() error {
 	i.next = 0
@@ -0,0 +1,41 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorydb
+
+import "perun.network/go-perun/pkg/sortedkv"
+
+// Snapshot is a read-only view of the database content and implements the
+// sortedkv.Snapshot interface.
+type Snapshot struct {
+	sortedkv.Reader
+	sortedkv.Iterable
+}
+
+// Snapshot returns a snapshot of the current content of the database. The
+// content is shared with the database until the next write, which copies it
+// for the snapshots first. Taking snapshots without writing in between is
+// cheap.
+func (d *Database) Snapshot() (sortedkv.Snapshot, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.snap == nil {
+		d.snap = &Database{data: d.data}
+	}
+	return &Snapshot{d.snap, d.snap}, nil
+}
+
+// Release is a noop, the content is garbage collected.
+func (s *Snapshot) Release() {}
@@ -0,0 +1,59 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorydb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/test"
+)
+
+func TestSnapshot(t *testing.T) {
+	test.GenericSnapshotTest(t, NewDatabase())
+}
+
+func TestSnapshot_CopyOnWrite(t *testing.T) {
+	data := map[string]string{"a": "av"}
+	db := FromData(data).(*Database)
+
+	s1, err := db.Snapshot()
+	require.NoError(t, err)
+	s2, err := db.Snapshot()
+	require.NoError(t, err)
+	dataPtr := func(s sortedkv.Snapshot) uintptr {
+		return reflect.ValueOf(s.(*Snapshot).Reader.(*Database).data).Pointer()
+	}
+	assert.Equal(t, reflect.ValueOf(data).Pointer(), dataPtr(s1), "snapshots should not copy")
+	assert.Equal(t, dataPtr(s1), dataPtr(s2), "snapshots should share the data")
+
+	// A write copies the data for the snapshots and keeps the database's data.
+	require.NoError(t, db.Put("a", "AV"))
+	assert.NotEqual(t, reflect.ValueOf(data).Pointer(), dataPtr(s1), "write should copy")
+	assert.Equal(t, "AV", data["a"])
+	for _, s := range []sortedkv.Snapshot{s1, s2} {
+		v, err := s.Get("a")
+		require.NoError(t, err)
+		assert.Equal(t, "av", v)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	test.GenericTransactionTest(t, NewDatabase())
+}
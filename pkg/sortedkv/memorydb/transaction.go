@@ -0,0 +1,111 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorydb
+
+import (
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// Transaction implements the sortedkv.Transaction interface. Its writes are
+// collected in a batch that is applied on commit.
+type Transaction struct {
+	batch  Batch
+	closed bool
+}
+
+// NewTransaction opens a new transaction. It holds the database's write lock
+// until it is committed or discarded.
+func (d *Database) NewTransaction() (sortedkv.Transaction, error) {
+	d.txMutex.Lock()
+	tx := &Transaction{batch: Batch{db: d}}
+	tx.batch.Reset()
+	return tx, nil
+}
+
+// Has returns true if the key is present in the database or was written by the
+// transaction.
+func (tx *Transaction) Has(key string) (bool, error) {
+	_, err := tx.Get(key)
+	if _, notFound := err.(*sortedkv.ErrNotFound); notFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get returns the value of a key as seen by the transaction.
+func (tx *Transaction) Get(key string) (string, error) {
+	if tx.closed {
+		return "", errors.New("transaction closed")
+	}
+	if value, ok := tx.batch.writes[key]; ok {
+		return value, nil
+	}
+	if _, ok := tx.batch.deletes[key]; ok {
+		return "", &sortedkv.ErrNotFound{Key: key}
+	}
+	return tx.batch.db.Get(key)
+}
+
+// GetBytes returns the value of a key in bytes as seen by the transaction.
+func (tx *Transaction) GetBytes(key string) ([]byte, error) {
+	value, err := tx.Get(key)
+	return []byte(value), err
+}
+
+// Put saves a value under a key.
+func (tx *Transaction) Put(key string, value string) error {
+	if tx.closed {
+		return errors.New("transaction closed")
+	}
+	return tx.batch.Put(key, value)
+}
+
+// PutBytes saves a bytes value under a key.
+func (tx *Transaction) PutBytes(key string, value []byte) error {
+	return tx.Put(key, string(value))
+}
+
+// Delete deletes a key. It returns an error if the key is not present.
+func (tx *Transaction) Delete(key string) error {
+	if _, err := tx.Get(key); err != nil {
+		return err
+	}
+	return tx.batch.Delete(key)
+}
+
+// Commit applies the writes of the transaction to the database at once and
+// releases the write lock.
+func (tx *Transaction) Commit() error {
+	if tx.closed {
+		return errors.New("transaction closed")
+	}
+	tx.batch.apply()
+	tx.close()
+	return nil
+}
+
+// Discard releases the write lock without applying the writes.
+func (tx *Transaction) Discard() {
+	if !tx.closed {
+		tx.close()
+	}
+}
+
+func (tx *Transaction) close() {
+	tx.closed = true
+	tx.batch.db.txMutex.Unlock()
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sortedkv
+
+// Snapshot is a consistent, read-only view of a database at the time it was
+// taken. Writes to the database after that are not visible in the snapshot.
+type Snapshot interface {
+	Reader
+	Iterable
+
+	// Release releases the snapshot. The snapshot and iterators created from
+	// it must not be used afterwards. Release can be called multiple times.
+	Release()
+}
+
+// Snapshotter is implemented by databases that can take snapshots.
+type Snapshotter interface {
+	// Snapshot takes a snapshot of the current database content.
+	Snapshot() (Snapshot, error)
+}
+
+// snapshotTable is a wrapper around a snapshot with a key prefix.
+type snapshotTable struct {
+	Snapshot
+	prefix string
+}
+
+// NewSnapshotTable creates a view on a snapshot that prefixes all keys, like
+// NewTable does for databases. Release releases the wrapped snapshot.
+func NewSnapshotTable(s Snapshot, prefix string) Snapshot {
+	return &snapshotTable{
+		Snapshot: s,
+		prefix:   prefix,
+	}
+}
+
+func (t *snapshotTable) pkey(key string) string {
+	return t.prefix + key
+}
+
+// Has calls s.Has with the prefixed key.
+func (t *snapshotTable) Has(key string) (bool, error) {
+	return t.Snapshot.Has(t.pkey(key))
+}
+
+// Get calls s.Get with the prefixed key.
+func (t *snapshotTable) Get(key string) (string, error) {
+	return t.Snapshot.Get(t.pkey(key))
+}
+
+// GetBytes calls s.GetBytes with the prefixed key.
+func (t *snapshotTable) GetBytes(key string) ([]byte, error) {
+	return t.Snapshot.GetBytes(t.pkey(key))
+}
+
+// NewIterator creates a new table iterator.
+func (t *snapshotTable) NewIterator() Iterator {
+	return newTableIterator(t.Snapshot.NewIteratorWithPrefix(t.prefix), t.prefix)
+}
+
+// NewIteratorWithRange creates a new ranged iterator.
+func (t *snapshotTable) NewIteratorWithRange(start string, end string) Iterator {
+	start, end = prefixRange(t.prefix, start, end)
+	return newTableIterator(t.Snapshot.NewIteratorWithRange(start, end), t.prefix)
+}
+
+// NewIteratorWithPrefix creates a new iterator for a prefix.
+func (t *snapshotTable) NewIteratorWithPrefix(prefix string) Iterator {
+	return newTableIterator(t.Snapshot.NewIteratorWithPrefix(t.pkey(prefix)), t.prefix)
+}
+
+// NewReverseIterator creates a new reverse table iterator.
+func (t *snapshotTable) NewReverseIterator() Iterator {
+	return newTableIterator(t.Snapshot.NewReverseIteratorWithPrefix(t.prefix), t.prefix)
+}
+
+// NewReverseIteratorWithRange creates a new reverse ranged iterator.
+func (t *snapshotTable) NewReverseIteratorWithRange(start string, end string) Iterator {
+	start, end = prefixRange(t.prefix, start, end)
+	return newTableIterator(t.Snapshot.NewReverseIteratorWithRange(start, end), t.prefix)
+}
+
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a prefix.
+func (t *snapshotTable) NewReverseIteratorWithPrefix(prefix string) Iterator {
+	return newTableIterator(t.Snapshot.NewReverseIteratorWithPrefix(t.pkey(prefix)), t.prefix)
+}
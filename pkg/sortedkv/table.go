@@ -71,24 +71,68 @@ func (t *table) NewBatch() Batch {
 	return &tableBatch{t.Database.NewBatch(), t.prefix}
 }
 
+// DeleteRange calls db.DeleteRange with the prefixed range.
+func (t *table) DeleteRange(start string, end string) error {
+	start, end = prefixRange(t.prefix, start, end)
+	return t.Database.DeleteRange(start, end)
+}
+
+// DeletePrefix calls db.DeletePrefix with the prefixed prefix.
+func (t *table) DeletePrefix(prefix string) error {
+	return t.Database.DeletePrefix(t.pkey(prefix))
+}
+
+// ApproximateSize calls db.ApproximateSize with the prefixed range.
+func (t *table) ApproximateSize(start string, end string) (int64, error) {
+	start, end = prefixRange(t.prefix, start, end)
+	return t.Database.ApproximateSize(start, end)
+}
+
+// Count calls db.Count with the prefixed range.
+func (t *table) Count(start string, end string) (int, error) {
+	start, end = prefixRange(t.prefix, start, end)
+	return t.Database.Count(start, end)
+}
+
 // NewIterator creates a new table iterator.
 func (t *table) NewIterator() Iterator {
-	return newTableIterator(t.Database.NewIteratorWithPrefix(t.prefix), t)
+	return newTableIterator(t.Database.NewIteratorWithPrefix(t.prefix), t.prefix)
 }
 
 // NewIteratorWithRange creates a new ranged iterator.
 func (t *table) NewIteratorWithRange(start string, end string) Iterator {
-	start = t.pkey(start)
-	if end == "" {
-		end = key.IncPrefix(t.prefix)
-	} else {
-		end = t.pkey(end)
-	}
-
-	return newTableIterator(t.Database.NewIteratorWithRange(start, end), t)
+	start, end = prefixRange(t.prefix, start, end)
+	return newTableIterator(t.Database.NewIteratorWithRange(start, end), t.prefix)
 }
 
 // NewIteratorWithPrefix creates a new iterator for a prefix.
 func (t *table) NewIteratorWithPrefix(prefix string) Iterator {
-	return newTableIterator(t.Database.NewIteratorWithPrefix(t.pkey(prefix)), t)
+	return newTableIterator(t.Database.NewIteratorWithPrefix(t.pkey(prefix)), t.prefix)
+}
+
+// NewReverseIterator creates a new reverse table iterator.
+func (t *table) NewReverseIterator() Iterator {
+	return newTableIterator(t.Database.NewReverseIteratorWithPrefix(t.prefix), t.prefix)
+}
+
+// NewReverseIteratorWithRange creates a new reverse ranged iterator.
+func (t *table) NewReverseIteratorWithRange(start string, end string) Iterator {
+	start, end = prefixRange(t.prefix, start, end)
+	return newTableIterator(t.Database.NewReverseIteratorWithRange(start, end), t.prefix)
+}
+
+// NewReverseIteratorWithPrefix creates a new reverse iterator for a prefix.
+func (t *table) NewReverseIteratorWithPrefix(prefix string) Iterator {
+	return newTableIterator(t.Database.NewReverseIteratorWithPrefix(t.pkey(prefix)), t.prefix)
+}
+
+// prefixRange prefixes the range [start, end) of a table. An empty end is
+// replaced by the end of the table.
+func prefixRange(prefix, start, end string) (string, string) {
+	if end == "" {
+		end = key.IncPrefix(prefix)
+	} else {
+		end = prefix + end
+	}
+	return prefix + start, end
 }
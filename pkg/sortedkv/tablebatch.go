@@ -21,6 +21,13 @@ type tableBatch struct {
 	prefix string
 }
 
+// NewTableBatch creates a view on an existing batch that prefixes all keys.
+// Apply and Reset act on the wrapped batch, so several table batches on the
+// same batch are applied together.
+func NewTableBatch(b Batch, prefix string) Batch {
+	return &tableBatch{Batch: b, prefix: prefix}
+}
+
 func (b *tableBatch) pkey(key string) string {
 	return b.prefix + key
 }
@@ -39,3 +46,14 @@ func (b *tableBatch) PutBytes(key string, value []byte) error {
 func (b *tableBatch) Delete(key string) error {
 	return b.Batch.Delete(b.pkey(key))
 }
+
+// DeleteRange deletes a prefixed range in a table batch.
+func (b *tableBatch) DeleteRange(start string, end string) error {
+	start, end = prefixRange(b.prefix, start, end)
+	return b.Batch.DeleteRange(start, end)
+}
+
+// DeletePrefix deletes all keys with a prefixed prefix in a table batch.
+func (b *tableBatch) DeletePrefix(prefix string) error {
+	return b.Batch.DeletePrefix(b.pkey(prefix))
+}
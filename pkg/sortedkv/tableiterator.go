@@ -17,18 +17,23 @@ package sortedkv
 // tableIterator is a wrapper around the Iterator interface.
 type tableIterator struct {
 	Iterator
-	prefix int
+	prefix string
 }
 
 // newTableIterator creates a new table iterator.
-func newTableIterator(it Iterator, table *table) Iterator {
+func newTableIterator(it Iterator, prefix string) Iterator {
 	return &tableIterator{
 		Iterator: it,
-		prefix:   len(table.prefix),
+		prefix:   prefix,
 	}
 }
 
 // Key returns the value that is iterated over, but without the table's prefix.
 func (it *tableIterator) Key() string {
-	return it.Iterator.Key()[it.prefix:]
+	return it.Iterator.Key()[len(it.prefix):]
+}
+
+// Seek calls Seek on the wrapped iterator with the prefixed key.
+func (it *tableIterator) Seek(key string) bool {
+	return it.Iterator.Seek(it.prefix + key)
 }
@@ -49,6 +49,28 @@ func GenericBatchTest(t *testing.T, database sortedkv.Database) {
 
 	dbtest.MustNotHave("1234")
 	dbtest.MustGetEqual("5678", "ghjk")
+
+	// Range deletions delete keys of the database at the time of Apply and
+	// keys that were put into the batch before, but not keys that are put
+	// afterwards.
+	dbtest.Put("r1", "r1 initial value")
+	dbtest.Put("r3", "r3 initial value")
+	dbtest.Put("s1", "s1 initial value")
+	this.Batch.Reset()
+	this.MustPut("r2", "r2 value")
+	this.MustDeleteRange("r1", "r3")
+	this.MustPut("r1", "r1 value")
+	this.MustDeletePrefix("s")
+	dbtest.Put("s2", "s2 initial value")
+	this.MustApply()
+
+	dbtest.MustGetEqual("r1", "r1 value")
+	dbtest.MustNotHave("r2")
+	dbtest.MustGetEqual("r3", "r3 initial value")
+	dbtest.MustNotHave("s1")
+	dbtest.MustNotHave("s2")
+	dbtest.Delete("r1")
+	dbtest.Delete("r3")
 }
 
 // BatchTest tests a batch.
@@ -78,6 +100,20 @@ func (bt *BatchTest) MustDelete(key string) {
 	}
 }
 
+// MustDeleteRange tests the deleteRange functionality.
+func (bt *BatchTest) MustDeleteRange(start, end string) {
+	if err := bt.Batch.DeleteRange(start, end); err != nil {
+		bt.Fatalf("DeleteRange(): Failed to delete [%q, %q): %v.\n", start, end, err)
+	}
+}
+
+// MustDeletePrefix tests the deletePrefix functionality.
+func (bt *BatchTest) MustDeletePrefix(prefix string) {
+	if err := bt.Batch.DeletePrefix(prefix); err != nil {
+		bt.Fatalf("DeletePrefix(): Failed to delete [%q]: %v.\n", prefix, err)
+	}
+}
+
 // MustApply tests the apply functionality.
 func (bt *BatchTest) MustApply() {
 	if err := bt.Batch.Apply(); err != nil {
@@ -0,0 +1,95 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// benchValue is the value written by the benchmarks, about the size of an
+// encoded channel state.
+var benchValue = make([]byte, 256)
+
+// GenericDatabaseBenchmark benchmarks the common operations of a database
+// implementation, so that backends can be compared with each other. The
+// database should be empty.
+func GenericDatabaseBenchmark(b *testing.B, database sortedkv.Database) {
+	b.Run("Put", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if err := database.PutBytes(benchKey("put", n), benchValue); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Get", func(b *testing.B) {
+		const entries = 1000
+		benchFill(b, database, "get", entries)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			if _, err := database.GetBytes(benchKey("get", n%entries)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Batch100", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			batch := database.NewBatch()
+			for i := 0; i < 100; i++ {
+				if err := batch.PutBytes(benchKey("batch", n*100+i), benchValue); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := batch.Apply(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Iterate1000", func(b *testing.B) {
+		benchFill(b, database, "iter", 1000)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			it := database.NewIteratorWithPrefix("iter")
+			for it.Next() {
+				_ = it.ValueBytes()
+			}
+			if err := it.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchFill writes num entries with the given key prefix in one batch.
+func benchFill(b *testing.B, database sortedkv.Database, prefix string, num int) {
+	batch := database.NewBatch()
+	for i := 0; i < num; i++ {
+		if err := batch.PutBytes(benchKey(prefix, i), benchValue); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := batch.Apply(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func benchKey(prefix string, i int) string {
+	return fmt.Sprintf("%s:%010d", prefix, i)
+}
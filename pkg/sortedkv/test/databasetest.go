@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/key"
 )
 
 // GenericDatabaseTest provides generic sortedkv tests.
@@ -84,6 +85,56 @@ func (d *DatabaseTest) test() {
 	d.Put("asdf", "YXCV")
 	d.MustGetEqual("asdf", "YXCV")
 	d.Delete("asdf")
+
+	d.testRanges()
+}
+
+// testRanges tests range deletions and sizes in the "Range." namespace of the
+// database. Unbounded ranges are tested on a table of the namespace.
+func (d *DatabaseTest) testRanges() {
+	const p = "Range."
+	end := key.IncPrefix(p)
+	// Entries just outside of the namespace must not be touched.
+	d.Put("Range", "before")
+	d.Put(end, "after")
+	for _, k := range []string{"a", "b1", "b2", "c", "d"} {
+		d.Put(p+k, k+"v")
+	}
+	table := DatabaseTest{T: d.T, Database: sortedkv.NewTable(d.Database, p)}
+
+	d.MustCount(p, end, 5)
+	d.MustCount(p+"b", p+"c", 2)
+	d.MustCount(p+"x", end, 0)
+	table.MustCount("", "", 5)
+	table.MustCount("c", "", 2)
+	if size := d.ApproximateSize(p, end); size < 0 {
+		d.Errorf("ApproximateSize(): negative size %d\n", size)
+	}
+	if size := d.ApproximateSize(p+"x", p+"y"); size != 0 {
+		d.Errorf("ApproximateSize(): empty range has size %d\n", size)
+	}
+
+	table.DeleteRange("b", "c")
+	d.MustNotHave(p + "b1")
+	d.MustNotHave(p + "b2")
+	d.MustHave(p + "a")
+	d.MustHave(p + "c")
+	table.DeleteRange("x", "y")
+	table.DeleteRange("d", "")
+	d.MustNotHave(p + "d")
+	d.MustHave(p + "c")
+
+	d.Put(p+"b3", "b3v")
+	d.DeletePrefix(p + "b")
+	d.MustNotHave(p + "b3")
+	d.MustHave(p + "a")
+	table.DeletePrefix("")
+	d.MustCount(p, end, 0)
+
+	d.MustGetEqual("Range", "before")
+	d.MustGetEqual(end, "after")
+	d.Delete("Range")
+	d.Delete(end)
 }
 
 // DatabaseTest is a sortedkv testing struct.
@@ -185,6 +236,40 @@ func (d *DatabaseTest) Delete(key string) {
 	}
 }
 
+// DeleteRange tests the deleteRange functionality.
+func (d *DatabaseTest) DeleteRange(start, end string) {
+	if err := d.Database.DeleteRange(start, end); err != nil {
+		d.Errorf("DeleteRange() [%q, %q) failed: %v", start, end, err)
+	}
+}
+
+// DeletePrefix tests the deletePrefix functionality.
+func (d *DatabaseTest) DeletePrefix(prefix string) {
+	if err := d.Database.DeletePrefix(prefix); err != nil {
+		d.Errorf("DeletePrefix() [%q] failed: %v", prefix, err)
+	}
+}
+
+// ApproximateSize tests the approximateSize functionality.
+func (d *DatabaseTest) ApproximateSize(start, end string) int64 {
+	size, err := d.Database.ApproximateSize(start, end)
+	if err != nil {
+		d.Fatalf("ApproximateSize() [%q, %q) failed: %v", start, end, err)
+	}
+	return size
+}
+
+// MustCount tests the count functionality.
+func (d *DatabaseTest) MustCount(start, end string, expected int) {
+	n, err := d.Database.Count(start, end)
+	if err != nil {
+		d.Fatalf("Count() [%q, %q) failed: %v", start, end, err)
+	}
+	if n != expected {
+		d.Errorf("Count() [%q, %q) returned %d, expected %d\n", start, end, n, expected)
+	}
+}
+
 // MustFailDelete tests the delete functionality.
 func (d *DatabaseTest) MustFailDelete(key string) {
 	if err := d.Database.Delete(key); err == nil {
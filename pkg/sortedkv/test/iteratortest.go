@@ -73,6 +73,67 @@ func GenericIteratorTest(t *testing.T, database sortedkv.Database) {
 	it.NextMustEqual("2b", "2bv")
 	it.MustEnd()
 
+	// Test reverse iteration.
+	it.Iterator = database.NewReverseIterator()
+	it.NextMustEqual("3", "3v")
+	it.NextMustEqual("2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.NextMustEqual("1", "1v")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIteratorWithRange("", "")
+	it.NextMustEqual("3", "3v")
+	it.NextMustEqual("2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.NextMustEqual("1", "1v")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIteratorWithRange("2", "3")
+	it.NextMustEqual("2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIteratorWithRange("2a", "")
+	it.NextMustEqual("3", "3v")
+	it.NextMustEqual("2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIteratorWithPrefix("2")
+	it.NextMustEqual("2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.MustEnd()
+
+	// Test seeking.
+	it.Iterator = database.NewIterator()
+	it.SeekMustEqual("2", "2a", "2av")
+	it.NextMustEqual("2b", "2bv")
+	it.SeekMustEqual("1", "1", "1v")
+	it.NextMustEqual("2a", "2av")
+	it.MustNotSeek("4")
+	it.MustEnd()
+
+	it.Iterator = database.NewIteratorWithRange("2", "3")
+	it.SeekMustEqual("1", "2a", "2av")
+	it.SeekMustEqual("2b", "2b", "2bv")
+	it.MustNotSeek("3")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIterator()
+	it.SeekMustEqual("2c", "2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.SeekMustEqual("9", "3", "3v")
+	it.SeekMustEqual("2a", "2a", "2av")
+	it.NextMustEqual("1", "1v")
+	it.MustNotSeek("0")
+	it.MustEnd()
+
+	it.Iterator = database.NewReverseIteratorWithRange("2", "3")
+	it.SeekMustEqual("9", "2b", "2bv")
+	it.NextMustEqual("2a", "2av")
+	it.MustNotSeek("1")
+	it.MustEnd()
+
 	// Test whether closing really ends the iterator.
 	it.Iterator = database.NewIterator()
 	it.NextMustEqual("1", "1v")
@@ -98,6 +159,32 @@ func (i *IteratorTest) NextMustEqual(key, value string) {
 	}
 }
 
+// SeekMustEqual tests the seek method.
+func (i *IteratorTest) SeekMustEqual(seek, key, value string) {
+	if !i.Iterator.Seek(seek) {
+		i.Errorf("Seek(%q): Expected [%q] = %q, but iterator ended.\n", seek, key, value)
+		return
+	}
+
+	if actual := i.Iterator.Value(); actual != value {
+		i.Errorf("Value(): Expected %q, but got %q.\n", value, actual)
+	}
+	if actual := i.Iterator.Key(); actual != key {
+		i.Errorf("Key(): Expected %q, but got %q.\n", key, actual)
+	}
+}
+
+// MustNotSeek tests the seek method.
+func (i *IteratorTest) MustNotSeek(seek string) {
+	if i.Iterator.Seek(seek) {
+		i.Errorf(
+			"Seek(%q): Expected end, but got [%q] = %q.\n",
+			seek,
+			i.Iterator.Key(),
+			i.Iterator.Value())
+	}
+}
+
 // MustEnd tests the next method.
 func (i *IteratorTest) MustEnd() {
 	if i.Iterator.Next() {
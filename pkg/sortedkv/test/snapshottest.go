@@ -0,0 +1,176 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// GenericSnapshotTest tests the snapshots of a database that implements
+// sortedkv.Snapshotter. The database should be empty.
+func GenericSnapshotTest(t *testing.T, database sortedkv.Database) {
+	snapshotter, ok := database.(sortedkv.Snapshotter)
+	require.True(t, ok, "database is not a Snapshotter")
+
+	dbtest := DatabaseTest{T: t, Database: database}
+	dbtest.Put("a", "av")
+	dbtest.Put("b", "bv")
+
+	snap, err := snapshotter.Snapshot()
+	require.NoError(t, err)
+	defer snap.Release()
+
+	// Writes after the snapshot must not be visible in it.
+	dbtest.Put("a", "AV")
+	dbtest.Delete("b")
+	dbtest.Put("c", "cv")
+
+	snaptest := DatabaseTest{T: t, Database: &snapshotDB{snap}}
+	snaptest.MustGetEqual("a", "av")
+	snaptest.MustGetBytesEqual("b", []byte("bv"))
+	snaptest.MustNotHave("c")
+	snaptest.MustFailGet("c")
+
+	it := IteratorTest{T: t, Iterator: snap.NewIterator()}
+	it.NextMustEqual("a", "av")
+	it.NextMustEqual("b", "bv")
+	it.MustEnd()
+
+	it.Iterator = sortedkv.NewSnapshotTable(snap, "a").NewIterator()
+	it.NextMustEqual("", "av")
+	it.MustEnd()
+
+	dbtest.MustGetEqual("a", "AV")
+	dbtest.MustNotHave("b")
+	dbtest.Delete("a")
+	dbtest.Delete("c")
+}
+
+// GenericTransactionTest tests the transactions of a database that implements
+// sortedkv.Transactor. The database should be empty.
+func GenericTransactionTest(t *testing.T, database sortedkv.Database) {
+	transactor, ok := database.(sortedkv.Transactor)
+	require.True(t, ok, "database is not a Transactor")
+
+	dbtest := DatabaseTest{T: t, Database: database}
+	dbtest.Put("k", "1")
+	dbtest.Put("d", "dv")
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := transactor.NewTransaction()
+		require.NoError(t, err)
+		defer tx.Discard()
+
+		v, err := tx.Get("k")
+		require.NoError(t, err)
+		require.NoError(t, tx.Put("k", v+"2"))
+		require.NoError(t, tx.PutBytes("n", []byte("nv")))
+		require.NoError(t, tx.Delete("d"))
+		assert.IsType(t, &sortedkv.ErrNotFound{}, tx.Delete("missing"))
+
+		// The transaction sees its own writes, other readers do not.
+		txtest := DatabaseTest{T: t, Database: &transactionDB{tx}}
+		txtest.MustGetEqual("k", "12")
+		txtest.MustGetBytesEqual("n", []byte("nv"))
+		txtest.MustNotHave("d")
+		dbtest.MustGetEqual("k", "1")
+		dbtest.MustNotHave("n")
+		dbtest.MustHave("d")
+
+		require.NoError(t, tx.Commit())
+		dbtest.MustGetEqual("k", "12")
+		dbtest.MustGetEqual("n", "nv")
+		dbtest.MustNotHave("d")
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		tx, err := transactor.NewTransaction()
+		require.NoError(t, err)
+		require.NoError(t, tx.Put("k", "discarded"))
+		tx.Discard()
+		tx.Discard()
+		dbtest.MustGetEqual("k", "12")
+	})
+
+	t.Run("blocks writes", func(t *testing.T) {
+		tx, err := transactor.NewTransaction()
+		require.NoError(t, err)
+		require.NoError(t, tx.Put("w", "tx"))
+
+		written := make(chan error)
+		go func() { written <- database.Put("w", "outside") }()
+		select {
+		case <-written:
+			t.Error("write did not block during transaction")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, tx.Commit())
+		require.NoError(t, <-written)
+		dbtest.MustGetEqual("w", "outside")
+	})
+
+	dbtest.Delete("k")
+	dbtest.Delete("n")
+	dbtest.Delete("w")
+}
+
+// snapshotDB adapts a snapshot to the DatabaseTest helpers, which only read.
+type snapshotDB struct {
+	sortedkv.Snapshot
+}
+
+func (*snapshotDB) Put(string, string) error                      { panic("read-only") }
+func (*snapshotDB) PutBytes(string, []byte) error                 { panic("read-only") }
+func (*snapshotDB) Delete(string) error                           { panic("read-only") }
+func (*snapshotDB) DeleteRange(string, string) error              { panic("read-only") }
+func (*snapshotDB) DeletePrefix(string) error                     { panic("read-only") }
+func (*snapshotDB) ApproximateSize(string, string) (int64, error) { panic("not supported") }
+func (*snapshotDB) Count(string, string) (int, error)             { panic("not supported") }
+func (*snapshotDB) NewBatch() sortedkv.Batch                      { panic("read-only") }
+func (*snapshotDB) Close() error                                  { return nil }
+
+// transactionDB adapts a transaction to the DatabaseTest helpers, which only
+// use its Reader and Writer methods.
+type transactionDB struct {
+	sortedkv.Transaction
+}
+
+func (*transactionDB) DeleteRange(string, string) error { panic("not supported") }
+func (*transactionDB) DeletePrefix(string) error        { panic("not supported") }
+func (*transactionDB) ApproximateSize(string, string) (int64, error) {
+	panic("not supported")
+}
+func (*transactionDB) Count(string, string) (int, error) { panic("not supported") }
+func (*transactionDB) NewBatch() sortedkv.Batch          { panic("not supported") }
+func (*transactionDB) NewIterator() sortedkv.Iterator    { panic("not supported") }
+func (*transactionDB) NewIteratorWithRange(string, string) sortedkv.Iterator {
+	panic("not supported")
+}
+func (*transactionDB) NewIteratorWithPrefix(string) sortedkv.Iterator { panic("not supported") }
+func (*transactionDB) NewReverseIterator() sortedkv.Iterator          { panic("not supported") }
+func (*transactionDB) NewReverseIteratorWithRange(string, string) sortedkv.Iterator {
+	panic("not supported")
+}
+func (*transactionDB) NewReverseIteratorWithPrefix(string) sortedkv.Iterator {
+	panic("not supported")
+}
+func (*transactionDB) Close() error { return nil }
@@ -79,4 +79,25 @@ func GenericTableTest(t *testing.T, database sortedkv.Database) {
 		it.NextMustEqual("Inner.KeyA", "Table.Inner.ValueA")
 		it.NextMustEqual("Inner.KeyB", "Table.Inner.ValueB")
 	})
+
+	t.Run(`Reverse`, func(t *testing.T) {
+		it := IteratorTest{T: t, Iterator: table.Database.NewReverseIterator()}
+		it.NextMustEqual("KeyC", "Table.ValueC")
+		it.SeekMustEqual("KeyAA", "KeyA", "Table.ValueA")
+		it.NextMustEqual("Inner.KeyB", "Table.Inner.ValueB")
+		it.NextMustEqual("Inner.KeyA", "Table.Inner.ValueA")
+		it.MustEnd()
+
+		it.Iterator = table.Database.NewReverseIteratorWithPrefix("Inner.")
+		it.NextMustEqual("Inner.KeyB", "Table.Inner.ValueB")
+		it.NextMustEqual("Inner.KeyA", "Table.Inner.ValueA")
+		it.MustEnd()
+	})
+
+	t.Run(`Seek`, func(t *testing.T) {
+		it := IteratorTest{T: t, Iterator: table.Database.NewIterator()}
+		it.SeekMustEqual("KeyB", "KeyB", "Table.ValueB")
+		it.NextMustEqual("KeyC", "Table.ValueC")
+		it.MustEnd()
+	})
 }
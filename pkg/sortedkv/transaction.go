@@ -0,0 +1,39 @@
+// Copyright 2020 - See NOTICE file for copyright holders.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sortedkv
+
+// Transaction is a read-modify-write transaction on a database. Reads within
+// the transaction see its own writes, which only become visible to other
+// readers once the transaction is committed. While a transaction is open, other
+// transactions and writes to the database block.
+type Transaction interface {
+	Reader
+	Writer
+
+	// Commit atomically applies all writes of the transaction to the database
+	// and closes the transaction.
+	Commit() error
+
+	// Discard closes the transaction without applying its writes. It is a
+	// noop if the transaction is already closed, so it can be deferred.
+	Discard()
+}
+
+// Transactor is implemented by databases that support transactions.
+type Transactor interface {
+	// NewTransaction opens a new transaction. It blocks until any other open
+	// transaction is closed.
+	NewTransaction() (Transaction, error)
+}
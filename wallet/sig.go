@@ -81,7 +81,13 @@ func EncodeSparseSigs(w io.Writer, sigs []Sig) error {
 }
 
 // DecodeSparseSigs decodes a collection of signatures in the form (mask, sig, sig, sig, ...).
-func DecodeSparseSigs(r io.Reader, sigs *[]Sig) (err error) {
+func DecodeSparseSigs(r io.Reader, sigs *[]Sig) error {
+	return DecodeSparseSigsWith(r, sigs, DecodeSig)
+}
+
+// DecodeSparseSigsWith decodes a collection of signatures in the form (mask,
+// sig, sig, sig, ...), using decodeSig to decode the single signatures.
+func DecodeSparseSigsWith(r io.Reader, sigs *[]Sig, decodeSig func(io.Reader) (Sig, error)) (err error) {
 	masklen := int(math.Ceil(float64(len(*sigs)) / 8.0))
 	mask := make([]uint8, masklen)
 
@@ -96,7 +102,7 @@ func DecodeSparseSigs(r io.Reader, sigs *[]Sig) (err error) {
 			if ((mask[maskIdx] >> bitIdx) % 2) == 0 {
 				(*sigs)[sigIdx] = nil
 			} else {
-				(*sigs)[sigIdx], err = DecodeSig(r)
+				(*sigs)[sigIdx], err = decodeSig(r)
 				if err != nil {
 					return errors.WithMessagef(err, "decoding signature %d", sigIdx)
 				}
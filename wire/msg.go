@@ -42,6 +42,14 @@ type (
 		// Msg contained in this Envelope. Not embedded so Envelope doesn't implement Msg.
 		Msg Msg
 	}
+
+	// Backends decode the backend-specific data of messages. Envelopes decode
+	// their addresses with them. They are passed on to the decoders registered
+	// with RegisterBackendDecoder, which may expect a specific implementation,
+	// like channel.Backends. A nil Backends stands for the global backends.
+	Backends interface {
+		DecodeAddress(io.Reader) (Address, error)
+	}
 )
 
 // Encode encodes an Envelope into an io.Writer.
@@ -52,15 +60,25 @@ func (env *Envelope) Encode(w io.Writer) error {
 	return Encode(env.Msg, w)
 }
 
-// Decode decodes an Envelope from an io.Reader.
-func (env *Envelope) Decode(r io.Reader) (err error) {
-	if env.Sender, err = DecodeAddress(r); err != nil {
+// Decode decodes an Envelope from an io.Reader using the global backends.
+func (env *Envelope) Decode(r io.Reader) error {
+	return env.DecodeWith(r, nil)
+}
+
+// DecodeWith decodes an Envelope from an io.Reader, decoding the addresses and
+// the message with the given backends.
+func (env *Envelope) DecodeWith(r io.Reader, b Backends) (err error) {
+	decodeAddress := DecodeAddress
+	if b != nil {
+		decodeAddress = b.DecodeAddress
+	}
+	if env.Sender, err = decodeAddress(r); err != nil {
 		return err
 	}
-	if env.Recipient, err = DecodeAddress(r); err != nil {
+	if env.Recipient, err = decodeAddress(r); err != nil {
 		return err
 	}
-	env.Msg, err = Decode(r)
+	env.Msg, err = DecodeWith(r, b)
 	return err
 }
 
@@ -72,8 +90,14 @@ func Encode(msg Msg, w io.Writer) error {
 	return perunio.Encode(w, byte(msg.Type()), msg)
 }
 
-// Decode decodes a message from an io.Reader.
+// Decode decodes a message from an io.Reader using the global backends.
 func Decode(r io.Reader) (Msg, error) {
+	return DecodeWith(r, nil)
+}
+
+// DecodeWith decodes a message from an io.Reader. Messages whose decoder was
+// registered with RegisterBackendDecoder are decoded with the given backends.
+func DecodeWith(r io.Reader, b Backends) (Msg, error) {
 	var t Type
 	if err := perunio.Decode(r, (*byte)(&t)); err != nil {
 		return nil, errors.WithMessage(err, "failed to decode message Type")
@@ -82,13 +106,22 @@ func Decode(r io.Reader) (Msg, error) {
 	if !t.Valid() {
 		return nil, errors.Errorf("wire: no decoder known for message Type): %v", t)
 	}
-	return decoders[t](r)
+	return decoders[t](r, b)
 }
 
-var decoders = make(map[Type]func(io.Reader) (Msg, error))
+var decoders = make(map[Type]func(io.Reader, Backends) (Msg, error))
 
 // RegisterDecoder sets the decoder of messages of Type `t`.
 func RegisterDecoder(t Type, decoder func(io.Reader) (Msg, error)) {
+	RegisterBackendDecoder(t, func(r io.Reader, _ Backends) (Msg, error) {
+		return decoder(r)
+	})
+}
+
+// RegisterBackendDecoder sets the decoder of messages of Type `t` that contain
+// backend-specific data, like addresses, assets or channel states. The decoder
+// is passed the backends that the message is decoded with, see DecodeWith.
+func RegisterBackendDecoder(t Type, decoder func(io.Reader, Backends) (Msg, error)) {
 	if decoders[t] != nil {
 		panic(fmt.Sprintf("wire: decoder for Type %v already set", t))
 	}
@@ -128,21 +161,27 @@ const (
 	ChannelUpdateAcc
 	ChannelUpdateRej
 	ChannelSync
+	ChannelClose
+	RoutingAnnouncement
+	RoutingForward
 	LastType // upper bound on the message types of the Perun wire protocol
 )
 
 var typeNames = map[Type]string{
-	Ping:               "Ping",
-	Pong:               "Pong",
-	Shutdown:           "Shutdown",
-	AuthResponse:       "AuthResponse",
-	ChannelProposal:    "ChannelProposal",
-	ChannelProposalAcc: "ChannelProposalAcc",
-	ChannelProposalRej: "ChannelProposalRej",
-	ChannelUpdate:      "ChannelUpdate",
-	ChannelUpdateAcc:   "ChannelUpdateAcc",
-	ChannelUpdateRej:   "ChannelUpdateRej",
-	ChannelSync:        "ChannelSync",
+	Ping:                "Ping",
+	Pong:                "Pong",
+	Shutdown:            "Shutdown",
+	AuthResponse:        "AuthResponse",
+	ChannelProposal:     "ChannelProposal",
+	ChannelProposalAcc:  "ChannelProposalAcc",
+	ChannelProposalRej:  "ChannelProposalRej",
+	ChannelUpdate:       "ChannelUpdate",
+	ChannelUpdateAcc:    "ChannelUpdateAcc",
+	ChannelUpdateRej:    "ChannelUpdateRej",
+	ChannelSync:         "ChannelSync",
+	ChannelClose:        "ChannelClose",
+	RoutingAnnouncement: "RoutingAnnouncement",
+	RoutingForward:      "RoutingForward",
 }
 
 // String returns the name of a message type if it is valid and name known
@@ -27,14 +27,23 @@ var _ Conn = (*ioConn)(nil)
 
 // ioConn is a connection that communicates its messages over an io stream.
 type ioConn struct {
-	closed atomic.Bool
-	conn   io.ReadWriteCloser
+	closed   atomic.Bool
+	conn     io.ReadWriteCloser
+	backends wire.Backends
 }
 
-// NewIoConn creates a peer message connection from an io stream.
+// NewIoConn creates a peer message connection from an io stream. Received
+// messages are decoded with the global backends.
 func NewIoConn(conn io.ReadWriteCloser) Conn {
+	return NewIoConnWithBackends(conn, nil)
+}
+
+// NewIoConnWithBackends creates a peer message connection from an io stream
+// that decodes received messages with the given backends.
+func NewIoConnWithBackends(conn io.ReadWriteCloser, b wire.Backends) Conn {
 	return &ioConn{
-		conn: conn,
+		conn:     conn,
+		backends: b,
 	}
 }
 
@@ -49,7 +58,7 @@ func (c *ioConn) Send(e *wire.Envelope) error {
 
 func (c *ioConn) Recv() (*wire.Envelope, error) {
 	var e wire.Envelope
-	if err := e.Decode(c.conn); err != nil {
+	if err := e.DecodeWith(c.conn, c.backends); err != nil {
 		// nolint:errcheck,gosec
 		c.conn.Close()
 		return nil, err
@@ -30,10 +30,11 @@ import (
 // Dialer is a simple lookup-table based dialer that can dial known peers.
 // New peer addresses can be added via Register().
 type Dialer struct {
-	mutex   sync.RWMutex              // Protects peers.
-	peers   map[wallet.AddrKey]string // Known peer addresses.
-	dialer  net.Dialer                // Used to dial connections.
-	network string                    // The socket type.
+	mutex    sync.RWMutex              // Protects peers.
+	peers    map[wallet.AddrKey]string // Known peer addresses.
+	dialer   net.Dialer                // Used to dial connections.
+	network  string                    // The socket type.
+	backends wire.Backends             // Used to decode messages.
 
 	pkgsync.Closer
 }
@@ -97,7 +98,14 @@ func (d *Dialer) Dial(ctx context.Context, addr wire.Address) (wirenet.Conn, err
 		return nil, errors.Wrap(err, "failed to dial peer")
 	}
 
-	return wirenet.NewIoConn(conn), nil
+	return wirenet.NewIoConnWithBackends(conn, d.backends), nil
+}
+
+// SetBackends sets the backends that the dialed connections decode received
+// messages with, instead of the global backends. It must be called before the
+// first Dial.
+func (d *Dialer) SetBackends(b wire.Backends) {
+	d.backends = b
 }
 
 // Register registers a network address for a peer address.
@@ -18,12 +18,14 @@ import (
 	"net"
 
 	"github.com/pkg/errors"
+	"perun.network/go-perun/wire"
 	wirenet "perun.network/go-perun/wire/net"
 )
 
 // Listener is a TCP Listener.
 type Listener struct {
 	net.Listener
+	backends wire.Backends // Used to decode messages.
 }
 
 var _ wirenet.Listener = (*Listener)(nil)
@@ -56,5 +58,12 @@ func (l *Listener) Accept() (wirenet.Conn, error) {
 		return nil, errors.Wrap(err, "accept failed")
 	}
 
-	return wirenet.NewIoConn(conn), nil
+	return wirenet.NewIoConnWithBackends(conn, l.backends), nil
+}
+
+// SetBackends sets the backends that the accepted connections decode received
+// messages with, instead of the global backends. It must be called before the
+// first Accept.
+func (l *Listener) SetBackends(b wire.Backends) {
+	l.backends = b
 }